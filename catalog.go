@@ -0,0 +1,498 @@
+package stres
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+// Compiled catalog layout, all integers are little endian uint32:
+//
+//	header     "STRC", version, locale count
+//	locales    name offset, name length, index offset, bucket count; one per locale, sorted by name
+//	data       locale names, indexes and entries
+//
+// An index is an open addressing hash table of entry offsets, 0 for empty buckets, probed linearly
+// from the FNV-1a hash of the entry key. An entry is a key ("<kind>\x00<name>") and a value, each
+// prefixed by its uvarint length. Values are encoded by kind:
+//
+//	string                  the string
+//	integer                 varint
+//	bool                    one byte, 0 or 1
+//	string-array, array     uvarint item count, then every item prefixed by its uvarint length
+//	integer-array           uvarint item count, then every item as varint
+//	plurals                 uvarint item count, then quantity and value of every item, prefixed by their uvarint length
+const (
+	catalogMagic     = "STRC"
+	catalogVersion   = 1
+	catalogHeaderLen = 12
+	catalogLocaleLen = 16
+)
+
+var ErrorInvalidCatalog error = errors.New("stres: invalid compiled catalog")
+
+/*
+	Compiled resources of one or more locales, looked up in place without decoding the whole catalog.
+	Lookups of resources missing in a locale fall back to the default locale. Metadata is not compiled.
+*/
+type Catalog struct {
+	data    []byte
+	locales map[string]catalogIndex
+	close   func() error
+}
+
+// catalogIndex locates the hash index of a locale.
+type catalogIndex struct {
+	offset, buckets uint32
+}
+
+// catalogEntry is a compiled resource.
+type catalogEntry struct {
+	key, value []byte
+}
+
+/*
+	Compiles the resources of every locale, the empty string being the default one, into a catalog written to w.
+*/
+func CompileCatalog(w io.Writer, locales map[string]*types.Nesting) error {
+	names := make([]string, 0, len(locales))
+	for locale := range locales {
+		names = append(names, locale)
+	}
+	sort.Strings(names)
+
+	buf := make([]byte, catalogHeaderLen+catalogLocaleLen*len(names))
+	copy(buf, catalogMagic)
+	binary.LittleEndian.PutUint32(buf[4:], catalogVersion)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(len(names)))
+
+	for i, locale := range names {
+		entries := catalogEntries(locales[locale])
+		buckets := uint32(1)
+		for buckets < uint32(2*len(entries)) {
+			buckets *= 2
+		}
+
+		dir := buf[catalogHeaderLen+catalogLocaleLen*i:]
+		binary.LittleEndian.PutUint32(dir, uint32(len(buf)))
+		binary.LittleEndian.PutUint32(dir[4:], uint32(len(locale)))
+		buf = append(buf, locale...)
+
+		index := len(buf)
+		dir = buf[catalogHeaderLen+catalogLocaleLen*i:]
+		binary.LittleEndian.PutUint32(dir[8:], uint32(index))
+		binary.LittleEndian.PutUint32(dir[12:], buckets)
+		buf = append(buf, make([]byte, 4*buckets)...)
+
+		for _, e := range entries {
+			b := catalogHash(e.key) & (buckets - 1)
+			for binary.LittleEndian.Uint32(buf[index+4*int(b):]) != 0 {
+				b = (b + 1) & (buckets - 1)
+			}
+			binary.LittleEndian.PutUint32(buf[index+4*int(b):], uint32(len(buf)))
+
+			buf = binary.AppendUvarint(buf, uint64(len(e.key)))
+			buf = append(buf, e.key...)
+			buf = binary.AppendUvarint(buf, uint64(len(e.value)))
+			buf = append(buf, e.value...)
+		}
+	}
+
+	if uint64(len(buf)) > 1<<32-1 {
+		return fmt.Errorf("%w: larger than 4 GiB", ErrorInvalidCatalog)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// catalogEntries encodes the resources in n, with references resolved as when loading them.
+func catalogEntries(n *types.Nesting) []catalogEntry {
+	integers, bools, integerArrays := resolveReferences(n)
+
+	var entries []catalogEntry
+	add := func(kind, name string, value []byte) {
+		entries = append(entries, catalogEntry{key: catalogKey(kind, name), value: value})
+	}
+	appendString := func(b []byte, s string) []byte {
+		b = binary.AppendUvarint(b, uint64(len(s)))
+		return append(b, s...)
+	}
+
+	for _, s := range n.Strings {
+		add(KindString, s.Name, []byte(s.Value))
+	}
+	for _, sa := range n.StringsArray {
+		b := binary.AppendUvarint(nil, uint64(len(sa.Items)))
+		for _, item := range sa.Items {
+			b = appendString(b, item.Value)
+		}
+		add(KindStringArray, sa.Name, b)
+	}
+	for _, pl := range n.Plurals {
+		b := binary.AppendUvarint(nil, uint64(len(pl.Items)))
+		for _, item := range pl.Items {
+			b = appendString(b, item.Quantity)
+			b = appendString(b, item.Value)
+		}
+		add(KindPlurals, pl.Name, b)
+	}
+	for _, i := range integers {
+		add(KindInteger, i.Name, binary.AppendVarint(nil, int64(i.Value)))
+	}
+	for _, b := range bools {
+		if b.Value {
+			add(KindBool, b.Name, []byte{1})
+		} else {
+			add(KindBool, b.Name, []byte{0})
+		}
+	}
+	for _, ia := range integerArrays {
+		b := binary.AppendUvarint(nil, uint64(len(ia.Items)))
+		for _, item := range ia.Items {
+			b = binary.AppendVarint(b, int64(item.Value))
+		}
+		add(KindIntegerArray, ia.Name, b)
+	}
+	for _, a := range n.Arrays {
+		b := binary.AppendUvarint(nil, uint64(len(a.Items)))
+		for _, item := range a.Items {
+			b = appendString(b, item.Value)
+		}
+		add(KindArray, a.Name, b)
+	}
+	return entries
+}
+
+func catalogKey(kind, name string) []byte {
+	return []byte(kind + "\x00" + name)
+}
+
+func catalogHash(key []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(key)
+	return h.Sum32()
+}
+
+/*
+	Compiles the resources of the default locale and of every translation into a catalog written to the file at path.
+	Uses setted resource file extension.
+*/
+func WriteCatalog(path string) error {
+	nestings, err := readLocales(fileType)
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.Buffer{}
+	if err = CompileCatalog(&buf, nestings); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0666)
+}
+
+/*
+	Returns the catalog compiled in data, e.g. embedded with go:embed. data must not be modified while the catalog is used.
+*/
+func NewCatalog(data []byte) (*Catalog, error) {
+	if len(data) < catalogHeaderLen || string(data[:4]) != catalogMagic {
+		return nil, fmt.Errorf("%w: bad header", ErrorInvalidCatalog)
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != catalogVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrorInvalidCatalog, v)
+	}
+
+	count := uint64(binary.LittleEndian.Uint32(data[8:]))
+	if catalogHeaderLen+catalogLocaleLen*count > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: truncated", ErrorInvalidCatalog)
+	}
+
+	c := &Catalog{data: data, locales: map[string]catalogIndex{}}
+	for i := uint64(0); i < count; i++ {
+		dir := data[catalogHeaderLen+catalogLocaleLen*i:]
+		nameOff, nameLen := uint64(binary.LittleEndian.Uint32(dir)), uint64(binary.LittleEndian.Uint32(dir[4:]))
+		idx := catalogIndex{offset: binary.LittleEndian.Uint32(dir[8:]), buckets: binary.LittleEndian.Uint32(dir[12:])}
+
+		if nameOff+nameLen > uint64(len(data)) || uint64(idx.offset)+4*uint64(idx.buckets) > uint64(len(data)) ||
+			idx.buckets == 0 || idx.buckets&(idx.buckets-1) != 0 {
+			return nil, fmt.Errorf("%w: truncated", ErrorInvalidCatalog)
+		}
+		c.locales[string(data[nameOff:nameOff+nameLen])] = idx
+	}
+	return c, nil
+}
+
+/*
+	Opens the catalog compiled in the file at path. The file is memory-mapped where supported, unless the
+	stres_nommap build tag is set, and must not be modified until the catalog is closed.
+*/
+func OpenCatalog(path string) (*Catalog, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewCatalog(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	c.close = unmap
+	return c, nil
+}
+
+/*
+	Releases the file of a catalog opened by OpenCatalog. The catalog can't be used afterwards.
+*/
+func (c *Catalog) Close() error {
+	if c.close == nil {
+		return nil
+	}
+	err := c.close()
+	c.data, c.locales, c.close = nil, nil, nil
+	return err
+}
+
+/*
+	Returns the locales of the catalog, sorted by name. The default locale is not included.
+*/
+func (c *Catalog) Locales() []string {
+	var locales []string
+	for locale := range c.locales {
+		if locale != "" {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// lookup returns the value of a resource of the locale, falling back to the default locale.
+func (c *Catalog) lookup(locale, kind, name string) ([]byte, error) {
+	key := catalogKey(kind, name)
+	for _, l := range []string{locale, ""} {
+		idx, ok := c.locales[l]
+		if !ok {
+			continue
+		}
+
+		mask := idx.buckets - 1
+		for b, probes := catalogHash(key)&mask, uint32(0); probes < idx.buckets; b, probes = (b+1)&mask, probes+1 {
+			off := uint64(binary.LittleEndian.Uint32(c.data[uint64(idx.offset)+4*uint64(b):]))
+			if off == 0 {
+				break
+			}
+
+			k, rest, ok := catalogBytes(c.data, off)
+			if !ok {
+				return nil, fmt.Errorf("%w: bad entry at %d", ErrorInvalidCatalog, off)
+			}
+			if !bytes.Equal(k, key) {
+				continue
+			}
+			value, _, ok := catalogBytes(c.data, rest)
+			if !ok {
+				return nil, fmt.Errorf("%w: bad entry at %d", ErrorInvalidCatalog, off)
+			}
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %q", ErrorNotFound, kind, name)
+}
+
+// catalogBytes reads the length-prefixed bytes at off in data, returning them and the offset following them.
+func catalogBytes(data []byte, off uint64) ([]byte, uint64, bool) {
+	if off >= uint64(len(data)) {
+		return nil, 0, false
+	}
+	n, size := binary.Uvarint(data[off:])
+	if size <= 0 {
+		return nil, 0, false
+	}
+	start := off + uint64(size)
+	if n > uint64(len(data))-start {
+		return nil, 0, false
+	}
+	return data[start : start+n], start + n, true
+}
+
+// catalogList decodes the items of an array or quantity string value, fields strings per item.
+func catalogList(value []byte, fields int) ([]string, error) {
+	n, size := binary.Uvarint(value)
+	if size <= 0 || n > uint64(len(value)) {
+		return nil, fmt.Errorf("%w: bad list", ErrorInvalidCatalog)
+	}
+
+	list := make([]string, 0, n*uint64(fields))
+	off := uint64(size)
+	for i := uint64(0); i < n*uint64(fields); i++ {
+		b, next, ok := catalogBytes(value, off)
+		if !ok {
+			return nil, fmt.Errorf("%w: bad list", ErrorInvalidCatalog)
+		}
+		list = append(list, string(b))
+		off = next
+	}
+	return list, nil
+}
+
+// catalogPlural decodes a quantity string value.
+func catalogPlural(name string, value []byte) (types.Plural, error) {
+	items, err := catalogList(value, 2)
+	if err != nil {
+		return types.Plural{}, err
+	}
+
+	pl := types.Plural{Name: name}
+	for i := 0; i+1 < len(items); i += 2 {
+		pl.Items = append(pl.Items, &types.PluralItem{Quantity: items[i], Value: items[i+1]})
+	}
+	return pl, nil
+}
+
+// catalogInt decodes an integer value.
+func catalogInt(value []byte) (int, error) {
+	i, size := binary.Varint(value)
+	if size <= 0 {
+		return 0, fmt.Errorf("%w: bad integer", ErrorInvalidCatalog)
+	}
+	return int(i), nil
+}
+
+// catalogBool decodes a bool value.
+func catalogBool(value []byte) (bool, error) {
+	if len(value) != 1 {
+		return false, fmt.Errorf("%w: bad bool", ErrorInvalidCatalog)
+	}
+	return value[0] == 1, nil
+}
+
+// catalogIntArray decodes an integer-array value.
+func catalogIntArray(value []byte) ([]int, error) {
+	n, off := binary.Uvarint(value)
+	if off <= 0 || n > uint64(len(value)) {
+		return nil, fmt.Errorf("%w: bad integer-array", ErrorInvalidCatalog)
+	}
+
+	arr := make([]int, 0, n)
+	for i := uint64(0); i < n; i++ {
+		v, size := binary.Varint(value[off:])
+		if size <= 0 {
+			return nil, fmt.Errorf("%w: bad integer-array", ErrorInvalidCatalog)
+		}
+		arr = append(arr, int(v))
+		off += size
+	}
+	return arr, nil
+}
+
+/*
+	Returns the string resource's value with the given name in the given locale.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (c *Catalog) LookupString(locale, name string) (string, error) {
+	value, err := c.lookup(locale, KindString, name)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+/*
+	Returns the string-array resource's values with the given name in the given locale.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (c *Catalog) LookupArrayString(locale, name string) ([]string, error) {
+	value, err := c.lookup(locale, KindStringArray, name)
+	if err != nil {
+		return nil, err
+	}
+	return catalogList(value, 1)
+}
+
+/*
+	Returns the quantity string resource's corresponding string value in the given locale based on the value of the
+	given count parameter. If the plural is not found, returns an error wrapping ErrorNotFound;
+	if it has no value for the count's quantity, returns ErrorQuantityStringPluralNotFound.
+*/
+func (c *Catalog) LookupQuantityString(locale, name string, count int) (string, error) {
+	value, err := c.lookup(locale, KindPlurals, name)
+	if err != nil {
+		return "", err
+	}
+	pl, err := catalogPlural(name, value)
+	if err != nil {
+		return "", err
+	}
+
+	quantity := quantityOf(count)
+	for _, item := range pl.Items {
+		if item.Quantity == quantity {
+			return item.Value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s %q count %d", ErrorQuantityStringPluralNotFound, KindPlurals, name, count)
+}
+
+/*
+	Returns the integer resource's value with the given name in the given locale.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (c *Catalog) LookupInt(locale, name string) (int, error) {
+	value, err := c.lookup(locale, KindInteger, name)
+	if err != nil {
+		return 0, err
+	}
+	return catalogInt(value)
+}
+
+/*
+	Returns the bool resource's value with the given name in the given locale.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (c *Catalog) LookupBool(locale, name string) (bool, error) {
+	value, err := c.lookup(locale, KindBool, name)
+	if err != nil {
+		return false, err
+	}
+	return catalogBool(value)
+}
+
+/*
+	Returns the integer-array resource's values with the given name in the given locale.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (c *Catalog) LookupIntArray(locale, name string) ([]int, error) {
+	value, err := c.lookup(locale, KindIntegerArray, name)
+	if err != nil {
+		return nil, err
+	}
+	return catalogIntArray(value)
+}
+
+/*
+	Returns the typed array resource's items with the given name in the given locale.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (c *Catalog) LookupArray(locale, name string) ([]string, error) {
+	value, err := c.lookup(locale, KindArray, name)
+	if err != nil {
+		return nil, err
+	}
+	return catalogList(value, 1)
+}
+
+// readFile reads the whole file at path, for platforms without memory mapping.
+func readFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,32 @@
+//go:build unix && !stres_nommap
+
+package stres
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile memory-maps the file at path read-only, returning its contents and the function unmapping them.
+func mapFile(path string) ([]byte, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.Size() == 0 || int64(int(fi.Size())) != fi.Size() {
+		// Can't be mapped, let NewCatalog report it.
+		return readFile(path)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix || stres_nommap
+
+package stres
+
+// mapFile reads the file at path, where memory mapping is not supported or disabled.
+func mapFile(path string) ([]byte, func() error, error) {
+	return readFile(path)
+}
@@ -0,0 +1,169 @@
+package stres
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+func TestCatalog(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetResourceType(XML) })
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="app_name">stres</string>
+	<string name="welcome">Welcome</string>
+	<string-array name="planets">
+		<item>Mercury</item>
+		<item>Venus</item>
+	</string-array>
+	<plurals name="files">
+		<item quantity="one">one file</item>
+		<item quantity="many">many files</item>
+	</plurals>
+	<integer name="max_retries">3</integer>
+	<bool name="beta">true</bool>
+	<integer-array name="limits">
+		<item>10</item>
+		<item>-20</item>
+	</integer-array>
+	<array name="icons">
+		<item>@drawable/home</item>
+	</array>
+</resources>`)
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string name="welcome">Benvenuto</string>
+	<plurals name="files">
+		<item quantity="one">un file</item>
+	</plurals>
+</resources>`)
+
+	SetResourceType(XML)
+	if err := WriteCatalog("strings.cat"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenCatalog("strings.cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if got := c.Locales(); !reflect.DeepEqual(got, []string{"it"}) {
+		t.Errorf("Locales() = %v, want [it]", got)
+	}
+	if got, err := c.LookupString("it", "welcome"); err != nil || got != "Benvenuto" {
+		t.Errorf("LookupString(it, welcome) = %q, %v, want Benvenuto", got, err)
+	}
+	if got, err := c.LookupString("it", "app_name"); err != nil || got != "stres" {
+		t.Errorf("LookupString(it, app_name) = %q, %v, want the default value", got, err)
+	}
+	if got, err := c.LookupArrayString("", "planets"); err != nil || !reflect.DeepEqual(got, []string{"Mercury", "Venus"}) {
+		t.Errorf("LookupArrayString() = %v, %v", got, err)
+	}
+	if got, err := c.LookupQuantityString("it", "files", 1); err != nil || got != "un file" {
+		t.Errorf("LookupQuantityString(it, files, 1) = %q, %v, want un file", got, err)
+	}
+	if _, err := c.LookupQuantityString("it", "files", 5); !errors.Is(err, ErrorQuantityStringPluralNotFound) {
+		t.Errorf("LookupQuantityString(it, files, 5) error = %v, want %v", err, ErrorQuantityStringPluralNotFound)
+	}
+	if got, err := c.LookupInt("", "max_retries"); err != nil || got != 3 {
+		t.Errorf("LookupInt() = %d, %v, want 3", got, err)
+	}
+	if got, err := c.LookupBool("", "beta"); err != nil || !got {
+		t.Errorf("LookupBool() = %v, %v, want true", got, err)
+	}
+	if got, err := c.LookupIntArray("", "limits"); err != nil || !reflect.DeepEqual(got, []int{10, -20}) {
+		t.Errorf("LookupIntArray() = %v, %v", got, err)
+	}
+	if got, err := c.LookupArray("", "icons"); err != nil || !reflect.DeepEqual(got, []string{"@drawable/home"}) {
+		t.Errorf("LookupArray() = %v, %v", got, err)
+	}
+	if _, err := c.LookupString("", "planets"); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("LookupString() of a string-array error = %v, want %v", err, ErrorNotFound)
+	}
+
+	for _, data := range [][]byte{nil, []byte("STRC\x02\x00\x00\x00\x00\x00\x00\x00"), []byte("STRC\x01\x00\x00\x00\x01\x00\x00\x00")} {
+		if _, err := NewCatalog(data); !errors.Is(err, ErrorInvalidCatalog) {
+			t.Errorf("NewCatalog(%q) error = %v, want %v", data, err, ErrorInvalidCatalog)
+		}
+	}
+}
+
+// benchmarkNesting returns n strings, as a big catalog would.
+func benchmarkNesting(n int) *types.Nesting {
+	nesting := &types.Nesting{}
+	for i := 0; i < n; i++ {
+		nesting.Strings = append(nesting.Strings, &types.String{
+			Name:  fmt.Sprintf("bench_string_%d", i),
+			Value: fmt.Sprintf("Value of the string number %d, long enough to look like a real one", i),
+		})
+	}
+	return nesting
+}
+
+func BenchmarkLoadValues(b *testing.B) {
+	inTempDir(b)
+	b.Cleanup(func() { SetResourceType(XML) })
+
+	n := benchmarkNesting(10000)
+	for _, t := range []types.FileType{XML, YAML, JSON, TOML, WATSON, MSGPACK, ARB, I18NEXT} {
+		writeFile(b, ResourcePath("", t), "")
+		if err := writeNesting(ResourcePath("", t), t, n); err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(string(t), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := LoadValues(t); err != nil {
+					b.Fatal(err)
+				}
+				if GetString("bench_string_5000") == "" {
+					b.Fatal("string not loaded")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkOpenCatalog(b *testing.B) {
+	inTempDir(b)
+
+	buf := bytes.Buffer{}
+	if err := CompileCatalog(&buf, map[string]*types.Nesting{"": benchmarkNesting(10000)}); err != nil {
+		b.Fatal(err)
+	}
+	writeFile(b, "strings.cat", buf.String())
+
+	for i := 0; i < b.N; i++ {
+		c, err := OpenCatalog("strings.cat")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if s, _ := c.LookupString("", "bench_string_5000"); s == "" {
+			b.Fatal("string not found")
+		}
+		c.Close()
+	}
+}
+
+func BenchmarkCatalog_LookupString(b *testing.B) {
+	buf := bytes.Buffer{}
+	if err := CompileCatalog(&buf, map[string]*types.Nesting{"": benchmarkNesting(10000)}); err != nil {
+		b.Fatal(err)
+	}
+	c, err := NewCatalog(buf.Bytes())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.LookupString("", "bench_string_5000"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
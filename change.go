@@ -0,0 +1,60 @@
+package stres
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Resource kinds, named after their XML elements.
+const (
+	KindString       = "string"
+	KindStringArray  = "string-array"
+	KindPlurals      = "plurals"
+	KindInteger      = "integer"
+	KindBool         = "bool"
+	KindIntegerArray = "integer-array"
+	KindArray        = "array"
+)
+
+type ChangeType int
+
+const (
+	ChangeAdded ChangeType = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+/*
+	A single value added, removed or modified in a resource file.
+	Key holds the item index for arrays and the quantity for quantity strings.
+*/
+type Change struct {
+	Type   ChangeType
+	Locale string
+	Kind   string
+	Name   string
+	Key    string
+	Old    string
+	New    string
+}
+
+func (c Change) String() string {
+	locale := c.Locale
+	if locale == "" {
+		locale = "default"
+	}
+
+	name := c.Name
+	if c.Key != "" {
+		name += "[" + c.Key + "]"
+	}
+
+	switch c.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s %s %s: %s", locale, c.Kind, name, strconv.Quote(c.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s %s %s: %s", locale, c.Kind, name, strconv.Quote(c.Old))
+	default:
+		return fmt.Sprintf("~ %s %s %s: %s -> %s", locale, c.Kind, name, strconv.Quote(c.Old), strconv.Quote(c.New))
+	}
+}
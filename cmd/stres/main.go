@@ -0,0 +1,418 @@
+// Command stres manages string resource files from the command line.
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/Vinetwigs/stres"
+	"github.com/Vinetwigs/stres/types"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"autotranslate": {usage: "fill the values missing in a locale with machine translations", run: runAutotranslate},
+	"compile":       {usage: "compile the resources of every locale into a binary catalog", run: runCompile},
+	"diff":          {usage: "list the changes between two resource files", run: runDiff},
+	"export":        {usage: "export resources of every locale to a spreadsheet", run: runExport},
+	"fmt":           {usage: "rewrite resource files in canonical form", run: runFmt},
+	"gen":           {usage: "generate Go code embedding the resources of every locale", run: runGen},
+	"import":        {usage: "apply an edited spreadsheet to the resource files", run: runImport},
+	"keygen":        {usage: "generate an ed25519 key pair for signing resources", run: runKeygen},
+	"merge":         {usage: "three-way merge resource files, as a git merge driver", run: runMerge},
+	"pseudo":        {usage: "write a pseudo-locale derived from the default resources", run: runPseudo},
+	"serve":         {usage: "serve a web editor and a REST API managing the resources of every locale", run: runServe},
+	"sign":          {usage: "sign the resources of every locale with a manifest of hashes, or a single file", run: runSign},
+	"untranslated":  {usage: "list the translatable values missing in a locale", run: runUntranslated},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "stres:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: stres <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", name, commands[name].usage)
+	}
+}
+
+// newFlagSet returns a flag set with the -format flag shared by every command.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("stres "+name, flag.ExitOnError)
+	format := fs.String("format", string(stres.XML), "resource file format")
+	return fs, format
+}
+
+func setFormat(format string) {
+	stres.SetResourceType(types.FileType(format))
+}
+
+func separator(tsv bool) rune {
+	if tsv {
+		return '\t'
+	}
+	return ','
+}
+
+func runExport(args []string) error {
+	fs, format := newFlagSet("export")
+	csv := fs.Bool("csv", false, "write comma separated values (default)")
+	tsv := fs.Bool("tsv", false, "write tab separated values")
+	out := fs.String("o", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	if *csv && *tsv {
+		return fmt.Errorf("usage: stres export [-format xml] [-csv|-tsv] [-o file]: -csv and -tsv are mutually exclusive")
+	}
+
+	setFormat(*format)
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return stres.ExportCSV(w, separator(*tsv))
+}
+
+func runImport(args []string) error {
+	fs, format := newFlagSet("import")
+	tsv := fs.Bool("tsv", false, "read tab separated values")
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: stres import [flags] <spreadsheet>")
+	}
+
+	setFormat(*format)
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	changes, err := stres.ImportCSV(f, separator(*tsv), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return nil
+}
+
+func runPseudo(args []string) error {
+	fs, format := newFlagSet("pseudo")
+	locale := fs.String("locale", "en-XA", "pseudo-locale to write")
+	bidi := fs.Bool("bidi", false, "mirror text right-to-left (default for ar-XB)")
+	expansion := fs.Float64("expansion", stres.PseudoAccented.Expansion, "ratio of padding added to the text")
+	brackets := fs.Bool("brackets", true, "surround values with brackets")
+	fs.Parse(args)
+
+	setFormat(*format)
+
+	opts := stres.PseudoOptions{Accents: true, Expansion: *expansion, Brackets: *brackets}
+	if *bidi || *locale == "ar-XB" {
+		opts = stres.PseudoBidi
+	}
+	return stres.WritePseudoLocale(*locale, opts)
+}
+
+func runUntranslated(args []string) error {
+	fs, format := newFlagSet("untranslated")
+	locale := fs.String("locale", "", "locale to check")
+	fs.Parse(args)
+
+	if *locale == "" {
+		return fmt.Errorf("usage: stres untranslated -locale <locale> [flags]")
+	}
+
+	t := types.FileType(*format)
+	if err := stres.LoadValues(t); err != nil {
+		return err
+	}
+	if err := stres.LoadLocale(*locale, t); err != nil {
+		return err
+	}
+
+	changes := stres.Untranslated(*locale)
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("%d untranslated values in %s", len(changes), *locale)
+	}
+	return nil
+}
+
+func runFmt(args []string) error {
+	fs, format := newFlagSet("fmt")
+	sorted := fs.Bool("sort", false, "sort resources by name")
+	check := fs.Bool("check", false, "only list the files that are not formatted")
+	fs.Parse(args)
+
+	setFormat(*format)
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		// Every locale of the current directory.
+		locales, err := stres.Locales()
+		if err != nil {
+			return err
+		}
+		for _, locale := range append([]string{""}, locales...) {
+			paths = append(paths, stres.ResourcePath(locale, types.FileType(*format)))
+		}
+	}
+
+	unformatted := 0
+	for _, path := range paths {
+		changed, err := stres.FormatFile(path, stres.FormatOptions{Sort: *sorted, Check: *check})
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Println(path)
+			unformatted++
+		}
+	}
+
+	if *check && unformatted > 0 {
+		return fmt.Errorf("%d files are not formatted", unformatted)
+	}
+	return nil
+}
+
+func runDiff(args []string) error {
+	fs, format := newFlagSet("diff")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: stres diff [flags] <old> <new>")
+	}
+
+	changes, err := stres.DiffFiles(fs.Arg(0), fs.Arg(1), types.FileType(*format))
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return nil
+}
+
+func runMerge(args []string) error {
+	fs, format := newFlagSet("merge")
+	fs.Parse(args)
+
+	if fs.NArg() != 3 {
+		return fmt.Errorf("usage: stres merge [flags] <base> <ours> <theirs>")
+	}
+
+	conflicts, err := stres.MergeFiles(fs.Arg(0), fs.Arg(1), fs.Arg(2), types.FileType(*format))
+	if err != nil {
+		return err
+	}
+	for _, c := range conflicts {
+		fmt.Fprintln(os.Stderr, c)
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%d conflicts, ours left unchanged", len(conflicts))
+	}
+	return nil
+}
+
+func runCompile(args []string) error {
+	fs, format := newFlagSet("compile")
+	out := fs.String("o", "strings.cat", "output file")
+	fs.Parse(args)
+
+	setFormat(*format)
+
+	return stres.WriteCatalog(*out)
+}
+
+func runGen(args []string) error {
+	fs, format := newFlagSet("gen")
+	embed := fs.Bool("embed", false, "generate static resource tables registered at init")
+	pkg := fs.String("pkg", "main", "package of the generated file")
+	out := fs.String("o", "stres_gen.go", "output file")
+	fs.Parse(args)
+
+	if !*embed {
+		return fmt.Errorf("usage: stres gen -embed [-pkg main] [-o stres_gen.go] [flags]")
+	}
+
+	setFormat(*format)
+
+	return stres.WriteEmbedded(*out, *pkg)
+}
+
+func runKeygen(args []string) error {
+	fs := flag.NewFlagSet("stres keygen", flag.ExitOnError)
+	out := fs.String("o", "stres", "output file prefix, the public key being written to <prefix>.pub")
+	fs.Parse(args)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(*out, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*out+".pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644)
+}
+
+func runServe(args []string) error {
+	fs, format := newFlagSet("serve")
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	fs.Parse(args)
+
+	if err := stres.LoadValues(types.FileType(*format)); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "serving the editor on http://%s/ and the REST API on http://%s/api/\n", *addr, *addr)
+	return http.ListenAndServe(*addr, stres.EditorHandler())
+}
+
+func runSign(args []string) error {
+	fs, format := newFlagSet("sign")
+	keyFile := fs.String("key", "", "PEM file of the ed25519 private key")
+	file := fs.String("file", "", "sign only this file, e.g. one loaded remotely, writing <file>.sig")
+	fs.Parse(args)
+
+	if *keyFile == "" {
+		return fmt.Errorf("usage: stres sign -key file [flags]")
+	}
+
+	data, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("%s: no PEM data", *keyFile)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return fmt.Errorf("%s: not an ed25519 private key", *keyFile)
+	}
+
+	if *file != "" {
+		return stres.SignFile(*file, priv)
+	}
+
+	setFormat(*format)
+
+	return stres.SignResources(priv)
+}
+
+func runAutotranslate(args []string) error {
+	fs, format := newFlagSet("autotranslate")
+	locale := fs.String("locale", "", "locale to fill")
+	from := fs.String("from", "en", "language of the default resources")
+	command := fs.String("command", "", "translation command reading the text on stdin, {from} and {to} being replaced by the languages")
+	fake := fs.Bool("fake", false, "use the deterministic fake translator")
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+	fs.Parse(args)
+
+	var tr stres.Translator
+	switch {
+	case *locale == "" || (*command == "") == !*fake:
+		return fmt.Errorf("usage: stres autotranslate -locale <locale> (-command <command> | -fake) [flags]")
+	case *fake:
+		tr = stres.FakeTranslator{}
+	default:
+		tr = commandTranslator(strings.Fields(*command))
+	}
+
+	setFormat(*format)
+
+	changes, err := stres.AutoTranslate(context.Background(), tr, *from, *locale, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return nil
+}
+
+// commandTranslator translates by running a command with the text on stdin, printing the translation.
+type commandTranslator []string
+
+func (c commandTranslator) Translate(ctx context.Context, src, from, to string) (string, error) {
+	args := make([]string, len(c))
+	for i, arg := range c {
+		args[i] = strings.NewReplacer("{from}", from, "{to}", to).Replace(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(src)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
@@ -0,0 +1,155 @@
+package stres
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+)
+
+/*
+	A compression of resource files, detected by the suffix following the format extension (e.g. "strings.xml.gz")
+	or by the magic bytes starting the file. Compressed files are read and written transparently.
+*/
+type Compression struct {
+	Suffix    string // file suffix, including the dot
+	Magic     []byte // bytes starting every compressed file
+	NewReader func(r io.Reader) (io.Reader, error)
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+}
+
+// Gzip compression, registered by default.
+var Gzip = Compression{
+	Suffix:    ".gz",
+	Magic:     []byte{0x1f, 0x8b},
+	NewReader: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+}
+
+var compressions = []Compression{Gzip}
+var compressions_mu sync.RWMutex
+
+/*
+	Registers a compression of resource files, replacing the one with the same suffix. Gzip is registered by default.
+	For example, zstd with github.com/klauspost/compress/zstd:
+
+		stres.RegisterCompression(stres.Compression{
+			Suffix:    ".zst",
+			Magic:     []byte{0x28, 0xb5, 0x2f, 0xfd},
+			NewReader: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+		})
+*/
+func RegisterCompression(c Compression) {
+	compressions_mu.Lock()
+	defer compressions_mu.Unlock()
+
+	for i := range compressions {
+		if compressions[i].Suffix == c.Suffix {
+			compressions[i] = c
+			return
+		}
+	}
+	compressions = append(compressions, c)
+}
+
+// compressionOf returns the compression of path by suffix, or else of its data by magic bytes.
+func compressionOf(path string, data []byte) (Compression, bool) {
+	compressions_mu.RLock()
+	defer compressions_mu.RUnlock()
+
+	for _, c := range compressions {
+		if strings.HasSuffix(path, c.Suffix) {
+			return c, true
+		}
+	}
+	for _, c := range compressions {
+		if len(c.Magic) > 0 && bytes.HasPrefix(data, c.Magic) {
+			return c, true
+		}
+	}
+	return Compression{}, false
+}
+
+// magicLength returns the length of the longest magic bytes, enough to detect the compression of a file.
+func magicLength() int {
+	compressions_mu.RLock()
+	defer compressions_mu.RUnlock()
+
+	n := 0
+	for _, c := range compressions {
+		n = max(n, len(c.Magic))
+	}
+	return n
+}
+
+// trimCompression removes the compression suffix of path, if any.
+func trimCompression(path string) string {
+	compressions_mu.RLock()
+	defer compressions_mu.RUnlock()
+
+	for _, c := range compressions {
+		if strings.HasSuffix(path, c.Suffix) {
+			return strings.TrimSuffix(path, c.Suffix)
+		}
+	}
+	return path
+}
+
+// compressedPath returns path or, if it doesn't exist, its first existing compressed variant.
+func compressedPath(path string) string {
+	if _, err := store.Stat(path); err == nil {
+		return path
+	}
+
+	compressions_mu.RLock()
+	defer compressions_mu.RUnlock()
+
+	for _, c := range compressions {
+		if _, err := store.Stat(path + c.Suffix); err == nil {
+			return path + c.Suffix
+		}
+	}
+	return path
+}
+
+// decompress returns the data of the file at path, decompressed if needed.
+func decompress(path string, data []byte) ([]byte, error) {
+	c, ok := compressionOf(path, data)
+	if !ok {
+		return data, nil
+	}
+
+	r, err := c.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return io.ReadAll(r)
+}
+
+// compress returns data compressed as the file at path, by suffix or as the existing file is.
+func compress(path string, data []byte) ([]byte, error) {
+	head, _ := store.Head(path, magicLength())
+
+	c, ok := compressionOf(path, head)
+	if !ok {
+		return data, nil
+	}
+
+	buf := bytes.Buffer{}
+	w, err := c.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,158 @@
+package stres
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, s string) string {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(s))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func readGzipped(t *testing.T, path string) string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("%s is not gzipped: %v", path, err)
+	}
+	b, _ := io.ReadAll(r)
+	return string(b)
+}
+
+func TestCompression(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetResourceType(XML) })
+
+	writeFile(t, "strings/strings.xml.gz", gzipped(t, `<resources>
+	<string name="gz_app_name">stres</string>
+</resources>`))
+	// Compressed without suffix, detected by magic bytes.
+	writeFile(t, "strings-it/strings.xml", gzipped(t, `<resources>
+	<string name="gz_app_name">stres it</string>
+</resources>`))
+
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLocale("it", XML); err != nil {
+		t.Fatal(err)
+	}
+	if GetString("gz_app_name") != "stres" || Locale("it").GetString("gz_app_name") != "stres it" {
+		t.Errorf("compressed files not loaded: %q, %q", GetString("gz_app_name"), Locale("it").GetString("gz_app_name"))
+	}
+
+	if _, err := NewString("gz_welcome", "Welcome"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readGzipped(t, "strings/strings.xml.gz"); !strings.Contains(got, "gz_welcome") {
+		t.Errorf("NewString() didn't update the compressed file:\n%s", got)
+	}
+	if _, err := os.Stat("strings/strings.xml"); !os.IsNotExist(err) {
+		t.Errorf("NewString() created an uncompressed file")
+	}
+
+	if _, err := FormatFile("strings-it/strings.xml", FormatOptions{Sort: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readGzipped(t, "strings-it/strings.xml"); !strings.Contains(got, "stres it") {
+		t.Errorf("FormatFile() lost the file content:\n%s", got)
+	}
+}
+
+func TestRegisterCompression(t *testing.T) {
+	inTempDir(t)
+	saved := append([]Compression{}, compressions...)
+	t.Cleanup(func() {
+		compressions = saved
+		SetResourceType(XML)
+	})
+
+	RegisterCompression(Compression{
+		Suffix:    ".z",
+		Magic:     []byte{0x78},
+		NewReader: func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil },
+	})
+
+	buf := bytes.Buffer{}
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(`string:
+  - name: zlib_app_name
+    value: stres
+`))
+	w.Close()
+	writeFile(t, "strings/strings.yml.z", buf.String())
+
+	if err := LoadValues(YAML); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetString("zlib_app_name"); got != "stres" {
+		t.Errorf("GetString() = %q, want stres", got)
+	}
+}
+
+func TestDeleteResourceFile_compressed(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "strings/strings.xml.gz", gzipped(t, `<resources></resources>`))
+	if err := DeleteResourceFile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("strings"); !os.IsNotExist(err) {
+		t.Errorf("DeleteResourceFile() left the resource directory: %v", err)
+	}
+}
+
+// headStore fails to read whole files, so that only Stat and Head can be used.
+type headStore struct {
+	*MemoryStore
+}
+
+func (s headStore) Get(path string) ([]byte, error) {
+	return nil, errors.New("whole file read")
+}
+
+func TestCompressedPath_noRead(t *testing.T) {
+	s := NewMemoryStore()
+	SetStore(headStore{s})
+	t.Cleanup(func() { SetStore(nil) })
+
+	s.Put("strings/strings.xml.gz", []byte(gzipped(t, "<resources></resources>")))
+	s.Put("strings-it/strings.xml", []byte(gzipped(t, "<resources></resources>")))
+
+	if got := compressedPath("strings/strings.xml"); got != "strings/strings.xml.gz" {
+		t.Errorf("compressedPath() = %q, want strings/strings.xml.gz", got)
+	}
+	if got := compressedPath("strings-it/strings.xml"); got != "strings-it/strings.xml" {
+		t.Errorf("compressedPath() = %q, want strings-it/strings.xml", got)
+	}
+
+	// Compressed without suffix, detected by the head of the existing file.
+	data, err := compress("strings-it/strings.xml", []byte("<resources></resources>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, Gzip.Magic) {
+		t.Errorf("compress() = %q, want gzipped data", data)
+	}
+}
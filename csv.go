@@ -0,0 +1,263 @@
+package stres
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+var (
+	ErrorSpreadsheetHeader error = errors.New("stres: spreadsheet header must be key, kind, quantity, default, <locales...>, description")
+	ErrorSpreadsheetRow    error = errors.New("stres: invalid spreadsheet row")
+)
+
+// Leading and trailing spreadsheet columns, locales are in between.
+var (
+	spreadsheetHead = []string{"key", "kind", "quantity", "default"}
+	spreadsheetTail = []string{"description"}
+)
+
+/*
+	Writes a spreadsheet of the default resource file and its translations to w, using comma as separator
+	(',' for CSV, '\t' for TSV). Each string, string-array item and quantity string quantity has its own row
+	with the default value, one column per locale and the resource's description. Resources marked
+	translatable="false" are skipped. Uses setted resource file extension.
+*/
+func ExportCSV(w io.Writer, comma rune) error {
+	def, _, err := readResources("", fileType)
+	if err != nil {
+		return err
+	}
+
+	locales, err := Locales()
+	if err != nil {
+		return err
+	}
+
+	translations := make([]*types.Nesting, len(locales))
+	for i, locale := range locales {
+		translations[i], _, err = readResources(locale, fileType)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	header := append(append(append([]string{}, spreadsheetHead...), locales...), spreadsheetTail...)
+	if err = cw.Write(header); err != nil {
+		return err
+	}
+
+	row := func(meta types.Meta, name, kind, key, value string, lookup func(n *types.Nesting) string) error {
+		if !meta.IsTranslatable() {
+			return nil
+		}
+		record := []string{name, kind, key, value}
+		for _, n := range translations {
+			record = append(record, lookup(n))
+		}
+		record = append(record, meta.Description)
+		return cw.Write(record)
+	}
+
+	for _, s := range def.Strings {
+		err = row(s.Meta, s.Name, KindString, "", s.Value, func(n *types.Nesting) string {
+			if t := findString(n, s.Name); t != nil {
+				return t.Value
+			}
+			return ""
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, sa := range def.StringsArray {
+		for i, item := range sa.Items {
+			err = row(sa.Meta, sa.Name, KindStringArray, strconv.Itoa(i), item.Value, func(n *types.Nesting) string {
+				if t := findStringArray(n, sa.Name); t != nil && i < len(t.Items) {
+					return t.Items[i].Value
+				}
+				return ""
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, pl := range def.Plurals {
+		for _, item := range pl.Items {
+			err = row(pl.Meta, pl.Name, KindPlurals, item.Quantity, item.Value, func(n *types.Nesting) string {
+				if t := findPlural(n, pl.Name); t != nil {
+					if it := findPluralItem(t, item.Quantity); it != nil {
+						return it.Value
+					}
+				}
+				return ""
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+/*
+	Applies a spreadsheet written by ExportCSV to the resource files of every locale in it, creating missing
+	resources and locale files. Empty cells are left untouched. Values longer than the max length of their resource,
+	or else of the default resource, are refused with an error wrapping ErrorMaxLength. Returns the applied changes;
+	if dryRun is true the changes are only computed and no file is written. Uses setted resource file extension.
+*/
+func ImportCSV(r io.Reader, comma rune, dryRun bool) ([]Change, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	if !isSpreadsheetHeader(header) {
+		return nil, ErrorSpreadsheetHeader
+	}
+
+	// Column 3 is the default locale.
+	locales := append([]string{""}, header[len(spreadsheetHead):len(header)-len(spreadsheetTail)]...)
+	files := make([]*types.Nesting, len(locales))
+	origins := make([]map[resourceKey]resourceFile, len(locales))
+	for i, locale := range locales {
+		files[i], origins[i], err = readResources(locale, fileType)
+		if errors.Is(err, os.ErrNotExist) {
+			files[i], origins[i], err = &types.Nesting{}, nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []Change
+	edits := make([][]resourceEdit, len(locales))
+	edited := make([]map[string]bool, len(locales))
+
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, kind, key := record[0], record[1], record[2]
+		if name == "" {
+			return nil, fmt.Errorf("%w: line %d: empty key", ErrorSpreadsheetRow, line)
+		}
+
+		var set func(n *types.Nesting, value string) (string, bool, error)
+		switch kind {
+		case KindString:
+			set = func(n *types.Nesting, value string) (string, bool, error) {
+				old, existed := setString(n, name, value)
+				return old, existed, nil
+			}
+		case KindStringArray:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("%w: line %d: invalid index %q", ErrorSpreadsheetRow, line, key)
+			}
+			set = func(n *types.Nesting, value string) (string, bool, error) {
+				return setArrayItem(n, name, idx, value)
+			}
+		case KindPlurals:
+			if quantityIndex(key) < 0 {
+				return nil, fmt.Errorf("%w: line %d: invalid quantity %q", ErrorSpreadsheetRow, line, key)
+			}
+			set = func(n *types.Nesting, value string) (string, bool, error) {
+				old, existed := setPluralItem(n, name, key, value)
+				return old, existed, nil
+			}
+		default:
+			return nil, fmt.Errorf("%w: line %d: invalid kind %q", ErrorSpreadsheetRow, line, kind)
+		}
+
+		for i, locale := range locales {
+			value := record[len(spreadsheetHead)-1+i]
+			if value == "" {
+				continue
+			}
+
+			if err := checkMaxLength(maxLength(findResource(files[i], kind, name), findResource(files[0], kind, name)), kind, name, value); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrorSpreadsheetRow, line, err)
+			}
+			old, existed, err := set(files[i], value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrorSpreadsheetRow, line, err)
+			}
+			if existed && old == value {
+				continue
+			}
+
+			c := Change{Type: ChangeModified, Locale: locale, Kind: kind, Name: name, Key: key, Old: old, New: value}
+			if !existed {
+				c.Type = ChangeAdded
+			}
+			changes = append(changes, c)
+
+			if edited[i] == nil {
+				edited[i] = map[string]bool{}
+			}
+			if !edited[i][kind+" "+name] {
+				edited[i][kind+" "+name] = true
+				edits[i] = append(edits[i], resourceEdit{kind: kind, name: name, value: findResource(files[i], kind, name)})
+			}
+		}
+	}
+
+	if dryRun {
+		return changes, nil
+	}
+
+	for i, locale := range locales {
+		if len(edits[i]) == 0 {
+			continue
+		}
+
+		written, err := writeEdits(locale, origins[i], edits[i])
+		if err != nil {
+			return nil, err
+		}
+		if locale == "" {
+			loadNesting(files[i])
+			setOrigins(written)
+		}
+	}
+
+	return changes, nil
+}
+
+func isSpreadsheetHeader(header []string) bool {
+	if len(header) < len(spreadsheetHead)+len(spreadsheetTail) {
+		return false
+	}
+	for i, col := range spreadsheetHead {
+		if header[i] != col {
+			return false
+		}
+	}
+	for i, col := range spreadsheetTail {
+		if header[len(header)-len(spreadsheetTail)+i] != col {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package stres
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDefaultXML = `<resources>
+	<string name="hello">Hello</string>
+	<string-array name="days"><item>Mon</item><item>Tue</item></string-array>
+	<plurals name="files"><item quantity="one">a file</item><item quantity="many">files</item></plurals>
+</resources>`
+
+const testItalianXML = `<resources>
+	<string name="hello">Ciao</string>
+	<string-array name="days"><item>Lun</item></string-array>
+</resources>`
+
+func TestExportCSV(t *testing.T) {
+	inTempDir(t)
+	writeFile(t, "strings/strings.xml", testDefaultXML)
+	writeFile(t, "strings-it/strings.xml", testItalianXML)
+
+	tests := []struct {
+		name  string
+		comma rune
+		want  string
+	}{
+		{
+			name:  "csv",
+			comma: ',',
+			want: "key,kind,quantity,default,it,description\n" +
+				"hello,string,,Hello,Ciao,\n" +
+				"days,string-array,0,Mon,Lun,\n" +
+				"days,string-array,1,Tue,,\n" +
+				"files,plurals,one,a file,,\n" +
+				"files,plurals,many,files,,\n",
+		},
+		{
+			name:  "tsv",
+			comma: '\t',
+			want: "key\tkind\tquantity\tdefault\tit\tdescription\n" +
+				"hello\tstring\t\tHello\tCiao\t\n" +
+				"days\tstring-array\t0\tMon\tLun\t\n" +
+				"days\tstring-array\t1\tTue\t\t\n" +
+				"files\tplurals\tone\ta file\t\t\n" +
+				"files\tplurals\tmany\tfiles\t\t\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			if err := ExportCSV(&buf, tt.comma); err != nil {
+				t.Fatalf("ExportCSV() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("ExportCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	inTempDir(t)
+	writeFile(t, "strings/strings.xml", testDefaultXML)
+	writeFile(t, "strings-it/strings.xml", testItalianXML)
+
+	sheet := "key,kind,quantity,default,it,fr,description\n" +
+		"hello,string,,Hello,Salve,Bonjour,\n" +
+		"days,string-array,1,Tue,Mar,,\n" +
+		"files,plurals,one,a file,un file,,\n"
+
+	want := []Change{
+		{Type: ChangeModified, Locale: "it", Kind: KindString, Name: "hello", Old: "Ciao", New: "Salve"},
+		{Type: ChangeAdded, Locale: "fr", Kind: KindString, Name: "hello", New: "Bonjour"},
+		{Type: ChangeAdded, Locale: "it", Kind: KindStringArray, Name: "days", Key: "1", New: "Mar"},
+		{Type: ChangeAdded, Locale: "it", Kind: KindPlurals, Name: "files", Key: "one", New: "un file"},
+	}
+
+	got, err := ImportCSV(strings.NewReader(sheet), ',', true)
+	if err != nil {
+		t.Fatalf("ImportCSV() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ImportCSV() = %v, want %v", got, want)
+	}
+	if _, err = os.Stat("strings-fr"); !os.IsNotExist(err) {
+		t.Errorf("ImportCSV() wrote files in dry-run mode")
+	}
+
+	if _, err = ImportCSV(strings.NewReader(sheet), ',', false); err != nil {
+		t.Fatalf("ImportCSV() error = %v", err)
+	}
+	got, err = ImportCSV(strings.NewReader(sheet), ',', true)
+	if err != nil {
+		t.Fatalf("ImportCSV() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ImportCSV() after import = %v, want no changes", got)
+	}
+
+	gap := "key,kind,quantity,default,it,description\n" +
+		"days,string-array,5,,Sab,\n"
+	if _, err = ImportCSV(strings.NewReader(gap), ',', true); !errors.Is(err, ErrorSpreadsheetRow) {
+		t.Errorf("ImportCSV() of an index past the end error = %v, want %v", err, ErrorSpreadsheetRow)
+	}
+
+	if _, err = ImportCSV(strings.NewReader("key,kind\n"), ',', true); err != ErrorSpreadsheetHeader {
+		t.Errorf("ImportCSV() error = %v, want %v", err, ErrorSpreadsheetHeader)
+	}
+}
+
+func TestImportCSV_maxLength(t *testing.T) {
+	inTempDir(t)
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="title" maxLength="8">Title</string>
+	<string-array name="days" maxLength="3"><item>Mon</item></string-array>
+</resources>`)
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string name="title" maxLength="10">Titolo</string>
+</resources>`)
+
+	tests := []struct {
+		sheet   string
+		wantErr bool
+	}{
+		{"key,kind,quantity,default,it,description\ntitle,string,,Heading,Titoletto,\n", false},
+		{"key,kind,quantity,default,it,description\ntitle,string,,Headline!,,\n", true},
+		{"key,kind,quantity,default,it,description\ntitle,string,,,Intestazione!,\n", true},
+		{"key,kind,quantity,default,it,description\ndays,string-array,0,,Lun,\n", false},
+		{"key,kind,quantity,default,it,description\ndays,string-array,0,,Lunedì,\n", true},
+	}
+	for _, tt := range tests {
+		_, err := ImportCSV(strings.NewReader(tt.sheet), ',', true)
+		if got := errors.Is(err, ErrorMaxLength); got != tt.wantErr || (got && !errors.Is(err, ErrorSpreadsheetRow)) {
+			t.Errorf("ImportCSV(%q) error = %v, want ErrorMaxLength %v", tt.sheet, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package stres
+
+import "fmt"
+
+/*
+	Returns the string resource's value with the given name. If not exists, returns def.
+*/
+func GetStringOr(name, def string) string {
+	return defaultLocalizer.GetStringOr(name, def)
+}
+
+/*
+	Returns the string-array resource's values with the given name. If not exists, returns def.
+*/
+func GetArrayStringOr(name string, def []string) []string {
+	return defaultLocalizer.GetArrayStringOr(name, def)
+}
+
+/*
+	Returns the quantity string resource's value for the given count. If the plural or its value for the count's
+	quantity does not exist, returns def.
+*/
+func GetQuantityStringOr(name string, count int, def string) string {
+	return defaultLocalizer.GetQuantityStringOr(name, count, def)
+}
+
+/*
+	Returns the string resource's value with the given name. If not exists, panics with a message naming it.
+*/
+func MustGetString(name string) string {
+	return defaultLocalizer.MustGetString(name)
+}
+
+/*
+	Returns the string-array resource's values with the given name. If not exists, panics with a message naming it.
+*/
+func MustGetArrayString(name string) []string {
+	return defaultLocalizer.MustGetArrayString(name)
+}
+
+/*
+	Returns the quantity string resource's value for the given count. If the plural or its value for the count's
+	quantity does not exist, panics with a message naming it.
+*/
+func MustGetQuantityString(name string, count int) string {
+	return defaultLocalizer.MustGetQuantityString(name, count)
+}
+
+/*
+	Returns the string resource's value with the given name. If not exists, returns def.
+*/
+func (l *Localizer) GetStringOr(name, def string) string {
+	val, err := l.LookupString(name)
+	if err != nil {
+		recordLookup(l.locale, KindString, name, LookupDefault)
+		return def
+	}
+	return val
+}
+
+/*
+	Returns the string-array resource's values with the given name. If not exists, returns def.
+*/
+func (l *Localizer) GetArrayStringOr(name string, def []string) []string {
+	arr, err := l.LookupArrayString(name)
+	if err != nil {
+		recordLookup(l.locale, KindStringArray, name, LookupDefault)
+		return def
+	}
+	return arr
+}
+
+/*
+	Returns the quantity string resource's value for the given count. If the plural or its value for the count's
+	quantity does not exist, returns def.
+*/
+func (l *Localizer) GetQuantityStringOr(name string, count int, def string) string {
+	val, err := l.LookupQuantityString(name, count)
+	if err != nil {
+		recordLookup(l.locale, KindPlurals, name, LookupDefault)
+		return def
+	}
+	return val
+}
+
+/*
+	Returns the string resource's value with the given name. If not exists, panics with a message naming it.
+*/
+func (l *Localizer) MustGetString(name string) string {
+	val, err := l.LookupString(name)
+	if err != nil {
+		l.mustPanic("MustGetString", err)
+	}
+	return val
+}
+
+/*
+	Returns the string-array resource's values with the given name. If not exists, panics with a message naming it.
+*/
+func (l *Localizer) MustGetArrayString(name string) []string {
+	arr, err := l.LookupArrayString(name)
+	if err != nil {
+		l.mustPanic("MustGetArrayString", err)
+	}
+	return arr
+}
+
+/*
+	Returns the quantity string resource's value for the given count. If the plural or its value for the count's
+	quantity does not exist, panics with a message naming it.
+*/
+func (l *Localizer) MustGetQuantityString(name string, count int) string {
+	val, err := l.LookupQuantityString(name, count)
+	if err != nil {
+		l.mustPanic("MustGetQuantityString", err)
+	}
+	return val
+}
+
+// mustPanic panics with the error of the lookup of a Must function.
+func (l *Localizer) mustPanic(fn string, err error) {
+	if l.locale == "" {
+		panic(fmt.Sprintf("%v (%s)", err, fn))
+	}
+	panic(fmt.Sprintf("%v (%s in locale %s)", err, fn, l.locale))
+}
@@ -0,0 +1,110 @@
+package stres
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLookupDefaults(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetMetrics(nil)
+		SetResourceType(XML)
+	})
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="defaults_title">Title</string>
+	<string name="defaults_empty"></string>
+	<string-array name="defaults_days"><item>Mon</item></string-array>
+	<plurals name="defaults_files">
+		<item quantity="one">%d file</item>
+	</plurals>
+</resources>`)
+	writeFile(t, "strings-dx/strings.xml", `<resources>
+	<string name="defaults_title">Titolo</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLocale("dx", XML); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &recordingMetrics{}
+	SetMetrics(m)
+
+	if got := GetStringOr("defaults_title", "Untitled"); got != "Title" {
+		t.Errorf("GetStringOr() = %q, want %q", got, "Title")
+	}
+	if got := GetStringOr("defaults_empty", "Untitled"); got != "" {
+		t.Errorf("GetStringOr() of an empty value = %q, want empty", got)
+	}
+	if got := GetStringOr("defaults_missing", "Untitled"); got != "Untitled" {
+		t.Errorf("GetStringOr() = %q, want %q", got, "Untitled")
+	}
+	if got := Locale("dx").GetStringOr("defaults_title", "Untitled"); got != "Titolo" {
+		t.Errorf("Locale().GetStringOr() = %q, want %q", got, "Titolo")
+	}
+	if got := GetArrayStringOr("defaults_days", nil); !reflect.DeepEqual(got, []string{"Mon"}) {
+		t.Errorf("GetArrayStringOr() = %v, want [Mon]", got)
+	}
+	if got := Locale("dx").GetArrayStringOr("defaults_missing", []string{"Tue"}); !reflect.DeepEqual(got, []string{"Tue"}) {
+		t.Errorf("Locale().GetArrayStringOr() = %v, want [Tue]", got)
+	}
+	if got := GetQuantityStringOr("defaults_files", 1, "files"); got != "%d file" {
+		t.Errorf("GetQuantityStringOr() = %q, want %q", got, "%d file")
+	}
+	if got := GetQuantityStringOr("defaults_files", 5, "files"); got != "files" {
+		t.Errorf("GetQuantityStringOr() without the quantity = %q, want %q", got, "files")
+	}
+
+	want := []lookupEvent{
+		{"", KindString, "defaults_title", LookupHit},
+		{"", KindString, "defaults_empty", LookupHit},
+		{"", KindString, "defaults_missing", LookupMiss},
+		{"", KindString, "defaults_missing", LookupDefault},
+		{"dx", KindString, "defaults_title", LookupHit},
+		{"", KindStringArray, "defaults_days", LookupHit},
+		{"dx", KindStringArray, "defaults_missing", LookupMiss},
+		{"dx", KindStringArray, "defaults_missing", LookupDefault},
+		{"", KindPlurals, "defaults_files", LookupHit},
+		{"", KindPlurals, "defaults_files", LookupMiss},
+		{"", KindPlurals, "defaults_files", LookupDefault},
+	}
+	if !reflect.DeepEqual(m.events, want) {
+		t.Errorf("events = %v, want %v", m.events, want)
+	}
+
+	if got := MustGetString("defaults_title"); got != "Title" {
+		t.Errorf("MustGetString() = %q, want %q", got, "Title")
+	}
+	if got := Locale("dx").MustGetArrayString("defaults_days"); !reflect.DeepEqual(got, []string{"Mon"}) {
+		t.Errorf("Locale().MustGetArrayString() = %v, want [Mon]", got)
+	}
+	if got := MustGetQuantityString("defaults_files", 1); got != "%d file" {
+		t.Errorf("MustGetQuantityString() = %q, want %q", got, "%d file")
+	}
+
+	mustPanic := func(name string, f func(), want ...string) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			r := recover()
+			msg, _ := r.(string)
+			if r == nil {
+				t.Errorf("%s did not panic", name)
+				return
+			}
+			for _, w := range want {
+				if !strings.Contains(msg, w) {
+					t.Errorf("%s panic = %q, want it to contain %q", name, msg, w)
+				}
+			}
+		}()
+		f()
+	}
+	mustPanic("MustGetString", func() { MustGetString("defaults_missing") }, `"defaults_missing"`, "MustGetString")
+	mustPanic("MustGetArrayString", func() { Locale("dx").MustGetArrayString("defaults_missing") }, `"defaults_missing"`, "locale dx")
+	mustPanic("MustGetQuantityString", func() { MustGetQuantityString("defaults_files", 5) }, "defaults_files")
+}
@@ -0,0 +1,424 @@
+package stres
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/Vinetwigs/stres/icu"
+	"github.com/Vinetwigs/stres/types"
+)
+
+// dictionary holds the resources of one locale.
+type dictionary struct {
+	// Guards the resource maps, written by loads and edits while lookups run (e.g. by RemoteLoader refreshes).
+	rw sync.RWMutex
+
+	strings map[string]string
+	arrays  map[string]types.StringArray
+	plurals map[string]types.Plural
+
+	integers      map[string]types.Integer
+	bools         map[string]types.Bool
+	integerArrays map[string]types.IntegerArray
+	typedArrays   map[string]types.Array
+
+	// Generated table answering lookups of the resources missing in the maps, nil if none.
+	embedded *EmbeddedTable
+
+	// Metadata of the strings, string-arrays and quantity strings hold their own.
+	meta map[string]types.Meta
+
+	// Parsed ICU messages by string name, filled at load time or on first use.
+	mu       sync.Mutex
+	messages map[string]*icu.Message
+}
+
+func newDictionary() *dictionary {
+	return &dictionary{
+		strings: make(map[string]string),
+		arrays:  make(map[string]types.StringArray),
+		plurals: make(map[string]types.Plural),
+
+		integers:      make(map[string]types.Integer),
+		bools:         make(map[string]types.Bool),
+		integerArrays: make(map[string]types.IntegerArray),
+		typedArrays:   make(map[string]types.Array),
+
+		meta:     make(map[string]types.Meta),
+		messages: make(map[string]*icu.Message),
+	}
+}
+
+// Resources of the default locale.
+var defaultDictionary = &dictionary{
+	strings: string_entries,
+	arrays:  string_array_entries,
+	plurals: plural_string_entries,
+
+	integers:      integer_entries,
+	bools:         bool_entries,
+	integerArrays: integer_array_entries,
+	typedArrays:   typed_array_entries,
+
+	meta:     make(map[string]types.Meta),
+	messages: make(map[string]*icu.Message),
+}
+
+// load copies the resources in n into the dictionary.
+func (d *dictionary) load(n *types.Nesting) {
+	d.rw.Lock()
+	defer d.rw.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+
+	// Load strings
+	go func() {
+		defer wg.Done()
+		for i := 0; i < len(n.Strings); i++ {
+			d.strings[n.Strings[i].Name] = n.Strings[i].Value
+			if n.Strings[i].Meta.IsZero() {
+				delete(d.meta, n.Strings[i].Name)
+			} else {
+				d.meta[n.Strings[i].Name] = n.Strings[i].Meta
+			}
+		}
+	}()
+
+	// Load string arrays
+	go func() {
+		defer wg.Done()
+		for i := 0; i < len(n.StringsArray); i++ {
+			d.arrays[n.StringsArray[i].Name] = *n.StringsArray[i]
+		}
+	}()
+
+	// Load quantity strings
+	go func() {
+		defer wg.Done()
+		for i := 0; i < len(n.Plurals); i++ {
+			d.plurals[n.Plurals[i].Name] = *n.Plurals[i]
+		}
+	}()
+
+	// Load integers, bools, integer-arrays and typed arrays
+	go func() {
+		defer wg.Done()
+		integers, bools, integerArrays := resolveReferences(n)
+		for _, i := range integers {
+			d.integers[i.Name] = *i
+		}
+		for _, b := range bools {
+			d.bools[b.Name] = *b
+		}
+		for _, ia := range integerArrays {
+			d.integerArrays[ia.Name] = *ia
+		}
+		for _, a := range n.Arrays {
+			d.typedArrays[a.Name] = *a
+		}
+	}()
+
+	wg.Wait()
+
+	d.forgetMessages(n)
+}
+
+// replace swaps the resources of the dictionary for the ones in n at once, dropping the resources not in n.
+// The maps are refilled rather than reassigned, as the default dictionary's are shared with the edit functions.
+func (d *dictionary) replace(n *types.Nesting) {
+	fresh := newDictionary()
+	fresh.load(n)
+
+	d.rw.Lock()
+	defer d.rw.Unlock()
+
+	refill(d.strings, fresh.strings)
+	refill(d.arrays, fresh.arrays)
+	refill(d.plurals, fresh.plurals)
+	refill(d.integers, fresh.integers)
+	refill(d.bools, fresh.bools)
+	refill(d.integerArrays, fresh.integerArrays)
+	refill(d.typedArrays, fresh.typedArrays)
+	refill(d.meta, fresh.meta)
+
+	d.mu.Lock()
+	d.messages = fresh.messages
+	d.mu.Unlock()
+}
+
+func refill[V any](dst, src map[string]V) {
+	clear(dst)
+	maps.Copy(dst, src)
+}
+
+// has reports whether a resource of the given kind was loaded or added, not counting the generated table.
+func (d *dictionary) has(kind, name string) bool {
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+
+	var ok bool
+	switch kind {
+	case KindString:
+		_, ok = d.strings[name]
+	case KindStringArray:
+		_, ok = d.arrays[name]
+	case KindPlurals:
+		_, ok = d.plurals[name]
+	case KindInteger:
+		_, ok = d.integers[name]
+	case KindBool:
+		_, ok = d.bools[name]
+	case KindIntegerArray:
+		_, ok = d.integerArrays[name]
+	case KindArray:
+		_, ok = d.typedArrays[name]
+	}
+	return ok
+}
+
+// editDefault runs f, editing the maps of the default resources, holding their lock.
+func editDefault(f func()) {
+	defaultDictionary.rw.Lock()
+	defer defaultDictionary.rw.Unlock()
+	f()
+}
+
+func (d *dictionary) getString(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	d.rw.RLock()
+	val, ok := d.strings[name]
+	d.rw.RUnlock()
+	if ok {
+		return val, true
+	}
+	b, ok := d.embeddedValue(KindString, name)
+	return string(b), ok
+}
+
+// getMeta returns the metadata of the string, string-array or quantity string with the given name.
+func (d *dictionary) getMeta(name string) (types.Meta, bool) {
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+
+	if _, ok := d.strings[name]; ok {
+		return d.meta[name], true
+	}
+	if sa, ok := d.arrays[name]; ok {
+		return sa.Meta, true
+	}
+	if pl, ok := d.plurals[name]; ok {
+		return pl.Meta, true
+	}
+	return types.Meta{}, false
+}
+
+func (d *dictionary) getArrayString(name string) ([]string, bool) {
+	if name == "" {
+		return nil, false
+	}
+
+	d.rw.RLock()
+	sa, ok := d.arrays[name]
+	d.rw.RUnlock()
+	if !ok {
+		return d.embeddedList(KindStringArray, name)
+	}
+
+	var arr []string
+	for i := 0; i < len(sa.Items); i++ {
+		arr = append(arr, sa.Items[i].Value)
+	}
+	return arr, true
+}
+
+func (d *dictionary) getQuantityString(name string, count int) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: %s %q", ErrorNotFound, KindPlurals, name)
+	}
+
+	d.rw.RLock()
+	val, exists := d.plurals[name]
+	d.rw.RUnlock()
+	if !exists {
+		val, exists = d.embeddedPlural(name)
+	}
+	if !exists {
+		return "", fmt.Errorf("%w: %s %q", ErrorNotFound, KindPlurals, name)
+	}
+
+	quantity := quantityOf(count)
+	for i := 0; i < len(val.Items); i++ {
+		if val.Items[i].Quantity == quantity {
+			return val.Items[i].Value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s %q count %d", ErrorQuantityStringPluralNotFound, KindPlurals, name, count)
+}
+
+// quantityOf returns the quantity of quantity strings selected by count.
+func quantityOf(count int) string {
+	idx := -1
+
+	if count == 0 {
+		idx = 0
+	}
+
+	if count == 1 {
+		idx = 1
+	}
+
+	if count == 2 {
+		idx = 2
+	}
+
+	if count > 2 && count <= few_threshold {
+		idx = 3
+	}
+
+	if idx == -1 {
+		idx = 4
+	}
+
+	return quantityValues[idx]
+}
+
+func (d *dictionary) getInt(name string) (int, bool) {
+	d.rw.RLock()
+	i, ok := d.integers[name]
+	d.rw.RUnlock()
+	if ok {
+		return i.Value, true
+	}
+	if b, ok := d.embeddedValue(KindInteger, name); ok {
+		i, err := catalogInt(b)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func (d *dictionary) getBool(name string) (bool, bool) {
+	d.rw.RLock()
+	b, ok := d.bools[name]
+	d.rw.RUnlock()
+	if ok {
+		return b.Value, true
+	}
+	if b, ok := d.embeddedValue(KindBool, name); ok {
+		val, err := catalogBool(b)
+		return val, err == nil
+	}
+	return false, false
+}
+
+func (d *dictionary) getIntArray(name string) ([]int, bool) {
+	d.rw.RLock()
+	ia, ok := d.integerArrays[name]
+	d.rw.RUnlock()
+	if !ok {
+		if b, ok := d.embeddedValue(KindIntegerArray, name); ok {
+			arr, err := catalogIntArray(b)
+			return arr, err == nil
+		}
+		return nil, false
+	}
+
+	arr := make([]int, 0, len(ia.Items))
+	for _, item := range ia.Items {
+		arr = append(arr, item.Value)
+	}
+	return arr, true
+}
+
+func (d *dictionary) getArray(name string) ([]string, bool) {
+	d.rw.RLock()
+	a, ok := d.typedArrays[name]
+	d.rw.RUnlock()
+	if !ok {
+		return d.embeddedList(KindArray, name)
+	}
+
+	arr := make([]string, 0, len(a.Items))
+	for _, item := range a.Items {
+		arr = append(arr, item.Value)
+	}
+	return arr, true
+}
+
+// embeddedValue returns the encoded value of a resource of the generated table.
+func (d *dictionary) embeddedValue(kind, name string) ([]byte, bool) {
+	if d.embedded == nil {
+		return nil, false
+	}
+	return d.embedded.lookup(kind, name)
+}
+
+// embeddedList returns the items of a string-array or typed array of the generated table.
+func (d *dictionary) embeddedList(kind, name string) ([]string, bool) {
+	b, ok := d.embeddedValue(kind, name)
+	if !ok {
+		return nil, false
+	}
+	arr, err := catalogList(b, 1)
+	return arr, err == nil
+}
+
+// embeddedPlural returns a quantity string of the generated table.
+func (d *dictionary) embeddedPlural(name string) (types.Plural, bool) {
+	b, ok := d.embeddedValue(KindPlurals, name)
+	if !ok {
+		return types.Plural{}, false
+	}
+	pl, err := catalogPlural(name, b)
+	return pl, err == nil
+}
+
+// nesting returns the dictionary's resources sorted by name.
+func (d *dictionary) nesting() *types.Nesting {
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+
+	n := &types.Nesting{}
+
+	for _, name := range sortedKeys(d.strings) {
+		n.Strings = append(n.Strings, &types.String{Name: name, Meta: d.meta[name], Value: d.strings[name]})
+	}
+	for _, name := range sortedKeys(d.arrays) {
+		sa := d.arrays[name]
+		n.StringsArray = append(n.StringsArray, &sa)
+	}
+	for _, name := range sortedKeys(d.plurals) {
+		pl := d.plurals[name]
+		n.Plurals = append(n.Plurals, &pl)
+	}
+	for _, name := range sortedKeys(d.integers) {
+		i := d.integers[name]
+		n.Integers = append(n.Integers, &i)
+	}
+	for _, name := range sortedKeys(d.bools) {
+		b := d.bools[name]
+		n.Bools = append(n.Bools, &b)
+	}
+	for _, name := range sortedKeys(d.integerArrays) {
+		ia := d.integerArrays[name]
+		n.IntegerArrays = append(n.IntegerArrays, &ia)
+	}
+	for _, name := range sortedKeys(d.typedArrays) {
+		a := d.typedArrays[name]
+		n.Arrays = append(n.Arrays, &a)
+	}
+	return n
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,635 @@
+package stres
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var preserveFormat = false
+
+/*
+	Enables the format-preserving edit mode for XML and YAML resource files.
+	When enabled, the functions adding, updating or removing resources only rewrite the edited resources,
+	keeping comments, ordering and formatting of the rest of the file. Other formats are always re-encoded.
+	(default value: false)
+*/
+func SetPreserveFormat(enabled bool) {
+	preserveFormat = enabled
+}
+
+// errorEditUnsupported makes editResource fall back to re-encoding the whole file.
+var errorEditUnsupported = errors.New("stres: document can't be edited in place")
+
+// resourceEdit describes the change of a single resource.
+type resourceEdit struct {
+	kind  string      // one of the Kind constants
+	name  string      // name of the resource
+	value interface{} // *types.String, *types.StringArray, *types.Plural, ... matching kind; nil removes the resource
+}
+
+// editResource applies edits to the resource file at path, adding or replacing resources with a value and
+// removing the others. Removing a resource not in the file returns an error wrapping ErrorNotFound.
+func editResource(path string, t types.FileType, edits ...resourceEdit) error {
+	return editFile(path, t, preserveFormat, edits)
+}
+
+// editFile is editResource, editing XML and YAML files in place if preserve is true.
+func editFile(path string, t types.FileType, preserve bool, edits []resourceEdit) error {
+	unlock, err := store.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	d, err := readBytes(path)
+	if err != nil {
+		return err
+	}
+
+	if preserve && len(bytes.TrimSpace(d)) > 0 {
+		var out []byte
+		switch t {
+		case XML:
+			out, err = editXML(d, edits)
+		case YAML:
+			out, err = editYAML(d, edits)
+		default:
+			err = errorEditUnsupported
+		}
+		if err == nil {
+			return writeBytes(path, out)
+		}
+		if !errors.Is(err, errorEditUnsupported) {
+			return parseError(path, t, err)
+		}
+	}
+
+	n := &types.Nesting{}
+	ed := types.EncoderDecoder{}
+	ed.SetStrategy(strategyFor(t))
+	if err = ed.Decode(d, &n); err != nil {
+		return parseError(path, t, err)
+	}
+
+	for _, e := range edits {
+		if err = applyEdit(n, e); err != nil {
+			return err
+		}
+	}
+	return writeNesting(path, t, n)
+}
+
+// applyEdit applies e to the decoded resources in n.
+func applyEdit(n *types.Nesting, e resourceEdit) error {
+	var found bool
+	switch e.kind {
+	case KindString:
+		n.Strings, found = editList(n.Strings, e, func(s *types.String) string { return s.Name })
+	case KindStringArray:
+		n.StringsArray, found = editList(n.StringsArray, e, func(sa *types.StringArray) string { return sa.Name })
+	case KindPlurals:
+		n.Plurals, found = editList(n.Plurals, e, func(pl *types.Plural) string { return pl.Name })
+	case KindInteger:
+		n.Integers, found = editList(n.Integers, e, func(i *types.Integer) string { return i.Name })
+	case KindBool:
+		n.Bools, found = editList(n.Bools, e, func(b *types.Bool) string { return b.Name })
+	case KindIntegerArray:
+		n.IntegerArrays, found = editList(n.IntegerArrays, e, func(ia *types.IntegerArray) string { return ia.Name })
+	case KindArray:
+		n.Arrays, found = editList(n.Arrays, e, func(a *types.Array) string { return a.Name })
+	}
+
+	if !found && e.value == nil {
+		return notFound(e)
+	}
+	return nil
+}
+
+// editList replaces, appends or removes the resource named e.name in list. Reports whether it was in list.
+func editList[T any](list []*T, e resourceEdit, nameOf func(*T) string) ([]*T, bool) {
+	for i, item := range list {
+		if nameOf(item) != e.name {
+			continue
+		}
+		if e.value == nil {
+			return append(list[:i], list[i+1:]...), true
+		}
+		list[i] = e.value.(*T)
+		return list, true
+	}
+
+	if e.value != nil {
+		list = append(list, e.value.(*T))
+	}
+	return list, false
+}
+
+func notFound(e resourceEdit) error {
+	return fmt.Errorf("%w: %s %q", ErrorNotFound, e.kind, e.name)
+}
+
+// xmlElement is a resource element of an XML document, located by byte offsets.
+type xmlElement struct {
+	kind, name           string
+	attrs                []xml.Attr
+	start, end           int64 // the whole element
+	innerStart, innerEnd int64 // content between the start and the end tag
+}
+
+// scanXML locates the resource elements of an XML document and the end tag of its root element.
+func scanXML(data []byte) ([]xmlElement, int64, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var elements []xmlElement
+	depth := 0
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if err != nil {
+			pe := &types.ParseError{Err: err}
+			pe.Line, pe.Column = dec.InputPos()
+			return nil, 0, pe
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if depth == 1 {
+				el := xmlElement{kind: tok.Name.Local, attrs: tok.Attr, start: offset, innerStart: dec.InputOffset()}
+				for _, attr := range tok.Attr {
+					if attr.Name.Local == "name" {
+						el.name = attr.Value
+					}
+				}
+				elements = append(elements, el)
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			switch depth {
+			case 1:
+				el := &elements[len(elements)-1]
+				el.innerEnd, el.end = offset, dec.InputOffset()
+			case 0:
+				if offset == dec.InputOffset() {
+					// Self-closing root element.
+					return nil, 0, errorEditUnsupported
+				}
+				return elements, offset, nil
+			}
+		}
+	}
+}
+
+// editXML applies edits to an XML document, leaving the bytes outside the edited elements untouched.
+func editXML(data []byte, edits []resourceEdit) ([]byte, error) {
+	for _, e := range edits {
+		elements, rootEnd, err := scanXML(data)
+		if err != nil {
+			return nil, err
+		}
+
+		idx, last := -1, -1
+		for i, el := range elements {
+			if el.kind != e.kind {
+				continue
+			}
+			last = i
+			if el.name == e.name {
+				idx = i
+			}
+		}
+
+		indent := "\t"
+		if len(elements) > 0 {
+			indent = lineIndent(data, elements[0].start)
+		}
+
+		var at, end int64
+		var text string
+		switch {
+		case idx >= 0 && e.value == nil:
+			el := elements[idx]
+			at, end = el.start, el.end
+			if lineStart := at - int64(len(lineIndent(data, at))); isLineEnd(data, end) && lineStart > 0 && data[lineStart-1] == '\n' {
+				// Remove the element's line.
+				at, end = lineStart-1, end+int64(len(lineRest(data, end)))
+			}
+		case idx >= 0 && elements[idx].innerStart == elements[idx].end:
+			// Self-closing element.
+			el := elements[idx]
+			at, end = el.start, el.end
+			text, err = xmlElementText(e.value, lineIndent(data, el.start), indent)
+		case idx >= 0:
+			// The start tag, rewritten only if the metadata changed, and the content.
+			el := elements[idx]
+			at, end = el.start, el.innerEnd
+			var start, inner string
+			start, err = xmlStartTag(data, el, e.value)
+			if err == nil {
+				inner, err = xmlInnerText(e.value, lineIndent(data, el.start), indent)
+			}
+			text = start + inner
+		case e.value == nil:
+			return nil, notFound(e)
+		case last >= 0:
+			// Next to the resources of the same kind.
+			at = elements[last].end
+			end = at
+			text, err = xmlElementText(e.value, indent, indent)
+			text = "\n" + indent + text
+		default:
+			at = rootEnd
+			end = at
+			text, err = xmlElementText(e.value, indent, indent)
+			if lineStart := rootEnd - int64(len(lineIndent(data, rootEnd))); lineStart > 0 && data[lineStart-1] == '\n' {
+				at, end = lineStart, lineStart
+				text = indent + text + "\n"
+			} else {
+				text = "\n" + indent + text + "\n"
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data[:at:at], append([]byte(text), data[end:]...)...)
+	}
+	return data, nil
+}
+
+// xmlElementText encodes a resource as an element starting at the given indentation.
+func xmlElementText(v interface{}, prefix, indent string) (string, error) {
+	b, err := xml.MarshalIndent(v, prefix, indent)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(string(b), prefix), nil
+}
+
+// xmlStartTag returns the start tag of el with the metadata attributes of v, keeping the other attributes
+// and, if no attribute changes, the original bytes.
+func xmlStartTag(data []byte, el xmlElement, v interface{}) (string, error) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	tok, err := xml.NewDecoder(bytes.NewReader(b)).RawToken()
+	if err != nil {
+		return "", err
+	}
+	encoded := tok.(xml.StartElement).Attr
+
+	value := func(name string) (string, bool) {
+		for _, attr := range encoded {
+			if attr.Name.Local == name {
+				return attr.Value, true
+			}
+		}
+		return "", false
+	}
+
+	var attrs []xml.Attr
+	kept := map[string]bool{}
+	for _, attr := range el.attrs {
+		if attr.Name.Space != "" || !xmlAttributes[attr.Name.Local] {
+			attrs = append(attrs, attr)
+			continue
+		}
+		if v, ok := value(attr.Name.Local); ok {
+			attrs = append(attrs, xml.Attr{Name: attr.Name, Value: v})
+			kept[attr.Name.Local] = true
+		}
+	}
+	for _, attr := range encoded {
+		if !kept[attr.Name.Local] {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	if slices.Equal(attrs, el.attrs) {
+		return string(data[el.start:el.innerStart]), nil
+	}
+
+	tag := strings.Builder{}
+	tag.WriteString("<" + el.kind)
+	for _, attr := range attrs {
+		name := attr.Name.Local
+		if attr.Name.Space != "" {
+			name = attr.Name.Space + ":" + name
+		}
+		tag.WriteString(" " + name + `="`)
+		xml.EscapeText(&tag, []byte(attr.Value))
+		tag.WriteString(`"`)
+	}
+	tag.WriteString(">")
+	return tag.String(), nil
+}
+
+// xmlInnerText encodes the content of a resource's element starting at the given indentation.
+func xmlInnerText(v interface{}, prefix, indent string) (string, error) {
+	var items []interface{}
+	switch v := v.(type) {
+	case *types.String:
+		return v.Value, nil
+	case *types.Integer:
+		return v.Text(), nil
+	case *types.Bool:
+		return v.Text(), nil
+	case *types.IntegerArray:
+		for _, item := range v.Items {
+			items = append(items, item)
+		}
+	case *types.Array:
+		for _, item := range v.Items {
+			items = append(items, item)
+		}
+	case *types.StringArray:
+		for _, item := range v.Items {
+			items = append(items, item)
+		}
+	case *types.Plural:
+		for _, item := range v.Items {
+			items = append(items, item)
+		}
+	}
+
+	b := strings.Builder{}
+	for _, item := range items {
+		text, err := xml.MarshalIndent(item, prefix+indent, indent)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("\n")
+		b.Write(text)
+	}
+	if len(items) > 0 {
+		b.WriteString("\n" + prefix)
+	}
+	return b.String(), nil
+}
+
+// lineIndent returns the whitespace preceding offset on its line, empty if offset is not the first non-blank.
+func lineIndent(data []byte, offset int64) string {
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	indent := data[start:offset]
+	if len(bytes.TrimLeft(indent, " \t")) > 0 {
+		return ""
+	}
+	return string(indent)
+}
+
+// lineRest returns the whitespace following offset up to the end of its line, excluded.
+func lineRest(data []byte, offset int64) string {
+	end := offset
+	for end < int64(len(data)) && (data[end] == ' ' || data[end] == '\t' || data[end] == '\r') {
+		end++
+	}
+	return string(data[offset:end])
+}
+
+// isLineEnd reports whether only whitespace follows offset on its line.
+func isLineEnd(data []byte, offset int64) bool {
+	end := offset + int64(len(lineRest(data, offset)))
+	return end == int64(len(data)) || data[end] == '\n'
+}
+
+// editYAML applies edits to the node tree of a YAML document, keeping its comments, key order and styles.
+func editYAML(data []byte, edits []resourceEdit) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errorEditUnsupported
+	}
+	root := doc.Content[0]
+
+	for _, e := range edits {
+		seq := yamlValue(root, e.kind)
+		if seq == nil {
+			if e.value == nil {
+				return nil, notFound(e)
+			}
+			seq = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yamlSequenceStyle(root)}
+			root.Content = append(root.Content, yamlScalar(e.kind), seq)
+		}
+		if seq.Kind != yaml.SequenceNode {
+			return nil, errorEditUnsupported
+		}
+
+		idx := -1
+		for i, entry := range seq.Content {
+			if name := yamlValue(entry, "name"); name != nil && name.Value == e.name {
+				idx = i
+			}
+		}
+
+		switch {
+		case idx >= 0 && e.value == nil:
+			seq.Content = append(seq.Content[:idx], seq.Content[idx+1:]...)
+		case idx >= 0:
+			yamlSetEntry(seq.Content[idx], e.value)
+		case e.value == nil:
+			return nil, notFound(e)
+		default:
+			entry := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			entry.Content = append(entry.Content, yamlScalar("name"), yamlScalar(e.name))
+			yamlSetEntry(entry, e.value)
+			seq.Content = append(seq.Content, entry)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent(data))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlSetEntry sets the values and the metadata of a resource in its mapping node, keeping the other keys.
+func yamlSetEntry(entry *yaml.Node, v interface{}) {
+	yamlSetMeta(entry, resourceMeta(v))
+
+	switch v := v.(type) {
+	case *types.String:
+		yamlSet(entry, "value", yamlScalar(v.Value))
+	case *types.Integer:
+		yamlSet(entry, "value", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(v.Value)})
+		yamlSetRef(entry, v.Ref)
+	case *types.Bool:
+		yamlSet(entry, "value", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v.Value)})
+		yamlSetRef(entry, v.Ref)
+	case *types.IntegerArray:
+		items := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v.Items {
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				yamlScalar("value"), {Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(item.Value)},
+			}}
+			yamlSetRef(node, item.Ref)
+			items.Content = append(items.Content, node)
+		}
+		yamlSet(entry, "items", items)
+	case *types.Array:
+		items := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v.Items {
+			items.Content = append(items.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				yamlScalar("value"), yamlScalar(item.Value),
+			}})
+		}
+		yamlSet(entry, "items", items)
+	case *types.StringArray:
+		items := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v.Items {
+			items.Content = append(items.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				yamlScalar("value"), yamlScalar(item.Value),
+			}})
+		}
+		yamlSet(entry, "items", items)
+	case *types.Plural:
+		items := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v.Items {
+			items.Content = append(items.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				yamlScalar("quantity"), yamlScalar(item.Quantity),
+				yamlScalar("value"), yamlScalar(item.Value),
+			}})
+		}
+		yamlSet(entry, "items", items)
+	}
+}
+
+// yamlSet sets the value of a key in a mapping node, keeping the style and comments of the replaced value.
+func yamlSet(mapping *yaml.Node, key string, value *yaml.Node) {
+	old := yamlValue(mapping, key)
+	if old == nil {
+		mapping.Content = append(mapping.Content, yamlScalar(key), value)
+		return
+	}
+
+	if old.Kind == value.Kind && old.Kind == yaml.ScalarNode && !strings.Contains(value.Value, "\n") {
+		value.Style = old.Style
+	}
+	if old.Kind == value.Kind && old.Kind == yaml.SequenceNode {
+		value.Style = old.Style
+	}
+	value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	*old = *value
+}
+
+// yamlSetRef sets the reference of a resource or an item, removing the key when there's none.
+func yamlSetRef(mapping *yaml.Node, ref string) {
+	if ref != "" {
+		yamlSet(mapping, "ref", yamlScalar(ref))
+		return
+	}
+	yamlDelete(mapping, "ref")
+}
+
+// yamlSetMeta sets the metadata keys of a resource, removing the unset ones.
+func yamlSetMeta(entry *yaml.Node, m types.Meta) {
+	setOrDelete := func(key string, set bool, value *yaml.Node) {
+		if set {
+			yamlSet(entry, key, value)
+		} else {
+			yamlDelete(entry, key)
+		}
+	}
+	boolNode := func(b *bool) *yaml.Node {
+		if b == nil {
+			return nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(*b)}
+	}
+
+	setOrDelete("description", m.Description != "", yamlScalar(m.Description))
+	setOrDelete("translatable", m.Translatable != nil, boolNode(m.Translatable))
+	setOrDelete("formatted", m.Formatted != nil, boolNode(m.Formatted))
+	setOrDelete("maxLength", m.MaxLength != 0, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(m.MaxLength)})
+
+	tags := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	for _, tag := range m.Tags {
+		tags.Content = append(tags.Content, yamlScalar(tag))
+	}
+	setOrDelete("tags", len(m.Tags) > 0, tags)
+}
+
+// yamlDelete removes a key from a mapping node.
+func yamlDelete(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// resourceMeta returns the metadata of a resource.
+func resourceMeta(v interface{}) types.Meta {
+	switch v := v.(type) {
+	case *types.String:
+		return v.Meta
+	case *types.StringArray:
+		return v.Meta
+	case *types.Plural:
+		return v.Meta
+	case *types.Integer:
+		return v.Meta
+	case *types.Bool:
+		return v.Meta
+	case *types.IntegerArray:
+		return v.Meta
+	case *types.Array:
+		return v.Meta
+	}
+	return types.Meta{}
+}
+
+// yamlValue returns the value of a key in a mapping node, nil if missing.
+func yamlValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func yamlScalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// yamlSequenceStyle returns the style of the document's resource lists, flow style as the YAML strategy writes.
+func yamlSequenceStyle(root *yaml.Node) yaml.Style {
+	for _, kind := range []string{KindString, KindStringArray, KindPlurals, KindInteger, KindBool, KindIntegerArray, KindArray} {
+		if seq := yamlValue(root, kind); seq != nil && seq.Kind == yaml.SequenceNode {
+			return seq.Style
+		}
+	}
+	return yaml.FlowStyle
+}
+
+// yamlIndent guesses the indentation width of a YAML document.
+func yamlIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && trimmed != "" && trimmed[0] != '#' {
+			return indent
+		}
+	}
+	return 4
+}
@@ -0,0 +1,238 @@
+package stres
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+func TestPreserveFormat_XML(t *testing.T) {
+	inTempDir(t)
+	SetPreserveFormat(true)
+	t.Cleanup(func() {
+		SetPreserveFormat(false)
+		SetResourceType(XML)
+	})
+
+	writeFile(t, "strings/strings.xml", `<?xml version="1.0" encoding="utf-8"?>
+<!-- Resources of the app -->
+<resources>
+    <string name="app_name" translatable="false">stres</string>
+
+    <!-- Shown on the home screen -->
+    <string name="welcome">Welcome</string>
+    <string-array name="planets">
+        <item>Mercury</item>
+    </string-array>
+    <string name="obsolete">Obsolete</string>
+</resources>
+`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpdateString("welcome", "Welcome back"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewString("goodbye", "Goodbye"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateStringArray("planets", []string{"Mercury", "Venus"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveString("obsolete"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewQuantityString("files", []string{"no files", "one file"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<!-- Resources of the app -->
+<resources>
+    <string name="app_name" translatable="false">stres</string>
+
+    <!-- Shown on the home screen -->
+    <string name="welcome">Welcome back</string>
+    <string-array name="planets">
+        <item>Mercury</item>
+        <item>Venus</item>
+    </string-array>
+    <string name="goodbye">Goodbye</string>
+    <plurals name="files">
+        <item quantity="zero">no files</item>
+        <item quantity="one">one file</item>
+    </plurals>
+</resources>
+`
+	got, err := os.ReadFile("strings/strings.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("strings.xml =\n%s\nwant\n%s", got, want)
+	}
+
+	if GetString("welcome") != "Welcome back" || GetString("obsolete") != "" {
+		t.Errorf("GetString() doesn't reflect the edits")
+	}
+	if err = RemoveString("obsolete"); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("RemoveString() error = %v, want %v", err, ErrorNotFound)
+	}
+}
+
+func TestPreserveFormat_YAML(t *testing.T) {
+	inTempDir(t)
+	SetPreserveFormat(true)
+	t.Cleanup(func() {
+		SetPreserveFormat(false)
+		SetResourceType(XML)
+	})
+
+	writeFile(t, "strings/strings.yml", `# Resources of the app
+string:
+  - name: app_name
+    value: stres # not translatable
+  # Shown on the home screen
+  - name: welcome
+    value: Welcome
+  - name: obsolete
+    value: Obsolete
+`)
+	if err := LoadValues(YAML); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpdateString("welcome", "Welcome: back"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveString("obsolete"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewString("farewell", "Farewell"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Resources of the app
+string:
+  - name: app_name
+    value: stres # not translatable
+  # Shown on the home screen
+  - name: welcome
+    value: 'Welcome: back'
+  - name: farewell
+    value: Farewell
+`
+	got, err := os.ReadFile("strings/strings.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("strings.yml =\n%s\nwant\n%s", got, want)
+	}
+
+	if err = LoadValues(YAML); err != nil {
+		t.Fatal(err)
+	}
+	if GetString("welcome") != "Welcome: back" {
+		t.Errorf("GetString() = %q, want %q", GetString("welcome"), "Welcome: back")
+	}
+}
+
+func TestPreserveFormat_meta(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetResourceType(XML) })
+
+	translatable := false
+	tests := []struct {
+		name  string
+		path  string
+		t     types.FileType
+		input string
+		edits []resourceEdit
+		want  string
+	}{
+		{
+			name: "test_xml_update",
+			path: "strings/strings.xml",
+			t:    XML,
+			input: `<resources>
+    <string name="welcome" product="tablet" translatable="false">Welcome</string>
+    <plurals name="files" description="Files count">
+        <item quantity="one">one file</item>
+    </plurals>
+    <string name="same" description="Kept">Same</string>
+</resources>
+`,
+			edits: []resourceEdit{
+				{kind: KindString, name: "welcome", value: &types.String{Name: "welcome", Meta: types.Meta{Description: "Home & away", Tags: types.Tags{"ui", "home"}}, Value: "Welcome back"}},
+				{kind: KindPlurals, name: "files", value: &types.Plural{Name: "files", Meta: types.Meta{Description: "Files count", Tags: types.Tags{"machine-translated"}}, Items: []*types.PluralItem{{Quantity: "one", Value: "one file"}}}},
+				{kind: KindString, name: "same", value: &types.String{Name: "same", Meta: types.Meta{Description: "Kept"}, Value: "Same"}},
+			},
+			want: `<resources>
+    <string name="welcome" product="tablet" description="Home &amp; away" tags="ui home">Welcome back</string>
+    <plurals name="files" description="Files count" tags="machine-translated">
+        <item quantity="one">one file</item>
+    </plurals>
+    <string name="same" description="Kept">Same</string>
+</resources>
+`,
+		},
+		{
+			name: "test_yaml",
+			path: "strings/strings.yml",
+			t:    YAML,
+			input: `string:
+  - name: welcome
+    translatable: false
+    value: Welcome # home screen
+`,
+			edits: []resourceEdit{
+				{kind: KindString, name: "welcome", value: &types.String{Name: "welcome", Meta: types.Meta{Description: "Home"}, Value: "Welcome back"}},
+				{kind: KindString, name: "app_name", value: &types.String{Name: "app_name", Meta: types.Meta{Translatable: &translatable, MaxLength: 10, Tags: types.Tags{"brand"}}, Value: "stres"}},
+			},
+			want: `string:
+  - name: welcome
+    value: Welcome back # home screen
+    description: Home
+  - name: app_name
+    translatable: false
+    maxLength: 10
+    tags: [brand]
+    value: stres
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, tt.path, tt.input)
+			if err := editFile(tt.path, tt.t, true, tt.edits); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("%s =\n%s\nwant\n%s", tt.path, got, tt.want)
+			}
+
+			n, err := readNesting(tt.path, tt.t)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range tt.edits {
+				if s, ok := e.value.(*types.String); ok {
+					if got := findString(n, s.Name); got == nil || !reflect.DeepEqual(got.Meta, s.Meta) {
+						t.Errorf("decoded %s = %+v, want meta %+v", s.Name, got, s.Meta)
+					}
+				}
+			}
+			os.Remove(tt.path)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package stres
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed editor
+var editorFiles embed.FS
+
+/*
+	Returns an HTTP handler serving a browser editor of the resources of every locale at its root and the REST API
+	of APIHandler under "/api/". The editor shows a grid of keys × locales for strings, string-arrays and quantity
+	strings, highlights missing translations and placeholders differing from the default resource, edits array items
+	and quantities inline and saves through the API. LoadValues must be called first.
+*/
+func EditorHandler() http.Handler {
+	assets, err := fs.Sub(editorFiles, "editor")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", APIHandler()))
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	return mux
+}
@@ -0,0 +1,367 @@
+"use strict";
+
+// Quantities of quantity strings, in the order of NewQuantityString.
+const QUANTITIES = ["zero", "one", "two", "few", "many"];
+
+// printf specifiers (%s, %1$d, %.2f) and ICU arguments ({name}, {count, plural, ...}).
+const PRINTF = /%(\d+\$)?[-#+ 0,(]*\d*(\.\d+)?[a-zA-Z]/g;
+const ICU_ARGUMENT = /\{\s*([A-Za-z0-9_]+)\s*[,}]/g;
+
+const state = {
+	kind: "string",
+	locales: [],
+	// resources[locale][name] is the resource as sent by the API.
+	resources: {},
+	// Keys added in the editor and not saved yet.
+	added: new Set(),
+};
+
+const grid = document.getElementById("grid");
+const statusLine = document.getElementById("status");
+const filter = document.getElementById("filter");
+const onlyProblems = document.getElementById("only-problems");
+
+function setStatus(message, error) {
+	statusLine.textContent = message;
+	statusLine.classList.toggle("error", !!error);
+}
+
+async function api(method, path, body, etag) {
+	const headers = {};
+	if (body !== undefined) {
+		headers["Content-Type"] = "application/json";
+	}
+	if (etag) {
+		headers["If-Match"] = etag;
+	}
+	const resp = await fetch("api/" + path, {
+		method: method,
+		headers: headers,
+		body: body === undefined ? undefined : JSON.stringify(body),
+	});
+	const data = resp.status === 204 ? null : await resp.json();
+	if (!resp.ok) {
+		const err = new Error((data && data.error && data.error.message) || resp.statusText);
+		err.status = resp.status;
+		err.field = data && data.error && data.error.field;
+		throw err;
+	}
+	return data;
+}
+
+// placeholders returns the sorted placeholders of a value.
+function placeholders(value) {
+	const found = [];
+	for (const m of (value || "").matchAll(PRINTF)) {
+		found.push(m[0]);
+	}
+	for (const m of (value || "").matchAll(ICU_ARGUMENT)) {
+		found.push("{" + m[1] + "}");
+	}
+	return found.sort();
+}
+
+function samePlaceholders(a, b) {
+	const pa = placeholders(a), pb = placeholders(b);
+	return pa.length === pb.length && pa.every((p, i) => p === pb[i]);
+}
+
+// metaOf returns a metadata field of a resource, or else of the default resource.
+function metaOf(field, def, res) {
+	for (const r of [res, def]) {
+		if (r && r.meta && r.meta[field] !== undefined) {
+			return r.meta[field];
+		}
+	}
+	return undefined;
+}
+
+// mismatched reports whether a translation uses placeholders different from the default resource's.
+// Values marked formatted="false" have no placeholders.
+function mismatched(kind, def, res) {
+	if (!def || !res || metaOf("formatted", def, res) === false) {
+		return false;
+	}
+	switch (kind) {
+	case "string":
+		return !samePlaceholders(def.value, res.value);
+	case "string-array":
+		return (res.items || []).some((item, i) => i < (def.items || []).length && !samePlaceholders(def.items[i], item));
+	case "plurals": {
+		// Quantities may legitimately drop a placeholder ("One apple"), not add unknown ones.
+		const known = new Set(Object.values(def.quantities || {}).flatMap(placeholders));
+		return Object.values(res.quantities || {}).some((v) => placeholders(v).some((p) => !known.has(p)));
+	}
+	}
+	return false;
+}
+
+// tooLong returns the first value of a resource longer than its max length, as the API counts characters.
+function tooLong(kind, def, res) {
+	const max = metaOf("maxLength", def, res);
+	if (!max || !res) {
+		return undefined;
+	}
+	const values = kind === "string" ? [res.value] : kind === "string-array" ? res.items || [] : Object.values(res.quantities || {});
+	return values.find((v) => Array.from(v || "").length > max);
+}
+
+async function load() {
+	setStatus("Loading…");
+	try {
+		state.locales = (await api("GET", "")).locales;
+		state.resources = {};
+		await Promise.all(state.locales.map(async (locale) => {
+			const data = await api("GET", locale + "/" + state.kind);
+			state.resources[locale] = {};
+			for (const res of data.resources) {
+				state.resources[locale][res.name] = res;
+			}
+		}));
+		render();
+		setStatus("");
+	} catch (err) {
+		setStatus(err.message, true);
+	}
+}
+
+function names() {
+	const all = new Set(state.added);
+	for (const locale of state.locales) {
+		Object.keys(state.resources[locale] || {}).forEach((name) => all.add(name));
+	}
+	return Array.from(all).sort();
+}
+
+function render() {
+	const head = document.createElement("tr");
+	head.appendChild(document.createElement("th")).textContent = "Key";
+	for (const locale of state.locales) {
+		head.appendChild(document.createElement("th")).textContent = locale;
+	}
+	grid.tHead.replaceChildren(head);
+
+	const rows = [];
+	for (const name of names()) {
+		const row = document.createElement("tr");
+		row.dataset.name = name;
+		const key = row.appendChild(document.createElement("th"));
+		key.className = "key";
+		key.textContent = name;
+		for (const locale of state.locales) {
+			row.appendChild(renderCell(locale, name));
+		}
+		rows.push(row);
+	}
+	grid.tBodies[0].replaceChildren(...rows);
+	applyFilter();
+}
+
+function renderCell(locale, name) {
+	const td = document.createElement("td");
+	td.dataset.locale = locale;
+	td.dataset.name = name;
+	const res = state.resources[locale][name];
+
+	switch (state.kind) {
+	case "string": {
+		const area = td.appendChild(document.createElement("textarea"));
+		area.rows = 1;
+		area.value = res ? res.value : "";
+		break;
+	}
+	case "string-array": {
+		for (const item of res ? res.items || [] : []) {
+			addArrayItem(td, item);
+		}
+		const add = td.appendChild(document.createElement("button"));
+		add.className = "add";
+		add.textContent = "+ item";
+		add.addEventListener("click", () => {
+			addArrayItem(td, "", add).querySelector("input").focus();
+			td.classList.add("dirty");
+		});
+		break;
+	}
+	case "plurals":
+		for (const q of QUANTITIES) {
+			const item = td.appendChild(document.createElement("div"));
+			item.className = "item";
+			item.appendChild(document.createElement("label")).textContent = q;
+			const input = item.appendChild(document.createElement("input"));
+			input.dataset.quantity = q;
+			input.value = (res && res.quantities && res.quantities[q]) || "";
+		}
+		break;
+	}
+
+	td.addEventListener("input", () => td.classList.add("dirty"));
+	td.addEventListener("keydown", (e) => {
+		if (e.key === "Enter" && (e.ctrlKey || e.metaKey)) {
+			e.preventDefault();
+			save(td);
+		}
+	});
+	td.addEventListener("focusout", (e) => {
+		if (!td.contains(e.relatedTarget) && td.classList.contains("dirty")) {
+			save(td);
+		}
+	});
+	mark(td);
+	return td;
+}
+
+function addArrayItem(td, value, before) {
+	const item = document.createElement("div");
+	item.className = "item";
+	const input = item.appendChild(document.createElement("input"));
+	input.value = value;
+	const remove = item.appendChild(document.createElement("button"));
+	remove.textContent = "×";
+	remove.title = "Remove item";
+	remove.addEventListener("click", () => {
+		item.remove();
+		td.classList.add("dirty");
+		save(td);
+	});
+	td.insertBefore(item, before || null);
+	return item;
+}
+
+// mark highlights missing translations and placeholder mismatches.
+function mark(td) {
+	const def = state.resources[state.locales[0]][td.dataset.name];
+	const res = state.resources[td.dataset.locale][td.dataset.name];
+	td.classList.toggle("missing", !res);
+	td.classList.toggle("mismatch", mismatched(state.kind, def, res));
+	td.classList.toggle("too-long", tooLong(state.kind, def, res) !== undefined);
+}
+
+// cellResource returns the resource edited in a cell, or null if the cell is empty.
+function cellResource(td) {
+	switch (state.kind) {
+	case "string": {
+		const value = td.querySelector("textarea").value;
+		return value === "" ? null : { value: value };
+	}
+	case "string-array": {
+		const items = Array.from(td.querySelectorAll("input"), (input) => input.value);
+		return items.length === 0 ? null : { items: items };
+	}
+	case "plurals": {
+		const quantities = {};
+		for (const input of td.querySelectorAll("input")) {
+			if (input.value !== "") {
+				quantities[input.dataset.quantity] = input.value;
+			}
+		}
+		return Object.keys(quantities).length === 0 ? null : { quantities: quantities };
+	}
+	}
+	return null;
+}
+
+// save saves a cell after its previous saves: removing an array item also moves the focus out of the cell,
+// and two requests sent with the same ETag would fail.
+function save(td) {
+	td.saving = (td.saving || Promise.resolve()).then(() => saveCell(td));
+	return td.saving;
+}
+
+async function saveCell(td) {
+	if (!td.classList.contains("dirty")) {
+		return;
+	}
+	const locale = td.dataset.locale, name = td.dataset.name;
+	const current = state.resources[locale][name];
+	const body = cellResource(td);
+	const path = locale + "/" + state.kind + "/" + encodeURIComponent(name);
+
+	// Removing a default resource removes it from the base file, which every locale falls back to.
+	if (!body && current && locale === state.locales[0] &&
+		!confirm("Remove " + name + " from the default resources?")) {
+		const cell = renderCell(locale, name);
+		td.replaceWith(cell);
+		applyFilter();
+		setStatus(name + " was not removed");
+		return;
+	}
+
+	// The API refuses values longer than the max length as well.
+	const def = state.resources[state.locales[0]][name];
+	const long = body ? tooLong(state.kind, def, Object.assign({}, current, body)) : undefined;
+	if (long !== undefined) {
+		td.classList.add("invalid");
+		setStatus(name + " (" + locale + "): \"" + long + "\" is longer than " + metaOf("maxLength", def, current) + " characters", true);
+		return;
+	}
+
+	// Changes made while saving mark the cell dirty again.
+	td.classList.remove("dirty");
+	try {
+		if (body) {
+			setStatus("Saving " + name + " (" + locale + ")…");
+			state.resources[locale][name] = await api("PUT", path, body, current && current.etag);
+		} else if (current) {
+			setStatus("Removing " + name + " (" + locale + ")…");
+			await api("DELETE", path, undefined, current.etag);
+			delete state.resources[locale][name];
+		}
+		td.classList.remove("invalid");
+		setStatus("Saved " + name + " (" + locale + ")");
+	} catch (err) {
+		if (err.status === 412) {
+			setStatus(name + " (" + locale + ") was changed by someone else: reloaded, edit it again.", true);
+			await load();
+			return;
+		}
+		// A value the resource file can't hold (e.g. a control character) stays in the cell to be fixed.
+		td.classList.add("dirty");
+		td.classList.toggle("invalid", err.status === 422);
+		setStatus(name + " (" + locale + "): " + err.message, true);
+		return;
+	}
+
+	// A change of the default resource can fix or cause mismatches in the whole row.
+	const row = td.parentElement;
+	row.querySelectorAll("td").forEach(mark);
+	applyFilter();
+}
+
+function applyFilter() {
+	const text = filter.value.toLowerCase();
+	for (const row of grid.tBodies[0].rows) {
+		const matches = !text || row.dataset.name.toLowerCase().includes(text) ||
+			Array.from(row.querySelectorAll("textarea, input"), (i) => i.value.toLowerCase()).some((v) => v.includes(text));
+		const problem = row.querySelector("td.missing, td.mismatch, td.too-long") !== null;
+		row.hidden = !matches || (onlyProblems.checked && !problem);
+	}
+}
+
+for (const button of document.querySelectorAll("#kinds button")) {
+	button.addEventListener("click", () => {
+		if (grid.querySelector("td.dirty") && !confirm("Discard unsaved changes?")) {
+			return;
+		}
+		document.querySelectorAll("#kinds button").forEach((b) => b.classList.toggle("selected", b === button));
+		state.kind = button.dataset.kind;
+		state.added.clear();
+		load();
+	});
+}
+document.getElementById("new-key").addEventListener("submit", (e) => {
+	e.preventDefault();
+	const name = e.target.elements.name.value.trim();
+	if (!names().includes(name)) {
+		state.added.add(name);
+		render();
+	}
+	e.target.reset();
+	const row = Array.from(grid.tBodies[0].rows).find((r) => r.dataset.name === name);
+	row.hidden = false;
+	row.querySelector("textarea, input, button").focus();
+});
+filter.addEventListener("input", applyFilter);
+onlyProblems.addEventListener("change", applyFilter);
+
+load();
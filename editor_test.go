@@ -0,0 +1,127 @@
+package stres
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEditorHandler(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetResourceType(XML) })
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="editor_title">Title</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(EditorHandler())
+	defer srv.Close()
+
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/", "text/html", `<script src="editor.js">`},
+		{"/editor.js", "javascript", `api("PUT", path, body, current && current.etag)`},
+		{"/editor.css", "text/css", ".mismatch"},
+		{"/api/default/string/editor_title", "application/json", `"value":"Title"`},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s = %d", tt.path, resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+			t.Errorf("GET %s Content-Type = %q, want %q", tt.path, ct, tt.contentType)
+		}
+		if !strings.Contains(string(body), tt.contains) {
+			t.Errorf("GET %s doesn't contain %q", tt.path, tt.contains)
+		}
+	}
+}
+
+func TestEditorHandler_save(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetResourceType(XML) })
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="editor_terms">Terms</string>
+</resources>`)
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string name="editor_terms">Termini</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(EditorHandler())
+	defer srv.Close()
+
+	// The requests of the editor's load and save functions.
+	load := func(locale string) map[string]string {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/api/" + locale + "/string")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("load %s = %d", locale, resp.StatusCode)
+		}
+		var data struct {
+			Resources []apiResource `json:"resources"`
+		}
+		json.NewDecoder(resp.Body).Decode(&data)
+		values := map[string]string{}
+		for _, res := range data.Resources {
+			values[res.Name] = *res.Value + "|" + res.ETag
+		}
+		return values
+	}
+	save := func(locale, name, value, etag string) int {
+		t.Helper()
+		body, _ := json.Marshal(map[string]string{"value": value})
+		req, _ := http.NewRequest("PUT", srv.URL+"/api/"+locale+"/string/"+name, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", etag)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, locale := range []string{"default", "it"} {
+		value, etag, _ := strings.Cut(load(locale)["editor_terms"], "|")
+		if value == "" {
+			t.Fatalf("load %s lacks editor_terms", locale)
+		}
+		if status := save(locale, "editor_terms", "Terms & <Conditions>", etag); status != http.StatusOK {
+			t.Fatalf("save %s = %d", locale, status)
+		}
+		if value, _, _ = strings.Cut(load(locale)["editor_terms"], "|"); value != "Terms & <Conditions>" {
+			t.Errorf("reloaded %s value = %q, want Terms & <Conditions>", locale, value)
+		}
+	}
+	if status := save("default", "editor_terms", "bell \a", "*"); status != http.StatusUnprocessableEntity {
+		t.Errorf("save of a control character = %d, want 422", status)
+	}
+	if value, _, _ := strings.Cut(load("default")["editor_terms"], "|"); value != "Terms & <Conditions>" {
+		t.Errorf("reloaded value after a refused save = %q", value)
+	}
+}
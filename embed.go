@@ -0,0 +1,238 @@
+package stres
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+var ErrorPerfectHash error = errors.New("stres: can't build a perfect hash of the resource names")
+
+/*
+	Static resource table of a locale, generated by "stres gen -embed". Keys ("<kind>\x00<name>") and values,
+	encoded as in compiled catalogs, are stored at the slot given by a minimal perfect hash of the key:
+	the key's bucket holds either the seed hashing its keys to their slots or, if negative, -(slot+1).
+*/
+type EmbeddedTable struct {
+	Seeds  []int32
+	Keys   []string
+	Values []string
+}
+
+/*
+	Registers the generated resource table of a locale, the empty string being the default one. Lookups of
+	resources not loaded from resource files are answered from the table, without any file access or parsing.
+	Called by the init function of generated files.
+*/
+func RegisterEmbedded(locale string, t *EmbeddedTable) {
+	if locale == "" {
+		defaultDictionary.embedded = t
+		return
+	}
+
+	locale_mu.Lock()
+	defer locale_mu.Unlock()
+
+	d, ok := locale_dictionaries[locale]
+	if !ok {
+		d = newDictionary()
+		locale_dictionaries[locale] = d
+	}
+	d.embedded = t
+}
+
+// embeddedHash is a seeded FNV-1a hash of key, with a final avalanche so that seeds spread keys well.
+func embeddedHash(seed uint32, key string) uint32 {
+	h := uint32(2166136261) ^ seed
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	return h
+}
+
+// lookup returns the value of a resource of the table.
+func (t *EmbeddedTable) lookup(kind, name string) ([]byte, bool) {
+	if len(t.Keys) == 0 || len(t.Seeds) == 0 {
+		return nil, false
+	}
+
+	key := kind + "\x00" + name
+	slot := 0
+	if seed := t.Seeds[embeddedHash(0, key)%uint32(len(t.Seeds))]; seed < 0 {
+		slot = int(-seed - 1)
+	} else {
+		slot = int(embeddedHash(uint32(seed), key) % uint32(len(t.Keys)))
+	}
+
+	if slot >= len(t.Keys) || slot >= len(t.Values) || t.Keys[slot] != key {
+		return nil, false
+	}
+	return []byte(t.Values[slot]), true
+}
+
+// newEmbeddedTable builds the table of the resources in n, placing keys with the hash and displace algorithm:
+// buckets are filled from the largest, looking for a seed sending all of their keys to free slots.
+func newEmbeddedTable(n *types.Nesting) (*EmbeddedTable, error) {
+	entries := catalogEntries(n)
+	size := len(entries)
+	t := &EmbeddedTable{Seeds: make([]int32, size), Keys: make([]string, size), Values: make([]string, size)}
+	if size == 0 {
+		return t, nil
+	}
+
+	seen := make(map[string]bool, size)
+	for _, e := range entries {
+		if seen[string(e.key)] {
+			// Keys hashing to the same slots for every seed: the search would never end.
+			kind, name, _ := strings.Cut(string(e.key), "\x00")
+			return nil, fmt.Errorf("%w: duplicate %s %q", ErrorPerfectHash, kind, name)
+		}
+		seen[string(e.key)] = true
+	}
+
+	buckets := make([][]int, size)
+	for i, e := range entries {
+		b := embeddedHash(0, string(e.key)) % uint32(size)
+		buckets[b] = append(buckets[b], i)
+	}
+	order := make([]int, size)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return len(buckets[order[i]]) > len(buckets[order[j]]) })
+
+	used := make([]bool, size)
+	free := 0
+	for _, b := range order {
+		bucket := buckets[b]
+		switch {
+		case len(bucket) == 0:
+			continue
+		case len(bucket) == 1:
+			for used[free] {
+				free++
+			}
+			used[free] = true
+			t.Seeds[b] = int32(-free - 1)
+			t.Keys[free], t.Values[free] = string(entries[bucket[0]].key), string(entries[bucket[0]].value)
+			continue
+		}
+
+		slots := make([]int, len(bucket))
+	seeds:
+		for seed := int32(1); ; seed++ {
+			if seed == 1<<24 {
+				return nil, ErrorPerfectHash
+			}
+			for i, e := range bucket {
+				slots[i] = int(embeddedHash(uint32(seed), string(entries[e].key)) % uint32(size))
+				if used[slots[i]] {
+					continue seeds
+				}
+				for _, s := range slots[:i] {
+					if s == slots[i] {
+						continue seeds
+					}
+				}
+			}
+
+			t.Seeds[b] = seed
+			for i, e := range bucket {
+				used[slots[i]] = true
+				t.Keys[slots[i]], t.Values[slots[i]] = string(entries[e].key), string(entries[e].value)
+			}
+			break
+		}
+	}
+	return t, nil
+}
+
+/*
+	Writes the Go source of package pkg embedding the resources of every locale, the empty string being the default
+	one. The generated file registers them with RegisterEmbedded at init.
+*/
+func GenerateEmbedded(w io.Writer, pkg string, locales map[string]*types.Nesting) error {
+	names := make([]string, 0, len(locales))
+	for locale := range locales {
+		names = append(names, locale)
+	}
+	sort.Strings(names)
+
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, "// Code generated by \"stres gen -embed\"; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	buf.WriteString("import \"github.com/Vinetwigs/stres\"\n\n")
+
+	buf.WriteString("func init() {\n")
+	for i, locale := range names {
+		fmt.Fprintf(&buf, "\tstres.RegisterEmbedded(%s, stresTable%d)\n", strconv.Quote(locale), i)
+	}
+	buf.WriteString("}\n")
+
+	for i, locale := range names {
+		t, err := newEmbeddedTable(locales[locale])
+		if err != nil {
+			return fmt.Errorf("%w: locale %q", err, locale)
+		}
+
+		fmt.Fprintf(&buf, "\n// Resources of the %s locale.\nvar stresTable%d = &stres.EmbeddedTable{\n", localeName(locale), i)
+		buf.WriteString("\tSeeds: []int32{")
+		for j, seed := range t.Seeds {
+			if j%16 == 0 {
+				buf.WriteString("\n\t\t")
+			}
+			buf.WriteString(strconv.Itoa(int(seed)) + ",")
+		}
+		buf.WriteString("\n\t},\n\tKeys: []string{\n")
+		for _, key := range t.Keys {
+			fmt.Fprintf(&buf, "\t\t%s,\n", strconv.Quote(key))
+		}
+		buf.WriteString("\t},\n\tValues: []string{\n")
+		for _, value := range t.Values {
+			fmt.Fprintf(&buf, "\t\t%s,\n", strconv.Quote(value))
+		}
+		buf.WriteString("\t},\n}\n")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func localeName(locale string) string {
+	if locale == "" {
+		return "default"
+	}
+	return strconv.Quote(locale)
+}
+
+/*
+	Generates the Go source of package pkg embedding the resources of the default locale and of every translation,
+	written to the file at path. Uses setted resource file extension.
+*/
+func WriteEmbedded(path, pkg string) error {
+	nestings, err := readLocales(fileType)
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.Buffer{}
+	if err = GenerateEmbedded(&buf, pkg, nestings); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0666)
+}
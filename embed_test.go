@@ -0,0 +1,148 @@
+package stres
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+func TestEmbeddedTable(t *testing.T) {
+	n := &types.Nesting{}
+	for i := 0; i < 1000; i++ {
+		n.Strings = append(n.Strings, &types.String{Name: fmt.Sprintf("string_%d", i), Value: fmt.Sprintf("value %d", i)})
+	}
+	n.Integers = []*types.Integer{{Name: "string_1", Value: 42}}
+
+	table, err := newEmbeddedTable(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Keys) != 1001 {
+		t.Errorf("newEmbeddedTable() has %d slots, want 1001", len(table.Keys))
+	}
+
+	for i := 0; i < 1000; i++ {
+		name := fmt.Sprintf("string_%d", i)
+		if got, ok := table.lookup(KindString, name); !ok || string(got) != fmt.Sprintf("value %d", i) {
+			t.Fatalf("lookup(%s) = %q, %v", name, got, ok)
+		}
+	}
+	if got, ok := table.lookup(KindInteger, "string_1"); !ok {
+		t.Errorf("lookup(integer string_1) = %q, %v", got, ok)
+	}
+	if _, ok := table.lookup(KindString, "missing"); ok {
+		t.Errorf("lookup(missing) found a value")
+	}
+}
+
+func TestRegisterEmbedded(t *testing.T) {
+	t.Cleanup(func() {
+		defaultDictionary.embedded = nil
+		locale_mu.Lock()
+		delete(locale_dictionaries, "embed-it")
+		locale_mu.Unlock()
+	})
+
+	def, err := newEmbeddedTable(&types.Nesting{
+		Strings:      []*types.String{{Name: "embed_welcome", Value: "Welcome"}, {Name: "embed_bye", Value: "Bye"}},
+		StringsArray: []*types.StringArray{{Name: "embed_planets", Items: []*types.Item{{Value: "Mercury"}}}},
+		Plurals:      []*types.Plural{{Name: "embed_files", Items: []*types.PluralItem{{Quantity: "one", Value: "one file"}}}},
+		Integers:     []*types.Integer{{Name: "embed_max", Value: 7}},
+		Bools:        []*types.Bool{{Name: "embed_beta", Value: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	it, err := newEmbeddedTable(&types.Nesting{Strings: []*types.String{{Name: "embed_welcome", Value: "Benvenuto"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterEmbedded("", def)
+	RegisterEmbedded("embed-it", it)
+
+	if got := GetString("embed_welcome"); got != "Welcome" {
+		t.Errorf("GetString() = %q, want Welcome", got)
+	}
+	if got := Locale("embed-it").GetString("embed_welcome"); got != "Benvenuto" {
+		t.Errorf("Locale(embed-it).GetString() = %q, want Benvenuto", got)
+	}
+	if got := Locale("embed-it").GetString("embed_bye"); got != "Bye" {
+		t.Errorf("Locale(embed-it).GetString() = %q, want the default value", got)
+	}
+	if got := GetArrayString("embed_planets"); !reflect.DeepEqual(got, []string{"Mercury"}) {
+		t.Errorf("GetArrayString() = %v", got)
+	}
+	if got := GetQuantityString("embed_files", 1); got != "one file" {
+		t.Errorf("GetQuantityString() = %q", got)
+	}
+	if GetInt("embed_max") != 7 || !GetBool("embed_beta") {
+		t.Errorf("GetInt(), GetBool() = %d, %v", GetInt("embed_max"), GetBool("embed_beta"))
+	}
+}
+
+func TestRegisterEmbedded_loadLocale(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		locale_mu.Lock()
+		delete(locale_dictionaries, "embed-fr")
+		locale_mu.Unlock()
+	})
+
+	table, err := newEmbeddedTable(&types.Nesting{Strings: []*types.String{{Name: "embed_title", Value: "Titre"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterEmbedded("embed-fr", table)
+
+	writeFile(t, "strings-embed-fr/strings.xml", `<resources>
+	<string name="embed_subtitle">Sous-titre</string>
+</resources>`)
+	if err := LoadLocale("embed-fr", XML); err != nil {
+		t.Fatal(err)
+	}
+
+	l := Locale("embed-fr")
+	if got := l.GetString("embed_subtitle"); got != "Sous-titre" {
+		t.Errorf("GetString() = %q, want the loaded value", got)
+	}
+	if got := l.GetString("embed_title"); got != "Titre" {
+		t.Errorf("GetString() after LoadLocale = %q, want the embedded value", got)
+	}
+}
+
+func TestEmbeddedTable_duplicate(t *testing.T) {
+	_, err := newEmbeddedTable(&types.Nesting{Strings: []*types.String{
+		{Name: "embed_a", Value: "a"}, {Name: "embed_dup", Value: "1"}, {Name: "embed_dup", Value: "2"},
+	}})
+	if !errors.Is(err, ErrorPerfectHash) || !strings.Contains(err.Error(), `string "embed_dup"`) {
+		t.Errorf("newEmbeddedTable() error = %v, want a duplicate embed_dup error", err)
+	}
+}
+
+func TestGenerateEmbedded(t *testing.T) {
+	buf := bytes.Buffer{}
+	err := GenerateEmbedded(&buf, "resources", map[string]*types.Nesting{
+		"":   {Strings: []*types.String{{Name: "app_name", Value: "stres \"quoted\"\n"}}},
+		"it": {Strings: []*types.String{{Name: "app_name", Value: "stres"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := buf.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "stres_gen.go", src, 0); err != nil {
+		t.Fatalf("GenerateEmbedded() wrote invalid Go: %v\n%s", err, src)
+	}
+	for _, want := range []string{"package resources", `stres.RegisterEmbedded("", stresTable0)`, `stres.RegisterEmbedded("it", stresTable1)`} {
+		if !strings.Contains(src, want) {
+			t.Errorf("GenerateEmbedded() output misses %q:\n%s", want, src)
+		}
+	}
+}
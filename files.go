@@ -0,0 +1,319 @@
+package stres
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+var mixedFormats = false
+
+/*
+	Allows resource files of different formats in the same resource directory, each decoded according to
+	its extension. Otherwise only the files of the setted resource type are loaded. (default value: false)
+*/
+func SetMixedFormats(enabled bool) {
+	mixedFormats = enabled
+}
+
+// resourceFile is a resource file and its format.
+type resourceFile struct {
+	path string
+	t    types.FileType
+}
+
+// resourceKey identifies a resource by kind and name.
+type resourceKey struct {
+	kind, name string
+}
+
+// Files the default locale's resources were loaded from, guarded by origins_mu.
+var resource_origins = map[resourceKey]resourceFile{}
+var origins_mu sync.RWMutex
+
+// setOrigins records the files resources of the default locale were loaded from or written to.
+func setOrigins(origins map[resourceKey]resourceFile) {
+	origins_mu.Lock()
+	defer origins_mu.Unlock()
+
+	maps.Copy(resource_origins, origins)
+}
+
+// resourceFiles lists the resource files of a directory: the ones with the extension of t, or of any FileType
+// if mixed formats are enabled. The "strings" file of type t comes first, the others follow by name.
+func resourceFiles(dir string, t types.FileType) ([]resourceFile, error) {
+	names, err := store.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	main := path.Join(dir, "strings."+string(t))
+	var files []resourceFile
+	for _, name := range names {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		ft, err := fileTypeOf(name)
+		if err != nil || (ft != t && !mixedFormats) {
+			continue
+		}
+		files = append(files, resourceFile{path: path.Join(dir, name), t: ft})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if pi, pj := trimCompression(files[i].path), trimCompression(files[j].path); pi == main || pj == main {
+			return pi == main && pj != main
+		}
+		return files[i].path < files[j].path
+	})
+	return files, nil
+}
+
+// readResources decodes every resource file of a locale into one Nesting, verifying the files' signature if a
+// verification key is set and validating ICU messages if enabled.
+// Returns the file each resource comes from; a resource defined by more than one file is an error.
+// With an EntryStore, the resources are its entries and come from no file.
+func readResources(locale string, t types.FileType) (*types.Nesting, map[resourceKey]resourceFile, error) {
+	if es, ok := store.(EntryStore); ok {
+		return readEntries(es, locale)
+	}
+
+	files, err := resourceFiles(resourceDirOf(locale), t)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	if len(files) == 0 {
+		// Report the missing resource file.
+		files = []resourceFile{{path: compressedPath(ResourcePath(locale, t)), t: t}}
+	}
+
+	var hashes map[string]string
+	if verificationKey != nil {
+		if hashes, err = readManifest(locale); err != nil {
+			return nil, nil, err
+		}
+		if len(hashes) != len(files) {
+			return nil, nil, fmt.Errorf("%w: %s doesn't list the resource files", ErrorSignature, path.Join(resourceDirOf(locale), manifestName))
+		}
+	}
+
+	merged := &types.Nesting{}
+	origins := map[resourceKey]resourceFile{}
+	add := func(f resourceFile, kind, name string) error {
+		k := resourceKey{kind, name}
+		if other, ok := origins[k]; ok {
+			return fmt.Errorf("%w: %q in %s and %s", duplicateError(kind), name, other.path, f.path)
+		}
+		origins[k] = f
+		return nil
+	}
+
+	for _, f := range files {
+		var n *types.Nesting
+		if hashes != nil {
+			n, err = readVerified(f, hashes)
+		} else {
+			n, err = readNesting(f.path, f.t)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if messageFormat {
+			if err = validateMessages(n); err != nil {
+				return nil, nil, parseError(f.path, f.t, err)
+			}
+		}
+
+		for _, s := range n.Strings {
+			if err = add(f, KindString, s.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, sa := range n.StringsArray {
+			if err = add(f, KindStringArray, sa.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, pl := range n.Plurals {
+			if err = add(f, KindPlurals, pl.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, i := range n.Integers {
+			if err = add(f, KindInteger, i.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, b := range n.Bools {
+			if err = add(f, KindBool, b.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, ia := range n.IntegerArrays {
+			if err = add(f, KindIntegerArray, ia.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, a := range n.Arrays {
+			if err = add(f, KindArray, a.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		merged.Strings = append(merged.Strings, n.Strings...)
+		merged.StringsArray = append(merged.StringsArray, n.StringsArray...)
+		merged.Plurals = append(merged.Plurals, n.Plurals...)
+		merged.Integers = append(merged.Integers, n.Integers...)
+		merged.Bools = append(merged.Bools, n.Bools...)
+		merged.IntegerArrays = append(merged.IntegerArrays, n.IntegerArrays...)
+		merged.Arrays = append(merged.Arrays, n.Arrays...)
+	}
+	return merged, origins, nil
+}
+
+// readEntries returns the resources of a locale in an EntryStore, validating ICU messages if enabled.
+func readEntries(es EntryStore, locale string) (*types.Nesting, map[resourceKey]resourceFile, error) {
+	if verificationKey != nil {
+		return nil, nil, fmt.Errorf("%w: the entries of %s aren't signed", ErrorSignature, resourceDirOf(locale))
+	}
+
+	n, err := es.Entries(locale)
+	if err != nil {
+		return nil, nil, err
+	}
+	if messageFormat {
+		if err = validateMessages(n); err != nil {
+			return nil, nil, parseError(resourceDirOf(locale), fileType, err)
+		}
+	}
+	return n, nil, nil
+}
+
+func duplicateError(kind string) error {
+	switch kind {
+	case KindStringArray:
+		return ErrorDuplicateStringArrayName
+	case KindPlurals:
+		return ErrorDuplicateQuantityStringName
+	case KindInteger:
+		return ErrorDuplicateIntegerName
+	case KindBool:
+		return ErrorDuplicateBoolName
+	case KindIntegerArray:
+		return ErrorDuplicateIntegerArrayName
+	case KindArray:
+		return ErrorDuplicateArrayName
+	default:
+		return ErrorDuplicateStringName
+	}
+}
+
+// originOf returns the file a resource of the default locale was loaded from, the "strings" file for new ones.
+func originOf(kind, name string) resourceFile {
+	origins_mu.RLock()
+	f, ok := resource_origins[resourceKey{kind, name}]
+	origins_mu.RUnlock()
+
+	if ok {
+		return f
+	}
+	return resourceFile{path: compressedPath(ResourcePath("", fileType)), t: fileType}
+}
+
+// editOrigin applies an edit to the file the resource comes from and records its new origin, or to the entry of
+// an EntryStore.
+func editOrigin(e resourceEdit) error {
+	if es, ok := store.(EntryStore); ok {
+		return es.PutEntry("", e.kind, e.name, e.value)
+	}
+
+	f := originOf(e.kind, e.name)
+	if err := editResource(f.path, f.t, e); err != nil {
+		return err
+	}
+
+	origins_mu.Lock()
+	defer origins_mu.Unlock()
+
+	if e.value == nil {
+		delete(resource_origins, resourceKey{e.kind, e.name})
+	} else {
+		resource_origins[resourceKey{e.kind, e.name}] = f
+	}
+	return nil
+}
+
+// writeEdits applies edits to the resource files of a locale. Resources are written back to the file they come
+// from according to origins, new ones to the "strings" file, created if needed.
+// Returns the file each edited resource was written to. With an EntryStore, the edits are applied to its entries.
+func writeEdits(locale string, origins map[resourceKey]resourceFile, edits []resourceEdit) (map[resourceKey]resourceFile, error) {
+	if es, ok := store.(EntryStore); ok {
+		for _, e := range edits {
+			if err := es.PutEntry(locale, e.kind, e.name, e.value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	main := resourceFile{path: compressedPath(ResourcePath(locale, fileType)), t: fileType}
+	var targets []resourceFile
+	byFile := map[resourceFile][]resourceEdit{}
+	written := map[resourceKey]resourceFile{}
+	for _, e := range edits {
+		f, ok := origins[resourceKey{e.kind, e.name}]
+		if !ok {
+			f = main
+		}
+		if _, ok = byFile[f]; !ok {
+			targets = append(targets, f)
+		}
+		byFile[f] = append(byFile[f], e)
+		written[resourceKey{e.kind, e.name}] = f
+	}
+
+	for _, f := range targets {
+		_, err := store.Stat(f.path)
+		switch {
+		case err == nil:
+			err = editResource(f.path, f.t, byFile[f]...)
+		case errors.Is(err, os.ErrNotExist):
+			n := &types.Nesting{}
+			for _, e := range byFile[f] {
+				if err = applyEdit(n, e); err != nil {
+					return nil, err
+				}
+			}
+			err = writeNesting(f.path, f.t, n)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return written, nil
+}
+
+// readLocales reads the resources of the default locale, keyed by the empty string, and of every translation.
+func readLocales(t types.FileType) (map[string]*types.Nesting, error) {
+	locales, err := Locales()
+	if err != nil {
+		return nil, err
+	}
+
+	nestings := map[string]*types.Nesting{}
+	for _, locale := range append([]string{""}, locales...) {
+		n, _, err := readResources(locale, t)
+		if err != nil {
+			return nil, err
+		}
+		nestings[locale] = n
+	}
+	return nestings, nil
+}
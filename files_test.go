@@ -0,0 +1,78 @@
+package stres
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadValues_files(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetMixedFormats(false)
+		SetResourceType(XML)
+	})
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="files_app_name">stres</string>
+</resources>`)
+	writeFile(t, "strings/errors.xml", `<resources>
+	<string name="files_error">Something went wrong</string>
+</resources>`)
+	writeFile(t, "strings/onboarding.yml", `string:
+  - name: files_onboarding
+    value: Welcome aboard
+`)
+
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if GetString("files_error") != "Something went wrong" || GetString("files_onboarding") != "" {
+		t.Errorf("LoadValues() didn't load only the XML files")
+	}
+
+	if _, err := UpdateString("files_error", "Try again"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile("strings/errors.xml"); !strings.Contains(string(got), "Try again") {
+		t.Errorf("UpdateString() didn't write to the origin file:\n%s", got)
+	}
+	if got, _ := os.ReadFile("strings/strings.xml"); strings.Contains(string(got), "files_error") {
+		t.Errorf("UpdateString() wrote to the strings file:\n%s", got)
+	}
+
+	SetMixedFormats(true)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if GetString("files_onboarding") != "Welcome aboard" {
+		t.Errorf("LoadValues() didn't load the YAML file with mixed formats")
+	}
+
+	if err := RemoveString("files_onboarding"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile("strings/onboarding.yml"); strings.Contains(string(got), "files_onboarding") {
+		t.Errorf("RemoveString() didn't remove from the origin file:\n%s", got)
+	}
+
+	writeFile(t, "strings/duplicates.xml", `<resources>
+	<string name="files_app_name">stres</string>
+</resources>`)
+	if err := LoadValues(XML); !errors.Is(err, ErrorDuplicateStringName) {
+		t.Errorf("LoadValues() error = %v, want %v", err, ErrorDuplicateStringName)
+	}
+}
+
+func TestWriteEdits_newFile(t *testing.T) {
+	inTempDir(t)
+
+	_, err := writeEdits("fr", nil, []resourceEdit{{kind: KindString, name: "files_missing"}})
+	if !errors.Is(err, ErrorNotFound) {
+		t.Errorf("writeEdits() removing from a missing file = %v, want ErrorNotFound", err)
+	}
+	if _, err := os.Stat(ResourcePath("fr", XML)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("writeEdits() created %s on error", ResourcePath("fr", XML))
+	}
+}
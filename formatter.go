@@ -0,0 +1,383 @@
+package stres
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var ErrorUnknownFileType error = errors.New("stres: unknown resource file type")
+
+/*
+	Options of FormatFile.
+*/
+type FormatOptions struct {
+	Sort  bool // sort resources by name instead of keeping their declaration order
+	Check bool // only report whether the file would change, without writing it
+}
+
+/*
+	Rewrites the resource file at path in canonical form: the encoding of its strategy, with quantity strings'
+	items ordered zero→many and, if required, resources sorted by name. The file type is taken from the path's
+	extension. XML and YAML files are normalized in place, keeping their comments (moved along with the resource
+	they precede), the XML declaration and the root element's attributes; elements holding comments or unknown
+	attributes are kept as they are. Returns whether the file was not already formatted.
+	(Named FormatFile rather than Format, which formats ICU messages.)
+*/
+func FormatFile(path string, opts FormatOptions) (bool, error) {
+	t, err := fileTypeOf(path)
+	if err != nil {
+		return false, err
+	}
+
+	unlock, err := store.Lock(path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	d, err := readBytes(path)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := readNesting(path, t)
+	if err != nil {
+		return false, err
+	}
+
+	var out []byte
+	switch {
+	case t == XML && len(bytes.TrimSpace(d)) > 0:
+		canonicalize(n, false)
+		out, err = formatXML(d, n, opts.Sort)
+	case t == YAML && len(bytes.TrimSpace(d)) > 0:
+		out, err = formatYAML(d, opts.Sort)
+	default:
+		canonicalize(n, opts.Sort)
+		ed := types.EncoderDecoder{}
+		ed.SetStrategy(strategyFor(t))
+		out, err = ed.Encode(n)
+	}
+	if err != nil {
+		return false, parseError(path, t, err)
+	}
+
+	if bytes.Equal(d, out) {
+		return false, nil
+	}
+	if opts.Check {
+		return true, nil
+	}
+	return true, writeBytes(path, out)
+}
+
+// canonicalize orders the quantity strings' items and, if sorted is true, the resources in n.
+func canonicalize(n *types.Nesting, sorted bool) {
+	for _, pl := range n.Plurals {
+		sort.SliceStable(pl.Items, func(i, j int) bool {
+			return quantityOrder(pl.Items[i].Quantity) < quantityOrder(pl.Items[j].Quantity)
+		})
+	}
+
+	if !sorted {
+		return
+	}
+	sort.SliceStable(n.Strings, func(i, j int) bool {
+		return n.Strings[i].Name < n.Strings[j].Name
+	})
+	sort.SliceStable(n.StringsArray, func(i, j int) bool {
+		return n.StringsArray[i].Name < n.StringsArray[j].Name
+	})
+	sort.SliceStable(n.Plurals, func(i, j int) bool {
+		return n.Plurals[i].Name < n.Plurals[j].Name
+	})
+	sort.SliceStable(n.Integers, func(i, j int) bool {
+		return n.Integers[i].Name < n.Integers[j].Name
+	})
+	sort.SliceStable(n.Bools, func(i, j int) bool {
+		return n.Bools[i].Name < n.Bools[j].Name
+	})
+	sort.SliceStable(n.IntegerArrays, func(i, j int) bool {
+		return n.IntegerArrays[i].Name < n.IntegerArrays[j].Name
+	})
+	sort.SliceStable(n.Arrays, func(i, j int) bool {
+		return n.Arrays[i].Name < n.Arrays[j].Name
+	})
+}
+
+// resourceKinds are the resource kinds in the order the strategies encode them.
+var resourceKinds = []string{KindString, KindStringArray, KindPlurals, KindInteger, KindBool, KindIntegerArray, KindArray}
+
+func kindOrder(kind string) int {
+	for i, k := range resourceKinds {
+		if k == kind {
+			return i
+		}
+	}
+	return len(resourceKinds)
+}
+
+// xmlUnit is a resource element of an XML document with its comments.
+type xmlUnit struct {
+	kind, name string
+	lead       []string // comments on the lines before the element
+	text       string   // the element
+	trail      []string // comments following the element on its line
+}
+
+// xmlAttributes are the attributes the resource types encode.
+var xmlAttributes = map[string]bool{"name": true, "description": true, "translatable": true, "formatted": true, "maxLength": true, "tags": true}
+
+// formatXML re-encodes the resource elements of an XML document with the canonical indentation, grouped by kind
+// and, if sorted is true, by name. Everything outside the root element's content is kept.
+func formatXML(data []byte, n *types.Nesting, sorted bool) ([]byte, error) {
+	elements, rootEnd, err := scanXML(data)
+	if errors.Is(err, errorEditUnsupported) {
+		// Self-closing root element: nothing to format.
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	contentStart := xmlContentStart(data)
+
+	// The decoded resources of each kind, in document order.
+	values := map[string][]interface{}{}
+	for _, v := range n.Strings {
+		values[KindString] = append(values[KindString], v)
+	}
+	for _, v := range n.StringsArray {
+		values[KindStringArray] = append(values[KindStringArray], v)
+	}
+	for _, v := range n.Plurals {
+		values[KindPlurals] = append(values[KindPlurals], v)
+	}
+	for _, v := range n.Integers {
+		values[KindInteger] = append(values[KindInteger], v)
+	}
+	for _, v := range n.Bools {
+		values[KindBool] = append(values[KindBool], v)
+	}
+	for _, v := range n.IntegerArrays {
+		values[KindIntegerArray] = append(values[KindIntegerArray], v)
+	}
+	for _, v := range n.Arrays {
+		values[KindArray] = append(values[KindArray], v)
+	}
+
+	var units []*xmlUnit
+	var rootTrail []string
+	prev := contentStart
+	for _, el := range elements {
+		trail, lead := xmlTrivia(data[prev:el.start])
+		if len(units) == 0 {
+			rootTrail = trail
+		} else {
+			units[len(units)-1].trail = trail
+		}
+
+		u := &xmlUnit{kind: el.kind, name: el.name, lead: lead, text: string(data[el.start:el.end])}
+		var value interface{}
+		if vs := values[el.kind]; len(vs) > 0 {
+			value, values[el.kind] = vs[0], vs[1:]
+		}
+		if value != nil && xmlReencodable(data, el) {
+			if u.text, err = xmlElementText(value, "\t", "\t"); err != nil {
+				return nil, err
+			}
+		}
+		units = append(units, u)
+		prev = el.end
+	}
+	trail, tail := xmlTrivia(data[prev:rootEnd])
+	if len(units) == 0 {
+		return data, nil
+	}
+	units[len(units)-1].trail = trail
+
+	sort.SliceStable(units, func(i, j int) bool {
+		if ki, kj := kindOrder(units[i].kind), kindOrder(units[j].kind); ki != kj {
+			return ki < kj
+		}
+		return sorted && units[i].name < units[j].name
+	})
+
+	b := bytes.Buffer{}
+	b.Write(data[:contentStart])
+	for _, c := range rootTrail {
+		b.WriteString(" " + c)
+	}
+	for _, u := range units {
+		for _, c := range u.lead {
+			b.WriteString("\n\t" + c)
+		}
+		b.WriteString("\n\t" + u.text)
+		for _, c := range u.trail {
+			b.WriteString(" " + c)
+		}
+	}
+	for _, c := range tail {
+		b.WriteString("\n\t" + c)
+	}
+	b.WriteString("\n")
+	b.Write(data[rootEnd:])
+	return b.Bytes(), nil
+}
+
+// xmlContentStart returns the offset following the start tag of the root element.
+func xmlContentStart(data []byte) int64 {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			return 0
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			return dec.InputOffset()
+		}
+	}
+}
+
+// xmlTrivia splits the comments and other non-blank text between two elements into those on the first line,
+// following the previous element, and the others.
+func xmlTrivia(data []byte) (trail, lead []string) {
+	firstLine := bytes.IndexByte(data, '\n')
+	if firstLine < 0 {
+		firstLine = len(data)
+	}
+
+	add := func(at int, text []byte) {
+		text = bytes.TrimSpace(text)
+		if len(text) == 0 {
+			return
+		}
+		if at < firstLine {
+			trail = append(trail, string(text))
+		} else {
+			lead = append(lead, string(text))
+		}
+	}
+
+	pos := 0
+	for {
+		start := bytes.Index(data[pos:], []byte("<!--"))
+		if start < 0 {
+			break
+		}
+		start += pos
+		end := bytes.Index(data[start:], []byte("-->"))
+		if end < 0 {
+			break
+		}
+		end += start + len("-->")
+
+		add(pos+len(data[pos:start])-len(bytes.TrimLeft(data[pos:start], " \t\r\n")), data[pos:start])
+		add(start, data[start:end])
+		pos = end
+	}
+	add(pos+len(data[pos:])-len(bytes.TrimLeft(data[pos:], " \t\r\n")), data[pos:])
+	return trail, lead
+}
+
+// xmlReencodable reports whether re-encoding an element from its decoded value keeps all of its content.
+func xmlReencodable(data []byte, el xmlElement) bool {
+	if bytes.Contains(data[el.innerStart:el.innerEnd], []byte("<!--")) {
+		return false
+	}
+	for _, attr := range el.attrs {
+		if attr.Name.Space != "" || !xmlAttributes[attr.Name.Local] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatYAML orders the resource lists of a YAML document by kind, their entries by name if sorted is true and
+// the items of quantity strings, keeping comments and styles.
+func formatYAML(data []byte, sorted bool) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+	root := doc.Content[0]
+
+	type pair struct{ key, value *yaml.Node }
+	var pairs []pair
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		pairs = append(pairs, pair{root.Content[i], root.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return kindOrder(pairs[i].key.Value) < kindOrder(pairs[j].key.Value)
+	})
+	root.Content = root.Content[:0]
+	for _, p := range pairs {
+		root.Content = append(root.Content, p.key, p.value)
+		if p.value.Kind != yaml.SequenceNode || kindOrder(p.key.Value) == len(resourceKinds) {
+			continue
+		}
+
+		for _, entry := range p.value.Content {
+			if items := yamlValue(entry, "items"); p.key.Value == KindPlurals && items != nil {
+				sort.SliceStable(items.Content, func(i, j int) bool {
+					return quantityOrder(yamlScalarValue(items.Content[i], "quantity")) <
+						quantityOrder(yamlScalarValue(items.Content[j], "quantity"))
+				})
+			}
+		}
+		if sorted {
+			sort.SliceStable(p.value.Content, func(i, j int) bool {
+				return yamlScalarValue(p.value.Content[i], "name") < yamlScalarValue(p.value.Content[j], "name")
+			})
+		}
+	}
+
+	buf := bytes.Buffer{}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlScalarValue returns the value of a key in a mapping node, empty if missing.
+func yamlScalarValue(mapping *yaml.Node, key string) string {
+	if v := yamlValue(mapping, key); v != nil {
+		return v.Value
+	}
+	return ""
+}
+
+// quantityOrder sorts unknown quantities after the known ones.
+func quantityOrder(quantity string) int {
+	if idx := quantityIndex(quantity); idx >= 0 {
+		return idx
+	}
+	return len(quantityValues)
+}
+
+// fileTypeOf returns the FileType of a resource file from its extension, ignoring compression suffixes.
+func fileTypeOf(path string) (types.FileType, error) {
+	path = trimCompression(path)
+
+	// Longest extensions first, "i18next.json" before "json".
+	for _, t := range []types.FileType{I18NEXT, XML, YAML, JSON, TOML, WATSON, MSGPACK, ARB} {
+		if strings.HasSuffix(path, "."+string(t)) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrorUnknownFileType, path)
+}
@@ -0,0 +1,133 @@
+package stres
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFormatFile(t *testing.T) {
+	inTempDir(t)
+
+	unformatted := `<resources>
+  <string name="welcome">Welcome</string>
+      <string name="app_name">stres</string>
+  <plurals name="files">
+    <item quantity="many">files</item>
+    <item quantity="one">file</item>
+  </plurals>
+</resources>`
+	want := `<resources>
+	<string name="app_name">stres</string>
+	<string name="welcome">Welcome</string>
+	<plurals name="files">
+		<item quantity="one">file</item>
+		<item quantity="many">files</item>
+	</plurals>
+</resources>`
+	writeFile(t, "strings/strings.xml", unformatted)
+
+	changed, err := FormatFile("strings/strings.xml", FormatOptions{Sort: true, Check: true})
+	if err != nil || !changed {
+		t.Fatalf("FormatFile() = %v, %v, want true", changed, err)
+	}
+	if got, _ := os.ReadFile("strings/strings.xml"); string(got) != unformatted {
+		t.Errorf("FormatFile() wrote the file in check mode")
+	}
+
+	if changed, err = FormatFile("strings/strings.xml", FormatOptions{Sort: true}); err != nil || !changed {
+		t.Fatalf("FormatFile() = %v, %v, want true", changed, err)
+	}
+	if got, _ := os.ReadFile("strings/strings.xml"); string(got) != want {
+		t.Errorf("FormatFile() wrote\n%s\nwant\n%s", got, want)
+	}
+
+	if changed, err = FormatFile("strings/strings.xml", FormatOptions{Sort: true, Check: true}); err != nil || changed {
+		t.Errorf("FormatFile() = %v, %v on a formatted file, want false", changed, err)
+	}
+
+	writeFile(t, "strings-it/strings.i18next.json", `{"b": "B", "a": {"x": "X"}}`)
+	if changed, err = FormatFile("strings-it/strings.i18next.json", FormatOptions{}); err != nil || !changed {
+		t.Errorf("FormatFile() = %v, %v, want true", changed, err)
+	}
+
+	if _, err = FormatFile("strings/strings.txt", FormatOptions{}); !errors.Is(err, ErrorUnknownFileType) {
+		t.Errorf("FormatFile() error = %v, want %v", err, ErrorUnknownFileType)
+	}
+}
+
+func TestFormatFile_preserve(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "strings/strings.xml", `<?xml version="1.0" encoding="utf-8"?>
+<!-- Generated strings -->
+<resources xmlns:tools="http://schemas.android.com/tools">
+  <!-- Shown on the home screen -->
+  <string name="welcome">Welcome</string> <!-- keep short -->
+      <string name="app_name" tools:ignore="MissingTranslation">stres</string>
+  <plurals name="files">
+    <item quantity="many">files</item>
+    <item quantity="one">file</item>
+  </plurals>
+  <string name="about">About</string>
+  <!-- End of strings -->
+</resources>
+`)
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<!-- Generated strings -->
+<resources xmlns:tools="http://schemas.android.com/tools">
+	<string name="about">About</string>
+	<string name="app_name" tools:ignore="MissingTranslation">stres</string>
+	<!-- Shown on the home screen -->
+	<string name="welcome">Welcome</string> <!-- keep short -->
+	<plurals name="files">
+		<item quantity="one">file</item>
+		<item quantity="many">files</item>
+	</plurals>
+	<!-- End of strings -->
+</resources>
+`
+	if _, err := FormatFile("strings/strings.xml", FormatOptions{Sort: true}); err != nil {
+		t.Fatalf("FormatFile() error = %v", err)
+	}
+	if got, _ := os.ReadFile("strings/strings.xml"); string(got) != want {
+		t.Errorf("FormatFile() wrote\n%s\nwant\n%s", got, want)
+	}
+	if changed, err := FormatFile("strings/strings.xml", FormatOptions{Sort: true, Check: true}); err != nil || changed {
+		t.Errorf("FormatFile() = %v, %v on a formatted file, want false", changed, err)
+	}
+
+	writeFile(t, "strings-it/strings.yml", `# Italian strings
+plurals:
+  - name: files
+    items:
+      - quantity: many
+        value: file
+      - quantity: one
+        value: file
+string:
+  # Home screen
+  - name: welcome
+    value: Benvenuto
+  - name: app_name
+    value: stres # brand
+`)
+	if _, err := FormatFile("strings-it/strings.yml", FormatOptions{Sort: true}); err != nil {
+		t.Fatalf("FormatFile() error = %v", err)
+	}
+	got, _ := os.ReadFile("strings-it/strings.yml")
+	for _, comment := range []string{"# Italian strings", "# Home screen", "# brand"} {
+		if !strings.Contains(string(got), comment) {
+			t.Errorf("FormatFile() dropped %q:\n%s", comment, got)
+		}
+	}
+	if strings.Index(string(got), "string:") > strings.Index(string(got), "plurals:") ||
+		strings.Index(string(got), "app_name") > strings.Index(string(got), "welcome") ||
+		strings.Index(string(got), "one") > strings.Index(string(got), "many") {
+		t.Errorf("FormatFile() didn't reorder the resources:\n%s", got)
+	}
+	if changed, err := FormatFile("strings-it/strings.yml", FormatOptions{Sort: true, Check: true}); err != nil || changed {
+		t.Errorf("FormatFile() = %v, %v on a formatted file, want false", changed, err)
+	}
+}
@@ -0,0 +1,251 @@
+// Package icu parses and formats ICU MessageFormat messages such as
+// "{count, plural, one {# file} other {# files}} in {folder}".
+//
+// Supported arguments are simple ones ("{name}", "{n, number}"), plural, selectordinal
+// and select, nested at any depth. Plural categories follow the English CLDR rules
+// unless the message is formatted with other PluralRules, such as the ones of RulesFor.
+package icu
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrorMissingArgument error = errors.New("icu: missing argument")
+
+// SyntaxError reports a malformed message and the byte offset where parsing failed.
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("icu: syntax error at offset %d: %s", e.Offset, e.Msg)
+}
+
+// PluralRules returns the plural category ("zero", "one", "two", "few", "many" or "other")
+// of n, as a cardinal or as an ordinal number.
+type PluralRules func(n float64, ordinal bool) string
+
+// English implements the CLDR plural rules of the English language.
+func English(n float64, ordinal bool) string {
+	if !ordinal {
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+
+	if n != float64(int64(n)) {
+		return "other"
+	}
+	i := int64(n)
+	if i < 0 {
+		i = -i
+	}
+	switch {
+	case i%10 == 1 && i%100 != 11:
+		return "one"
+	case i%10 == 2 && i%100 != 12:
+		return "two"
+	case i%10 == 3 && i%100 != 13:
+		return "few"
+	}
+	return "other"
+}
+
+// Message is a parsed message, safe for concurrent use.
+type Message struct {
+	parts []part
+}
+
+type part interface {
+	format(b *strings.Builder, args map[string]interface{}, rules PluralRules, pound *float64) error
+}
+
+type text string
+
+type pound struct{}
+
+type simpleArg struct {
+	name  string
+	typ   string
+	style string
+}
+
+type pluralArg struct {
+	name    string
+	ordinal bool
+	offset  float64
+	cases   []pluralCase
+}
+
+type pluralCase struct {
+	selector string
+	msg      *Message
+}
+
+type selectArg struct {
+	name  string
+	cases []pluralCase
+}
+
+// Parse parses an ICU message. Errors are of type *SyntaxError.
+func Parse(s string) (*Message, error) {
+	p := &parser{s: s}
+	m, err := p.message(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(s) {
+		return nil, p.errorf("unexpected %q", s[p.pos])
+	}
+	return m, nil
+}
+
+// Format formats the message with the given arguments using the English plural rules.
+func (m *Message) Format(args map[string]interface{}) (string, error) {
+	return m.FormatWith(args, English)
+}
+
+// FormatWith formats the message with the given arguments and plural rules.
+func (m *Message) FormatWith(args map[string]interface{}, rules PluralRules) (string, error) {
+	b := strings.Builder{}
+	if err := m.format(&b, args, rules, nil); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (m *Message) format(b *strings.Builder, args map[string]interface{}, rules PluralRules, n *float64) error {
+	for _, p := range m.parts {
+		if err := p.format(b, args, rules, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t text) format(b *strings.Builder, args map[string]interface{}, rules PluralRules, n *float64) error {
+	b.WriteString(string(t))
+	return nil
+}
+
+func (pound) format(b *strings.Builder, args map[string]interface{}, rules PluralRules, n *float64) error {
+	if n == nil {
+		b.WriteByte('#')
+		return nil
+	}
+	b.WriteString(formatNumber(*n))
+	return nil
+}
+
+func (a *simpleArg) format(b *strings.Builder, args map[string]interface{}, rules PluralRules, n *float64) error {
+	v, ok := args[a.name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrorMissingArgument, a.name)
+	}
+
+	if a.typ == "number" {
+		f, ok := toFloat(v)
+		if !ok {
+			return fmt.Errorf("icu: argument %s is not a number", a.name)
+		}
+		if a.style == "percent" {
+			f *= 100
+			b.WriteString(formatNumber(f) + "%")
+			return nil
+		}
+		b.WriteString(formatNumber(f))
+		return nil
+	}
+
+	if f, ok := toFloat(v); ok {
+		b.WriteString(formatNumber(f))
+		return nil
+	}
+	fmt.Fprint(b, v)
+	return nil
+}
+
+func (a *pluralArg) format(b *strings.Builder, args map[string]interface{}, rules PluralRules, n *float64) error {
+	v, ok := args[a.name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrorMissingArgument, a.name)
+	}
+	f, ok := toFloat(v)
+	if !ok {
+		return fmt.Errorf("icu: argument %s is not a number", a.name)
+	}
+
+	exact := "=" + formatNumber(f)
+	for _, c := range a.cases {
+		if c.selector == exact {
+			rel := f - a.offset
+			return c.msg.format(b, args, rules, &rel)
+		}
+	}
+
+	rel := f - a.offset
+	category := rules(rel, a.ordinal)
+	msg := selectCase(a.cases, category)
+	return msg.format(b, args, rules, &rel)
+}
+
+func (a *selectArg) format(b *strings.Builder, args map[string]interface{}, rules PluralRules, n *float64) error {
+	v, ok := args[a.name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrorMissingArgument, a.name)
+	}
+	return selectCase(a.cases, fmt.Sprint(v)).format(b, args, rules, n)
+}
+
+// selectCase returns the case matching the selector, or the mandatory "other" case.
+func selectCase(cases []pluralCase, selector string) *Message {
+	var other *Message
+	for _, c := range cases {
+		if c.selector == selector {
+			return c.msg
+		}
+		if c.selector == "other" {
+			other = c.msg
+		}
+	}
+	return other
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,140 @@
+package icu
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMessage_Format(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		args    map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "simple",
+			msg:  "Hello {name}!",
+			args: map[string]interface{}{"name": "Ann"},
+			want: "Hello Ann!",
+		},
+		{
+			name: "plural_one",
+			msg:  "{count, plural, one {# file} other {# files}} in {folder}",
+			args: map[string]interface{}{"count": 1, "folder": "docs"},
+			want: "1 file in docs",
+		},
+		{
+			name: "plural_other",
+			msg:  "{count, plural, one {# file} other {# files}} in {folder}",
+			args: map[string]interface{}{"count": 3, "folder": "docs"},
+			want: "3 files in docs",
+		},
+		{
+			name: "plural_exact_offset",
+			msg:  "{n, plural, offset:1 =0 {nobody} =1 {{host}} one {{host} and # other} other {{host} and # others}}",
+			args: map[string]interface{}{"n": 3, "host": "Bob"},
+			want: "Bob and 2 others",
+		},
+		{
+			name: "selectordinal",
+			msg:  "{pos, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+			args: map[string]interface{}{"pos": 22},
+			want: "22nd",
+		},
+		{
+			name: "nested_select",
+			msg:  "{gender, select, female {{n, plural, one {She has # cat} other {She has # cats}}} other {{n, plural, one {They have # cat} other {They have # cats}}}}",
+			args: map[string]interface{}{"gender": "female", "n": 2},
+			want: "She has 2 cats",
+		},
+		{
+			name: "quoted",
+			msg:  "It''s '{literal}' {n, number}",
+			args: map[string]interface{}{"n": 1.5},
+			want: "It's {literal} 1.5",
+		},
+		{
+			name:    "missing_argument",
+			msg:     "Hello {name}",
+			args:    map[string]interface{}{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.msg)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := m.Format(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Format() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    string
+		offset int
+	}{
+		{name: "unclosed", msg: "Hello {name", offset: 6},
+		{name: "no_other", msg: "{n, plural, one {a}}", offset: 20},
+		{name: "unknown_type", msg: "{n, foo}", offset: 7},
+		{name: "stray_brace", msg: "a } b", offset: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.msg)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse() error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Offset != tt.offset {
+				t.Errorf("Parse() offset = %d, want %d", syntaxErr.Offset, tt.offset)
+			}
+		})
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	tests := []struct {
+		locale  string
+		n       float64
+		ordinal bool
+		want    string
+	}{
+		{locale: "", n: 0, want: "other"},
+		{locale: "en-US", n: 1, want: "one"},
+		{locale: "en", n: 2, ordinal: true, want: "two"},
+		{locale: "fr", n: 0, want: "one"},
+		{locale: "pt-BR", n: 1.5, want: "one"},
+		{locale: "fr", n: 2, ordinal: true, want: "two"},
+		{locale: "ja", n: 1, want: "other"},
+		{locale: "ru", n: 21, want: "one"},
+		{locale: "ru", n: 23, want: "few"},
+		{locale: "ru", n: 12, want: "many"},
+		{locale: "ru", n: 1.5, want: "other"},
+		{locale: "pl", n: 21, want: "many"},
+		{locale: "pl_PL", n: 22, want: "few"},
+		{locale: "cs", n: 3, want: "few"},
+		{locale: "cs", n: 5, want: "other"},
+		{locale: "ar", n: 0, want: "zero"},
+		{locale: "ar", n: 2, want: "two"},
+		{locale: "ar", n: 103, want: "few"},
+		{locale: "ar", n: 11, want: "many"},
+		{locale: "ar", n: 100, want: "other"},
+	}
+	for _, tt := range tests {
+		if got := RulesFor(tt.locale)(tt.n, tt.ordinal); got != tt.want {
+			t.Errorf("RulesFor(%q)(%v, %v) = %q, want %q", tt.locale, tt.n, tt.ordinal, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package icu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// message parses text and arguments up to an unmatched '}' or the end of input.
+// Inside plural cases '#' is the number being formatted.
+func (p *parser) message(inPlural bool) (*Message, error) {
+	m := &Message{}
+	b := strings.Builder{}
+
+	flush := func() {
+		if b.Len() > 0 {
+			m.parts = append(m.parts, text(b.String()))
+			b.Reset()
+		}
+	}
+
+	for !p.eof() {
+		c := p.s[p.pos]
+		switch {
+		case c == '\'':
+			p.quoted(&b, inPlural)
+		case c == '{':
+			flush()
+			arg, err := p.argument()
+			if err != nil {
+				return nil, err
+			}
+			m.parts = append(m.parts, arg)
+		case c == '}':
+			flush()
+			return m, nil
+		case c == '#' && inPlural:
+			flush()
+			m.parts = append(m.parts, pound{})
+			p.pos++
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	flush()
+	return m, nil
+}
+
+// quoted handles an apostrophe: "''" is a literal apostrophe and an apostrophe before a
+// special character starts a literal section that ends at the next single apostrophe.
+func (p *parser) quoted(b *strings.Builder, inPlural bool) {
+	p.pos++
+	if p.eof() {
+		b.WriteByte('\'')
+		return
+	}
+
+	next := p.s[p.pos]
+	if next == '\'' {
+		b.WriteByte('\'')
+		p.pos++
+		return
+	}
+	if next != '{' && next != '}' && next != '|' && !(next == '#' && inPlural) {
+		b.WriteByte('\'')
+		return
+	}
+
+	for !p.eof() {
+		c := p.s[p.pos]
+		p.pos++
+		if c != '\'' {
+			b.WriteByte(c)
+			continue
+		}
+		if !p.eof() && p.s[p.pos] == '\'' {
+			b.WriteByte('\'')
+			p.pos++
+			continue
+		}
+		return
+	}
+}
+
+func (p *parser) identifier() string {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\r\n{},#'", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// argument parses "{name}", "{name, type[, style]}" or a complex argument.
+func (p *parser) argument() (part, error) {
+	start := p.pos
+	p.pos++
+	p.skipSpace()
+
+	name := p.identifier()
+	if name == "" {
+		return nil, p.errorf("expected argument name")
+	}
+	p.skipSpace()
+
+	if p.eof() {
+		p.pos = start
+		return nil, p.errorf("unclosed argument %s", name)
+	}
+	if p.s[p.pos] == '}' {
+		p.pos++
+		return &simpleArg{name: name}, nil
+	}
+	if p.s[p.pos] != ',' {
+		return nil, p.errorf("expected ',' or '}' after argument %s", name)
+	}
+	p.pos++
+	p.skipSpace()
+
+	typ := p.identifier()
+	p.skipSpace()
+
+	switch typ {
+	case "plural", "selectordinal":
+		return p.plural(name, typ == "selectordinal")
+	case "select":
+		cases, err := p.cases(name, false)
+		if err != nil {
+			return nil, err
+		}
+		return &selectArg{name: name, cases: cases}, nil
+	case "number", "date", "time", "spellout", "ordinal", "duration":
+	case "":
+		return nil, p.errorf("expected type of argument %s", name)
+	default:
+		return nil, p.errorf("unknown type %q of argument %s", typ, name)
+	}
+
+	arg := &simpleArg{name: name, typ: typ}
+	if p.eof() {
+		return nil, p.errorf("unclosed argument %s", name)
+	}
+	if p.s[p.pos] == ',' {
+		p.pos++
+		styleStart := p.pos
+		for !p.eof() && p.s[p.pos] != '}' {
+			p.pos++
+		}
+		arg.style = strings.TrimSpace(p.s[styleStart:p.pos])
+	}
+	if p.eof() || p.s[p.pos] != '}' {
+		return nil, p.errorf("unclosed argument %s", name)
+	}
+	p.pos++
+	return arg, nil
+}
+
+func (p *parser) plural(name string, ordinal bool) (part, error) {
+	if p.eof() || p.s[p.pos] != ',' {
+		return nil, p.errorf("expected ',' after plural type of argument %s", name)
+	}
+	p.pos++
+	p.skipSpace()
+
+	arg := &pluralArg{name: name, ordinal: ordinal}
+	if strings.HasPrefix(p.s[p.pos:], "offset:") {
+		p.pos += len("offset:")
+		p.skipSpace()
+		num := p.identifier()
+		offset, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return nil, p.errorf("invalid offset %q", num)
+		}
+		arg.offset = offset
+	}
+
+	cases, err := p.cases(name, true)
+	if err != nil {
+		return nil, err
+	}
+	arg.cases = cases
+	return arg, nil
+}
+
+// cases parses "selector {message} ..." up to the closing brace of the argument.
+func (p *parser) cases(name string, plural bool) ([]pluralCase, error) {
+	if !plural {
+		if p.eof() || p.s[p.pos] != ',' {
+			return nil, p.errorf("expected ',' after select type of argument %s", name)
+		}
+		p.pos++
+	}
+
+	var cases []pluralCase
+	hasOther := false
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unclosed argument %s", name)
+		}
+		if p.s[p.pos] == '}' {
+			p.pos++
+			break
+		}
+
+		selector := p.identifier()
+		if selector == "" {
+			return nil, p.errorf("expected selector in argument %s", name)
+		}
+		if plural && strings.HasPrefix(selector, "=") {
+			if _, err := strconv.ParseFloat(selector[1:], 64); err != nil {
+				return nil, p.errorf("invalid explicit value %q", selector)
+			}
+		}
+		for _, c := range cases {
+			if c.selector == selector {
+				return nil, p.errorf("duplicate selector %q in argument %s", selector, name)
+			}
+		}
+		hasOther = hasOther || selector == "other"
+
+		p.skipSpace()
+		if p.eof() || p.s[p.pos] != '{' {
+			return nil, p.errorf("expected '{' after selector %q", selector)
+		}
+		p.pos++
+
+		msg, err := p.message(plural)
+		if err != nil {
+			return nil, err
+		}
+		if p.eof() {
+			return nil, p.errorf("unclosed case %q in argument %s", selector, name)
+		}
+		p.pos++
+		cases = append(cases, pluralCase{selector: selector, msg: msg})
+	}
+
+	if !hasOther {
+		return nil, p.errorf("argument %s has no 'other' case", name)
+	}
+	return cases, nil
+}
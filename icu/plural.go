@@ -0,0 +1,122 @@
+package icu
+
+import (
+	"math"
+	"strings"
+)
+
+// pluralRules maps languages to their CLDR cardinal plural rules. Languages not listed
+// use the English rules.
+var pluralRules = map[string]PluralRules{}
+
+func init() {
+	for _, lang := range []string{"ja", "zh", "ko", "vi", "th", "id", "ms", "lo", "my", "km"} {
+		pluralRules[lang] = otherOnly
+	}
+	for _, lang := range []string{"fr", "pt", "hi", "bn", "fa", "gu", "kn", "zu", "am"} {
+		pluralRules[lang] = oneBelowTwo
+	}
+	for _, lang := range []string{"ru", "uk", "be"} {
+		pluralRules[lang] = eastSlavic
+	}
+	pluralRules["pl"] = polish
+	pluralRules["cs"] = czech
+	pluralRules["sk"] = czech
+	pluralRules["ar"] = arabic
+}
+
+// RulesFor returns the plural rules of the language of a locale such as "fr", "pt-BR" or "sr_Latn".
+// Cardinal rules are provided for common languages, ordinals only for English; other languages
+// and ordinals of languages other than English fall back to the English rules.
+func RulesFor(locale string) PluralRules {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+
+	rules, ok := pluralRules[lang]
+	if !ok {
+		return English
+	}
+	return func(n float64, ordinal bool) string {
+		if ordinal {
+			return English(n, ordinal)
+		}
+		return rules(n, ordinal)
+	}
+}
+
+// operands returns the absolute integer part of n and whether n has a fraction.
+func operands(n float64) (int64, bool) {
+	n = math.Abs(n)
+	return int64(n), n != math.Trunc(n)
+}
+
+func otherOnly(n float64, ordinal bool) string {
+	return "other"
+}
+
+func oneBelowTwo(n float64, ordinal bool) string {
+	if i, _ := operands(n); i <= 1 {
+		return "one"
+	}
+	return "other"
+}
+
+func eastSlavic(n float64, ordinal bool) string {
+	i, frac := operands(n)
+	switch {
+	case frac:
+		return "other"
+	case i%10 == 1 && i%100 != 11:
+		return "one"
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+func polish(n float64, ordinal bool) string {
+	i, frac := operands(n)
+	switch {
+	case frac:
+		return "other"
+	case i == 1:
+		return "one"
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+func czech(n float64, ordinal bool) string {
+	i, frac := operands(n)
+	switch {
+	case frac:
+		return "many"
+	case i == 1:
+		return "one"
+	case i >= 2 && i <= 4:
+		return "few"
+	}
+	return "other"
+}
+
+func arabic(n float64, ordinal bool) string {
+	i, frac := operands(n)
+	switch {
+	case frac:
+		return "other"
+	case i == 0:
+		return "zero"
+	case i == 1:
+		return "one"
+	case i == 2:
+		return "two"
+	case i%100 >= 3 && i%100 <= 10:
+		return "few"
+	case i%100 >= 11:
+		return "many"
+	}
+	return "other"
+}
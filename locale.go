@@ -0,0 +1,62 @@
+package stres
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+// Resources of the default locale live in "strings", translations in
+// "strings-<locale>" directories next to it, like Android's values-<qualifier>.
+const (
+	resourceDir     = "strings"
+	localeDirPrefix = "strings-"
+)
+
+/*
+	Returns the path of the resource file of the given locale for the given FileType.
+	An empty locale refers to the default resource file.
+*/
+func ResourcePath(locale string, t types.FileType) string {
+	return resourceDirOf(locale) + "/strings." + string(t)
+}
+
+// resourceDirOf returns the directory of the resource files of the given locale.
+func resourceDirOf(locale string) string {
+	if locale == "" {
+		return resourceDir
+	}
+	return localeDirPrefix + locale
+}
+
+/*
+	Returns the locales having resource files of the setted resource type, sorted by name, or the locales of the
+	store if it is an EntryStore. The default locale is not included.
+*/
+func Locales() ([]string, error) {
+	if es, ok := store.(EntryStore); ok {
+		return es.Locales()
+	}
+
+	names, err := store.List(".")
+	if err != nil {
+		return nil, err
+	}
+
+	var locales []string
+	for _, name := range names {
+		dir, ok := strings.CutSuffix(name, "/")
+		if !ok || !strings.HasPrefix(dir, localeDirPrefix) {
+			continue
+		}
+
+		locale := strings.TrimPrefix(dir, localeDirPrefix)
+		if files, err := resourceFiles(dir, fileType); err == nil && len(files) > 0 {
+			locales = append(locales, locale)
+		}
+	}
+
+	sort.Strings(locales)
+	return locales, nil
+}
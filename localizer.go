@@ -0,0 +1,200 @@
+package stres
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Vinetwigs/stres/icu"
+	"github.com/Vinetwigs/stres/types"
+)
+
+// Dictionaries of the loaded locales.
+var locale_dictionaries = map[string]*dictionary{}
+var locale_mu sync.RWMutex
+
+/*
+	Resource lookups bound to a locale. Resources missing in the locale fall back to the default ones.
+*/
+type Localizer struct {
+	locale string
+	dict   *dictionary
+}
+
+/*
+	Loads values from the resource file of the given locale into an internal dictionary, replacing the
+	previously loaded ones. Takes a FileType parameter to specify strings file format.
+*/
+func LoadLocale(locale string, t types.FileType) error {
+	if locale == "" {
+		return LoadValues(t)
+	}
+
+	n, _, err := readResources(locale, t)
+	if err != nil {
+		return err
+	}
+
+	d := newDictionary()
+	d.load(n)
+	setLocaleDictionary(locale, d)
+
+	return nil
+}
+
+// setLocaleDictionary replaces the dictionary of a locale, keeping the table registered by RegisterEmbedded.
+func setLocaleDictionary(locale string, d *dictionary) {
+	locale_mu.Lock()
+	defer locale_mu.Unlock()
+
+	if old, ok := locale_dictionaries[locale]; ok {
+		d.embedded = old.embedded
+	}
+	locale_dictionaries[locale] = d
+}
+
+// Lookups of the default locale.
+var defaultLocalizer = &Localizer{dict: defaultDictionary}
+
+// Resources of the locales not loaded: their lookups fall back to the default resources.
+var unloadedDictionary = newDictionary()
+
+/*
+	Returns the lookups bound to the given locale. If the locale is not loaded, every lookup
+	falls back to the default resources and is reported as such to Metrics and the missing handler.
+*/
+func Locale(locale string) *Localizer {
+	locale_mu.RLock()
+	d, ok := locale_dictionaries[locale]
+	locale_mu.RUnlock()
+
+	if !ok {
+		return &Localizer{locale: locale, dict: unloadedDictionary}
+	}
+	return &Localizer{locale: locale, dict: d}
+}
+
+/*
+	Returns the locale the lookups are bound to, empty for the default locale.
+*/
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+/*
+	Returns the string resource's value with the given name. If not exists, returns the missing handler's value.
+*/
+func (l *Localizer) GetString(name string) string {
+	val, err := l.LookupString(name)
+	if err != nil {
+		return l.missing(KindString, name)
+	}
+	return val
+}
+
+/*
+	Returns the string-array resource's values with the given name. If not exists, returns nil
+	or the missing handler's value as only item.
+*/
+func (l *Localizer) GetArrayString(name string) []string {
+	arr, err := l.LookupArrayString(name)
+	if err != nil {
+		if val := l.missing(KindStringArray, name); val != "" {
+			return []string{val}
+		}
+		return nil
+	}
+	return arr
+}
+
+/*
+	Returns the quantity string resource's corresponding string value based on the value of the given count parameter.
+	If the plural is not found, returns the missing handler's value; if it has no value for the count's quantity,
+	returns an empty string.
+*/
+func (l *Localizer) GetQuantityString(name string, count int) string {
+	val, err := l.LookupQuantityString(name, count)
+	// A plural without a value for the count's quantity exists: it isn't reported to the missing handler.
+	if errors.Is(err, ErrorNotFound) {
+		return l.missing(KindPlurals, name)
+	}
+	return val
+}
+
+/*
+	Returns the string resource's value with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (l *Localizer) LookupString(name string) (string, error) {
+	if val, ok := l.dict.getString(name); ok {
+		recordLookup(l.locale, KindString, name, LookupHit)
+		return val, nil
+	}
+	if val, ok := defaultDictionary.getString(name); ok {
+		recordLookup(l.locale, KindString, name, LookupFallback)
+		return val, nil
+	}
+	recordLookup(l.locale, KindString, name, LookupMiss)
+	return "", fmt.Errorf("%w: %s %q", ErrorNotFound, KindString, name)
+}
+
+/*
+	Returns the string-array resource's values with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (l *Localizer) LookupArrayString(name string) ([]string, error) {
+	if arr, ok := l.dict.getArrayString(name); ok {
+		recordLookup(l.locale, KindStringArray, name, LookupHit)
+		return arr, nil
+	}
+	if arr, ok := defaultDictionary.getArrayString(name); ok {
+		recordLookup(l.locale, KindStringArray, name, LookupFallback)
+		return arr, nil
+	}
+	recordLookup(l.locale, KindStringArray, name, LookupMiss)
+	return nil, fmt.Errorf("%w: %s %q", ErrorNotFound, KindStringArray, name)
+}
+
+/*
+	Returns the quantity string resource's corresponding string value based on the value of the given count parameter.
+	If the plural is not found, returns an error wrapping ErrorNotFound;
+	if it has no value for the count's quantity, returns ErrorQuantityStringPluralNotFound.
+*/
+func (l *Localizer) LookupQuantityString(name string, count int) (string, error) {
+	val, err := l.dict.getQuantityString(name, count)
+	if err == nil {
+		recordLookup(l.locale, KindPlurals, name, LookupHit)
+		return val, nil
+	}
+	if l.dict == defaultDictionary {
+		recordLookup(l.locale, KindPlurals, name, LookupMiss)
+		return val, err
+	}
+
+	val, defErr := defaultDictionary.getQuantityString(name, count)
+	if defErr == nil {
+		recordLookup(l.locale, KindPlurals, name, LookupFallback)
+		return val, nil
+	}
+	recordLookup(l.locale, KindPlurals, name, LookupMiss)
+	// A locale's plural missing the quantity is more relevant than a default plural not existing.
+	if errors.Is(defErr, ErrorNotFound) {
+		return "", err
+	}
+	return "", defErr
+}
+
+/*
+	Returns the string resource's value with the given name formatted as an ICU message with the given arguments.
+	Plural categories follow the rules of the locale's language (see icu.RulesFor), those of values falling back
+	to the default resources the English rules. If not exists, returns the missing handler's value.
+*/
+func (l *Localizer) Format(name string, args map[string]interface{}) (string, error) {
+	if _, ok := l.dict.getString(name); ok && l.dict != defaultDictionary {
+		return l.dict.format(name, args, icu.RulesFor(l.locale))
+	}
+	if _, ok := defaultDictionary.getString(name); ok {
+		return defaultDictionary.format(name, args, icu.English)
+	}
+	return l.missing(KindString, name), nil
+}
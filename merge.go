@@ -0,0 +1,447 @@
+package stres
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+/*
+	A value changed differently by both sides of a three-way merge. Missing values are empty.
+*/
+type Conflict struct {
+	Kind   string
+	Name   string
+	Key    string
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+func (c Conflict) String() string {
+	name := c.Name
+	if c.Key != "" {
+		name += "[" + c.Key + "]"
+	}
+	return fmt.Sprintf("! %s %s: base %s, ours %s, theirs %s",
+		c.Kind, name, strconv.Quote(c.Base), strconv.Quote(c.Ours), strconv.Quote(c.Theirs))
+}
+
+// valueKey identifies a single value: a string, an integer or a bool, an array (or one of its items) or a quantity
+// string quantity.
+type valueKey struct {
+	kind, name, key string
+}
+
+// flatValues lists the values of the resources in n in declaration order.
+type flatValues struct {
+	keys   []valueKey
+	values map[valueKey]string
+}
+
+// flatten lists the values of the resources in n, arrays as a whole if wholeArrays is true or item by item.
+func flatten(n *types.Nesting, wholeArrays bool) flatValues {
+	f := flatValues{values: map[valueKey]string{}}
+	add := func(k valueKey, value string) {
+		f.keys = append(f.keys, k)
+		f.values[k] = value
+	}
+
+	for _, s := range n.Strings {
+		add(valueKey{KindString, s.Name, ""}, s.Value)
+	}
+	addArray := func(kind, name string, values []string) {
+		if wholeArrays {
+			add(valueKey{kind, name, ""}, arrayText(values))
+			return
+		}
+		for i, value := range values {
+			add(valueKey{kind, name, strconv.Itoa(i)}, value)
+		}
+	}
+
+	for _, sa := range n.StringsArray {
+		var values []string
+		for _, item := range sa.Items {
+			values = append(values, item.Value)
+		}
+		addArray(KindStringArray, sa.Name, values)
+	}
+	for _, pl := range n.Plurals {
+		for _, item := range pl.Items {
+			add(valueKey{KindPlurals, pl.Name, item.Quantity}, item.Value)
+		}
+	}
+	for _, i := range n.Integers {
+		add(valueKey{KindInteger, i.Name, ""}, i.Text())
+	}
+	for _, b := range n.Bools {
+		add(valueKey{KindBool, b.Name, ""}, b.Text())
+	}
+	for _, ia := range n.IntegerArrays {
+		var values []string
+		for _, item := range ia.Items {
+			values = append(values, item.Text())
+		}
+		addArray(KindIntegerArray, ia.Name, values)
+	}
+	for _, a := range n.Arrays {
+		var values []string
+		for _, item := range a.Items {
+			values = append(values, item.Value)
+		}
+		addArray(KindArray, a.Name, values)
+	}
+	return f
+}
+
+// arrayText returns the items of an array as a single value, e.g. ["Mercury", "Venus"].
+func arrayText(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+/*
+	Returns the changes turning the resources in a into the ones in b, one per string, integer, bool,
+	array item and quantity string quantity.
+*/
+func Diff(a, b *types.Nesting) []Change {
+	fa, fb := flatten(a, false), flatten(b, false)
+
+	var changes []Change
+	for _, k := range fa.keys {
+		old := fa.values[k]
+		val, ok := fb.values[k]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Type: ChangeRemoved, Kind: k.kind, Name: k.name, Key: k.key, Old: old})
+		case val != old:
+			changes = append(changes, Change{Type: ChangeModified, Kind: k.kind, Name: k.name, Key: k.key, Old: old, New: val})
+		}
+	}
+	for _, k := range fb.keys {
+		if _, ok := fa.values[k]; !ok {
+			changes = append(changes, Change{Type: ChangeAdded, Kind: k.kind, Name: k.name, Key: k.key, New: fb.values[k]})
+		}
+	}
+	return changes
+}
+
+/*
+	Merges the changes made to base by ours and theirs, value by value; arrays are merged as a whole, so that
+	inserting, removing or reordering items doesn't shift the others. Values changed by one side only take that
+	side's value; values changed differently by both sides keep ours and are returned as conflicts.
+*/
+func Merge(base, ours, theirs *types.Nesting) (*types.Nesting, []Conflict) {
+	fb, fo, ft := flatten(base, true), flatten(ours, true), flatten(theirs, true)
+	result := cloneNesting(ours)
+	clone := cloneNesting(theirs)
+
+	keys := append([]valueKey{}, fo.keys...)
+	for _, k := range ft.keys {
+		if _, ok := fo.values[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	var conflicts []Conflict
+	removed := map[valueKey]bool{}
+	for _, k := range keys {
+		b, inBase := fb.values[k]
+		o, inOurs := fo.values[k]
+		t, inTheirs := ft.values[k]
+
+		switch {
+		case inOurs == inTheirs && o == t:
+		case inOurs == inBase && o == b:
+			if !inTheirs {
+				removed[k] = true
+				continue
+			}
+			switch k.kind {
+			case KindString:
+				setString(result, k.name, t)
+			case KindPlurals:
+				setPluralItem(result, k.name, k.key, t)
+			case KindInteger:
+				setInteger(result, findInteger(clone, k.name))
+			case KindBool:
+				setBool(result, findBool(clone, k.name))
+			case KindStringArray:
+				if sa := findStringArray(result, k.name); sa != nil {
+					sa.Items = findStringArray(clone, k.name).Items
+				} else {
+					result.StringsArray = append(result.StringsArray, findStringArray(clone, k.name))
+				}
+			case KindIntegerArray:
+				if ia := findIntegerArray(result, k.name); ia != nil {
+					ia.Items = findIntegerArray(clone, k.name).Items
+				} else {
+					result.IntegerArrays = append(result.IntegerArrays, findIntegerArray(clone, k.name))
+				}
+			case KindArray:
+				if a := findArray(result, k.name); a != nil {
+					a.Items = findArray(clone, k.name).Items
+				} else {
+					result.Arrays = append(result.Arrays, findArray(clone, k.name))
+				}
+			}
+		case inTheirs == inBase && t == b:
+		default:
+			conflicts = append(conflicts, Conflict{Kind: k.kind, Name: k.name, Key: k.key, Base: b, Ours: o, Theirs: t})
+		}
+	}
+
+	removeValues(result, removed)
+	keepMeta(result, ours, theirs)
+	return result, conflicts
+}
+
+// removeValues removes the given values from n, dropping the resources left empty.
+func removeValues(n *types.Nesting, removed map[valueKey]bool) {
+	strs := n.Strings[:0]
+	for _, s := range n.Strings {
+		if !removed[valueKey{KindString, s.Name, ""}] {
+			strs = append(strs, s)
+		}
+	}
+	n.Strings = strs
+
+	arrays := n.StringsArray[:0]
+	for _, sa := range n.StringsArray {
+		if !removed[valueKey{KindStringArray, sa.Name, ""}] {
+			arrays = append(arrays, sa)
+		}
+	}
+	n.StringsArray = arrays
+
+	plurals := n.Plurals[:0]
+	for _, pl := range n.Plurals {
+		items := []*types.PluralItem{}
+		for _, item := range pl.Items {
+			if !removed[valueKey{KindPlurals, pl.Name, item.Quantity}] {
+				items = append(items, item)
+			}
+		}
+		if len(items) == 0 && len(pl.Items) > 0 {
+			continue
+		}
+		pl.Items = items
+		plurals = append(plurals, pl)
+	}
+	n.Plurals = plurals
+
+	integers := n.Integers[:0]
+	for _, i := range n.Integers {
+		if !removed[valueKey{KindInteger, i.Name, ""}] {
+			integers = append(integers, i)
+		}
+	}
+	n.Integers = integers
+
+	bools := n.Bools[:0]
+	for _, b := range n.Bools {
+		if !removed[valueKey{KindBool, b.Name, ""}] {
+			bools = append(bools, b)
+		}
+	}
+	n.Bools = bools
+
+	integerArrays := n.IntegerArrays[:0]
+	for _, ia := range n.IntegerArrays {
+		if !removed[valueKey{KindIntegerArray, ia.Name, ""}] {
+			integerArrays = append(integerArrays, ia)
+		}
+	}
+	n.IntegerArrays = integerArrays
+
+	typedArrays := n.Arrays[:0]
+	for _, a := range n.Arrays {
+		if !removed[valueKey{KindArray, a.Name, ""}] {
+			typedArrays = append(typedArrays, a)
+		}
+	}
+	n.Arrays = typedArrays
+}
+
+// keepMeta copies the metadata of the resources added by theirs into the merge result.
+func keepMeta(result, ours, theirs *types.Nesting) {
+	for _, s := range result.Strings {
+		if t := findString(theirs, s.Name); t != nil && findString(ours, s.Name) == nil {
+			s.Meta = t.Meta
+		}
+	}
+	for _, sa := range result.StringsArray {
+		if t := findStringArray(theirs, sa.Name); t != nil && findStringArray(ours, sa.Name) == nil {
+			sa.Meta = t.Meta
+		}
+	}
+	for _, pl := range result.Plurals {
+		if t := findPlural(theirs, pl.Name); t != nil && findPlural(ours, pl.Name) == nil {
+			pl.Meta = t.Meta
+		}
+	}
+	for _, i := range result.Integers {
+		if t := findInteger(theirs, i.Name); t != nil && findInteger(ours, i.Name) == nil {
+			i.Meta = t.Meta
+		}
+	}
+	for _, b := range result.Bools {
+		if t := findBool(theirs, b.Name); t != nil && findBool(ours, b.Name) == nil {
+			b.Meta = t.Meta
+		}
+	}
+	for _, ia := range result.IntegerArrays {
+		if t := findIntegerArray(theirs, ia.Name); t != nil && findIntegerArray(ours, ia.Name) == nil {
+			ia.Meta = t.Meta
+		}
+	}
+	for _, a := range result.Arrays {
+		if t := findArray(theirs, a.Name); t != nil && findArray(ours, a.Name) == nil {
+			a.Meta = t.Meta
+		}
+	}
+}
+
+// cloneNesting returns a copy of n sharing no resource with it.
+func cloneNesting(n *types.Nesting) *types.Nesting {
+	out := &types.Nesting{}
+	for _, s := range n.Strings {
+		c := *s
+		out.Strings = append(out.Strings, &c)
+	}
+	for _, sa := range n.StringsArray {
+		c := *sa
+		c.Items = nil
+		for _, item := range sa.Items {
+			it := *item
+			c.Items = append(c.Items, &it)
+		}
+		out.StringsArray = append(out.StringsArray, &c)
+	}
+	for _, pl := range n.Plurals {
+		c := *pl
+		c.Items = nil
+		for _, item := range pl.Items {
+			it := *item
+			c.Items = append(c.Items, &it)
+		}
+		out.Plurals = append(out.Plurals, &c)
+	}
+	for _, i := range n.Integers {
+		c := *i
+		out.Integers = append(out.Integers, &c)
+	}
+	for _, b := range n.Bools {
+		c := *b
+		out.Bools = append(out.Bools, &c)
+	}
+	for _, ia := range n.IntegerArrays {
+		c := *ia
+		c.Items = nil
+		for _, item := range ia.Items {
+			it := *item
+			c.Items = append(c.Items, &it)
+		}
+		out.IntegerArrays = append(out.IntegerArrays, &c)
+	}
+	for _, a := range n.Arrays {
+		c := *a
+		c.Items = nil
+		for _, item := range a.Items {
+			it := *item
+			c.Items = append(c.Items, &it)
+		}
+		out.Arrays = append(out.Arrays, &c)
+	}
+	return out
+}
+
+/*
+	Returns the changes turning the resource file at path a into the one at path b, both of the given FileType.
+*/
+func DiffFiles(a, b string, t types.FileType) ([]Change, error) {
+	na, err := readNesting(a, t)
+	if err != nil {
+		return nil, err
+	}
+	nb, err := readNesting(b, t)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(na, nb), nil
+}
+
+/*
+	Merges the resource files at paths base, ours and theirs, all of the given FileType, as a git merge driver does
+	("stres merge %O %A %B"). Without conflicts the merged resources are written to ours, editing XML and YAML files
+	in place to keep their comments and formatting; with conflicts ours is left untouched and the conflicts are
+	returned, for the driver to report the merge as failed.
+*/
+func MergeFiles(base, ours, theirs string, t types.FileType) ([]Conflict, error) {
+	nb, err := readNesting(base, t)
+	if err != nil {
+		return nil, err
+	}
+	no, err := readNesting(ours, t)
+	if err != nil {
+		return nil, err
+	}
+	nt, err := readNesting(theirs, t)
+	if err != nil {
+		return nil, err
+	}
+
+	result, conflicts := Merge(nb, no, nt)
+	if len(conflicts) > 0 {
+		return conflicts, nil
+	}
+
+	edits := nestingEdits(no, result)
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	return nil, editFile(ours, t, true, edits)
+}
+
+// nestingEdits returns the edits turning the resources in from into the ones in to.
+func nestingEdits(from, to *types.Nesting) []resourceEdit {
+	var edits []resourceEdit
+	edits = append(edits, listEdits(KindString, from.Strings, to.Strings, func(s *types.String) string { return s.Name })...)
+	edits = append(edits, listEdits(KindStringArray, from.StringsArray, to.StringsArray, func(sa *types.StringArray) string { return sa.Name })...)
+	edits = append(edits, listEdits(KindPlurals, from.Plurals, to.Plurals, func(pl *types.Plural) string { return pl.Name })...)
+	edits = append(edits, listEdits(KindInteger, from.Integers, to.Integers, func(i *types.Integer) string { return i.Name })...)
+	edits = append(edits, listEdits(KindBool, from.Bools, to.Bools, func(b *types.Bool) string { return b.Name })...)
+	edits = append(edits, listEdits(KindIntegerArray, from.IntegerArrays, to.IntegerArrays, func(ia *types.IntegerArray) string { return ia.Name })...)
+	edits = append(edits, listEdits(KindArray, from.Arrays, to.Arrays, func(a *types.Array) string { return a.Name })...)
+	return edits
+}
+
+// listEdits returns the edits turning the resources of a kind in from into the ones in to.
+func listEdits[T any](kind string, from, to []*T, nameOf func(*T) string) []resourceEdit {
+	old := map[string]*T{}
+	for _, v := range from {
+		old[nameOf(v)] = v
+	}
+
+	var edits []resourceEdit
+	kept := map[string]bool{}
+	for _, v := range to {
+		kept[nameOf(v)] = true
+		if o, ok := old[nameOf(v)]; !ok || !reflect.DeepEqual(o, v) {
+			edits = append(edits, resourceEdit{kind: kind, name: nameOf(v), value: v})
+		}
+	}
+	for _, v := range from {
+		if !kept[nameOf(v)] {
+			edits = append(edits, resourceEdit{kind: kind, name: nameOf(v)})
+		}
+	}
+	return edits
+}
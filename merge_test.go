@@ -0,0 +1,144 @@
+package stres
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+func TestDiff(t *testing.T) {
+	a := &types.Nesting{
+		Strings: []*types.String{{Name: "app_name", Value: "stres"}, {Name: "welcome", Value: "Welcome"}},
+		StringsArray: []*types.StringArray{
+			{Name: "planets", Items: []*types.Item{{Value: "Mercury"}, {Value: "Venus"}}},
+		},
+	}
+	b := &types.Nesting{
+		Strings: []*types.String{{Name: "app_name", Value: "stres"}, {Name: "welcome", Value: "Welcome back"}},
+		StringsArray: []*types.StringArray{
+			{Name: "planets", Items: []*types.Item{{Value: "Mercury"}}},
+		},
+		Plurals: []*types.Plural{
+			{Name: "files", Items: []*types.PluralItem{{Quantity: "one", Value: "file"}}},
+		},
+	}
+
+	var got []string
+	for _, c := range Diff(a, b) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		`~ default string welcome: "Welcome" -> "Welcome back"`,
+		`- default string-array planets[1]: "Venus"`,
+		`+ default plurals files[one]: "file"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+}
+
+func TestMerge_arrays(t *testing.T) {
+	base := &types.Nesting{StringsArray: []*types.StringArray{
+		{Name: "planets", Items: []*types.Item{{Value: "Mercury"}, {Value: "Venus"}}},
+		{Name: "days", Items: []*types.Item{{Value: "Mon"}, {Value: "Tue"}}},
+	}}
+	ours := &types.Nesting{StringsArray: []*types.StringArray{
+		{Name: "planets", Items: []*types.Item{{Value: "Mercury"}, {Value: "Venus"}}},
+		{Name: "days", Items: []*types.Item{{Value: "Sun"}, {Value: "Mon"}, {Value: "Tue"}}},
+	}}
+	theirs := &types.Nesting{StringsArray: []*types.StringArray{
+		{Name: "planets", Items: []*types.Item{{Value: "Mercury"}, {Value: "Venus"}, {Value: "Earth"}}},
+		{Name: "days", Items: []*types.Item{{Value: "Mon"}, {Value: "Tue"}, {Value: "Wed"}}},
+	}}
+
+	result, conflicts := Merge(base, ours, theirs)
+	if got := findStringArray(result, "planets").Items; len(got) != 3 || got[2].Value != "Earth" {
+		t.Errorf("Merge() planets = %v, want theirs' items", got)
+	}
+	want := []Conflict{{Kind: KindStringArray, Name: "days",
+		Base: `["Mon", "Tue"]`, Ours: `["Sun", "Mon", "Tue"]`, Theirs: `["Mon", "Tue", "Wed"]`}}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("Merge() conflicts = %v, want %v", conflicts, want)
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "base.xml", `<resources>
+	<string name="app_name">stres</string>
+	<string name="welcome">Welcome</string>
+	<string name="obsolete">Obsolete</string>
+	<plurals name="files">
+		<item quantity="one">file</item>
+		<item quantity="many">files</item>
+	</plurals>
+</resources>`)
+	writeFile(t, "ours.xml", `<?xml version="1.0" encoding="utf-8"?>
+<resources xmlns:tools="http://schemas.android.com/tools">
+	<!-- Brand -->
+	<string name="app_name">stres</string>
+	<string name="welcome">Hello</string>
+	<string name="obsolete">Obsolete</string>
+	<string name="ours">Ours</string>
+	<plurals name="files">
+		<item quantity="one">file</item>
+		<item quantity="many">files</item>
+	</plurals>
+</resources>`)
+	writeFile(t, "theirs.xml", `<resources>
+	<string name="app_name">Stres</string>
+	<string name="welcome">Welcome</string>
+	<string name="theirs" description="Added by theirs">Theirs</string>
+	<plurals name="files">
+		<item quantity="zero">no files</item>
+		<item quantity="one">file</item>
+		<item quantity="many">files</item>
+	</plurals>
+</resources>`)
+
+	conflicts, err := MergeFiles("base.xml", "ours.xml", "theirs.xml", XML)
+	if err != nil || len(conflicts) > 0 {
+		t.Fatalf("MergeFiles() = %v, %v, want no conflicts", conflicts, err)
+	}
+
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<resources xmlns:tools="http://schemas.android.com/tools">
+	<!-- Brand -->
+	<string name="app_name">Stres</string>
+	<string name="welcome">Hello</string>
+	<string name="ours">Ours</string>
+	<string name="theirs" description="Added by theirs">Theirs</string>
+	<plurals name="files">
+		<item quantity="zero">no files</item>
+		<item quantity="one">file</item>
+		<item quantity="many">files</item>
+	</plurals>
+</resources>`
+	if got, _ := os.ReadFile("ours.xml"); string(got) != want {
+		t.Errorf("MergeFiles() wrote\n%s\nwant\n%s", got, want)
+	}
+
+	writeFile(t, "theirs.xml", `<resources>
+	<string name="app_name">stres</string>
+	<string name="welcome">Hi</string>
+</resources>`)
+	conflicting := `<resources>
+	<string name="app_name">stres</string>
+	<string name="welcome">Hello</string>
+</resources>`
+	writeFile(t, "ours.xml", conflicting)
+	conflicts, err = MergeFiles("base.xml", "ours.xml", "theirs.xml", XML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantConflicts := []Conflict{{Kind: KindString, Name: "welcome", Base: "Welcome", Ours: "Hello", Theirs: "Hi"}}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("MergeFiles() conflicts = %v, want %v", conflicts, wantConflicts)
+	}
+	if got, _ := os.ReadFile("ours.xml"); string(got) != conflicting {
+		t.Errorf("MergeFiles() with conflicts wrote ours:\n%s", got)
+	}
+}
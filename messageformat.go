@@ -0,0 +1,73 @@
+package stres
+
+import (
+	"github.com/Vinetwigs/stres/icu"
+	"github.com/Vinetwigs/stres/types"
+)
+
+var messageFormat = false
+
+/*
+	Enables ICU MessageFormat syntax inside string values.
+	When enabled, LoadValues and NewString reject strings with malformed messages.
+	(default value: false)
+*/
+func SetMessageFormat(enabled bool) {
+	messageFormat = enabled
+}
+
+/*
+	Returns the string resource's value with the given name formatted as an ICU message with the given arguments,
+	e.g. "{count, plural, one {# file} other {# files}} in {folder}". If not exists, returns empty string.
+	Plural categories of the default resources follow the English rules.
+*/
+func Format(name string, args map[string]interface{}) (string, error) {
+	return defaultLocalizer.Format(name, args)
+}
+
+func (d *dictionary) format(name string, args map[string]interface{}, rules icu.PluralRules) (string, error) {
+	value, _ := d.getString(name)
+
+	m, err := d.message(name, value)
+	if err != nil {
+		return "", err
+	}
+	return m.FormatWith(args, rules)
+}
+
+// message returns the parsed ICU message of a string.
+func (d *dictionary) message(name, value string) (*icu.Message, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if m, ok := d.messages[name]; ok {
+		return m, nil
+	}
+
+	m, err := icu.Parse(value)
+	if err != nil {
+		return nil, &ParseError{Name: name, Err: err}
+	}
+	d.messages[name] = m
+	return m, nil
+}
+
+// forgetMessages drops the parsed messages of the strings in n.
+func (d *dictionary) forgetMessages(n *types.Nesting) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, s := range n.Strings {
+		delete(d.messages, s.Name)
+	}
+}
+
+// validateMessages checks that every string in n is a well-formed ICU message.
+func validateMessages(n *types.Nesting) error {
+	for _, s := range n.Strings {
+		if _, err := icu.Parse(s.Value); err != nil {
+			return &ParseError{Name: s.Name, Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package stres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Vinetwigs/stres/icu"
+)
+
+func TestFormat(t *testing.T) {
+	NewString("format_files", "{count, plural, one {# file} other {# files}} in {folder}")
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test_success_one",
+			args: map[string]interface{}{"count": 1, "folder": "docs"},
+			want: "1 file in docs",
+		},
+		{
+			name: "test_success_other",
+			args: map[string]interface{}{"count": 7, "folder": "docs"},
+			want: "7 files in docs",
+		},
+		{
+			name:    "test_error_missing_argument",
+			args:    map[string]interface{}{"count": 7},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format("format_files", tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Format() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizer_FormatPluralRules(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetResourceType(XML)
+		locale_mu.Lock()
+		delete(locale_dictionaries, "fr")
+		locale_mu.Unlock()
+	})
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="format_rules_files">{count, plural, one {# file} other {# files}}</string>
+	<string name="format_rules_items">{count, plural, one {# item} other {# items}}</string>
+</resources>`)
+	writeFile(t, "strings-fr/strings.xml", `<resources>
+	<string name="format_rules_files">{count, plural, one {# fichier} other {# fichiers}}</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLocale("fr", XML); err != nil {
+		t.Fatal(err)
+	}
+
+	args := map[string]interface{}{"count": 0}
+	if got, _ := Locale("fr").Format("format_rules_files", args); got != "0 fichier" {
+		t.Errorf("Locale(fr).Format() = %q, want %q", got, "0 fichier")
+	}
+	// Values falling back to the default resources keep the English rules.
+	if got, _ := Locale("fr").Format("format_rules_items", args); got != "0 items" {
+		t.Errorf("Locale(fr).Format() of a default value = %q, want %q", got, "0 items")
+	}
+	if got, _ := Format("format_rules_files", args); got != "0 files" {
+		t.Errorf("Format() = %q, want %q", got, "0 files")
+	}
+}
+
+func TestSetMessageFormat(t *testing.T) {
+	SetMessageFormat(true)
+	defer SetMessageFormat(false)
+
+	_, err := NewString("format_malformed", "{count, plural, one {# file}}")
+	var syntaxErr *icu.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("NewString() error = %v, want *icu.SyntaxError", err)
+	}
+
+	inTempDir(t)
+	writeFile(t, "strings/strings.xml", `<resources><string name="broken">{n, plural, one {x}</string></resources>`)
+	if err = LoadValues(XML); !errors.As(err, &syntaxErr) {
+		t.Errorf("LoadValues() error = %v, want *icu.SyntaxError", err)
+	}
+}
@@ -0,0 +1,98 @@
+package stres
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+var ErrorMaxLength error = errors.New("stres: value longer than the resource's max length")
+
+/*
+	Returns the translator metadata of the resource with the given name, looking up strings first, then
+	string-arrays and quantity strings. If not exists, returns empty metadata.
+*/
+func GetMeta(name string) types.Meta {
+	meta, _ := defaultDictionary.getMeta(name)
+	return meta
+}
+
+/*
+	Returns the values of the translatable default resources missing in the given loaded locale, as changes
+	adding them. Resources marked translatable="false" are skipped.
+*/
+func Untranslated(locale string) []Change {
+	locale_mu.RLock()
+	d, ok := locale_dictionaries[locale]
+	locale_mu.RUnlock()
+
+	if !ok {
+		d = newDictionary()
+	}
+
+	return untranslated(defaultDictionary.nesting(), d, locale)
+}
+
+// untranslated returns the values of the translatable resources of def missing in the dictionary d of a locale.
+func untranslated(def *types.Nesting, d *dictionary, locale string) []Change {
+	var changes []Change
+	add := func(kind, name, key, value string) {
+		changes = append(changes, Change{Type: ChangeAdded, Locale: locale, Kind: kind, Name: name, Key: key, New: value})
+	}
+
+	for _, s := range def.Strings {
+		if !s.Meta.IsTranslatable() {
+			continue
+		}
+		if _, ok := d.getString(s.Name); !ok {
+			add(KindString, s.Name, "", s.Value)
+		}
+	}
+
+	for _, sa := range def.StringsArray {
+		if !sa.Meta.IsTranslatable() {
+			continue
+		}
+		d.rw.RLock()
+		translated := d.arrays[sa.Name].Items
+		d.rw.RUnlock()
+		for i := len(translated); i < len(sa.Items); i++ {
+			add(KindStringArray, sa.Name, strconv.Itoa(i), sa.Items[i].Value)
+		}
+	}
+
+	for _, pl := range def.Plurals {
+		if !pl.Meta.IsTranslatable() {
+			continue
+		}
+		d.rw.RLock()
+		translated, ok := d.plurals[pl.Name]
+		d.rw.RUnlock()
+		for _, item := range pl.Items {
+			if !ok || findPluralItem(&translated, item.Quantity) == nil {
+				add(KindPlurals, pl.Name, item.Quantity, item.Value)
+			}
+		}
+	}
+
+	return changes
+}
+
+// maxLength returns the max length of a resource of a locale: its own, or the default resource's.
+func maxLength(res, def interface{}) int {
+	if m := resourceMeta(res).MaxLength; m > 0 {
+		return m
+	}
+	return resourceMeta(def).MaxLength
+}
+
+// checkMaxLength returns an error wrapping ErrorMaxLength if value has more characters than max, unless it's 0.
+func checkMaxLength(max int, kind, name, value string) error {
+	if length := utf8.RuneCountInString(value); max > 0 && length > max {
+		return fmt.Errorf("%w: %s %q has %d characters, at most %d allowed", ErrorMaxLength, kind, name, length, max)
+	}
+	return nil
+}
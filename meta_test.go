@@ -0,0 +1,77 @@
+package stres
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMeta(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="meta_app_name" translatable="false">stres</string>
+	<string name="meta_welcome" description="Title of the home screen" maxLength="20" tags="home title">Welcome</string>
+	<string-array name="meta_planets">
+		<item>Mercury</item>
+		<item>Venus</item>
+	</string-array>
+	<plurals name="meta_files" formatted="false">
+		<item quantity="one">one file</item>
+		<item quantity="many">many files</item>
+	</plurals>
+</resources>`)
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string-array name="meta_planets">
+		<item>Mercurio</item>
+	</string-array>
+	<plurals name="meta_files">
+		<item quantity="one">un file</item>
+	</plurals>
+</resources>`)
+
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLocale("it", XML); err != nil {
+		t.Fatal(err)
+	}
+
+	if meta := GetMeta("meta_app_name"); meta.IsTranslatable() {
+		t.Errorf("GetMeta() = %+v, want untranslatable", meta)
+	}
+	meta := GetMeta("meta_welcome")
+	if meta.Description != "Title of the home screen" || meta.MaxLength != 20 || !reflect.DeepEqual([]string(meta.Tags), []string{"home", "title"}) {
+		t.Errorf("GetMeta() = %+v", meta)
+	}
+	if meta = GetMeta("meta_files"); meta.IsFormatted() || !meta.IsTranslatable() {
+		t.Errorf("GetMeta() = %+v, want translatable and not formatted", meta)
+	}
+
+	var got []string
+	for _, c := range Untranslated("it") {
+		if strings.HasPrefix(c.Name, "meta_") {
+			got = append(got, c.String())
+		}
+	}
+	want := []string{
+		`+ it string meta_welcome: "Welcome"`,
+		`+ it string-array meta_planets[1]: "Venus"`,
+		`+ it plurals meta_files[many]: "many files"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Untranslated() = %q, want %q", got, want)
+	}
+
+	buf := bytes.Buffer{}
+	if err := ExportCSV(&buf, ','); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "meta_app_name") {
+		t.Errorf("ExportCSV() exported an untranslatable string:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "meta_welcome,string,,Welcome,,Title of the home screen\n") {
+		t.Errorf("ExportCSV() misses the description:\n%s", buf.String())
+	}
+}
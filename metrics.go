@@ -0,0 +1,128 @@
+package stres
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// Result of a resource lookup reported to Metrics.
+type LookupResult int
+
+const (
+	LookupHit      LookupResult = iota // found in the locale
+	LookupFallback                     // missing in the locale, found in the default resources
+	LookupMiss                         // not found
+	LookupDefault                      // not found, the caller's default value used (reported after LookupMiss)
+)
+
+/*
+	Instrumentation of resource lookups, set with SetMetrics. Lookup is called by every GetString, GetArrayString
+	and GetQuantityString lookup (and their Lookup, Or, Must and Localizer variants) with the locale, empty for the
+	default one, and must be safe for concurrent use.
+*/
+type Metrics interface {
+	Lookup(locale, kind, name string, result LookupResult)
+}
+
+// Metrics set with SetMetrics, which may be called while lookups run.
+var metrics atomic.Pointer[metricsHolder]
+
+// metricsHolder wraps Metrics so that a nil Metrics can be stored.
+type metricsHolder struct {
+	m Metrics
+}
+
+// Resources found by a lookup since the metrics were set.
+var accessed sync.Map
+
+/*
+	Sets the instrumentation of resource lookups and resets the tracking of the resources looked up for Report.
+	A nil Metrics disables it. (default value: nil)
+*/
+func SetMetrics(m Metrics) {
+	metrics.Store(&metricsHolder{m})
+	accessed.Range(func(k, _ interface{}) bool {
+		accessed.Delete(k)
+		return true
+	})
+}
+
+// recordLookup reports a lookup to the metrics, if set.
+func recordLookup(locale, kind, name string, result LookupResult) {
+	h := metrics.Load()
+	if h == nil || h.m == nil {
+		return
+	}
+	if result == LookupHit || result == LookupFallback {
+		accessed.Store(resourceKey{kind, name}, struct{}{})
+	}
+	h.m.Lookup(locale, kind, name, result)
+}
+
+/*
+	Returns the names of the default string, string-array and quantity string resources never found by a lookup
+	since the metrics were set, by kind and sorted. Lookups are only tracked while metrics are set.
+*/
+func Report() map[string][]string {
+	unused := map[string][]string{}
+	add := func(kind string, names []string) {
+		for _, name := range names {
+			if _, ok := accessed.Load(resourceKey{kind, name}); !ok {
+				unused[kind] = append(unused[kind], name)
+			}
+		}
+	}
+
+	defaultDictionary.rw.RLock()
+	defer defaultDictionary.rw.RUnlock()
+
+	add(KindString, sortedKeys(defaultDictionary.strings))
+	add(KindStringArray, sortedKeys(defaultDictionary.arrays))
+	add(KindPlurals, sortedKeys(defaultDictionary.plurals))
+	return unused
+}
+
+/*
+	Metrics published with expvar as a map of counters by locale ("default" for the default one) for each result,
+	and the resources never looked up as returned by Report:
+
+		{"hits": {"default": 42, "it": 17}, "fallbacks": {"it": 2}, "misses": {"it": 1}, "defaults": {"it": 1},
+		 "unused": {"string": [...]}}
+*/
+type ExpvarMetrics struct {
+	hits, fallbacks, misses, defaults *expvar.Map
+}
+
+/*
+	Returns metrics published with expvar under the given name (e.g. "stres"). Like expvar.NewMap, it panics if
+	the name is already in use.
+*/
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	m := &ExpvarMetrics{hits: new(expvar.Map), fallbacks: new(expvar.Map), misses: new(expvar.Map), defaults: new(expvar.Map)}
+
+	v := expvar.NewMap(name)
+	v.Set("hits", m.hits)
+	v.Set("fallbacks", m.fallbacks)
+	v.Set("misses", m.misses)
+	v.Set("defaults", m.defaults)
+	v.Set("unused", expvar.Func(func() interface{} { return Report() }))
+	return m
+}
+
+func (m *ExpvarMetrics) Lookup(locale, kind, name string, result LookupResult) {
+	if locale == "" {
+		locale = "default"
+	}
+
+	switch result {
+	case LookupHit:
+		m.hits.Add(locale, 1)
+	case LookupFallback:
+		m.fallbacks.Add(locale, 1)
+	case LookupMiss:
+		m.misses.Add(locale, 1)
+	case LookupDefault:
+		m.defaults.Add(locale, 1)
+	}
+}
@@ -0,0 +1,180 @@
+package stres
+
+import (
+	"encoding/json"
+	"expvar"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type lookupEvent struct {
+	locale, kind, name string
+	result             LookupResult
+}
+
+type recordingMetrics struct {
+	mu     sync.Mutex
+	events []lookupEvent
+}
+
+func (m *recordingMetrics) Lookup(locale, kind, name string, result LookupResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, lookupEvent{locale, kind, name, result})
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMetrics(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetMetrics(nil)
+		SetResourceType(XML)
+	})
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="metrics_title">Title</string>
+	<string name="metrics_unused">Unused</string>
+	<string-array name="metrics_days"><item>Mon</item></string-array>
+	<plurals name="metrics_files">
+		<item quantity="one">One file</item>
+		<item quantity="many">%d files</item>
+	</plurals>
+</resources>`)
+	writeFile(t, "strings-mx/strings.xml", `<resources>
+	<string name="metrics_title">Título</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLocale("mx", XML); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &recordingMetrics{}
+	SetMetrics(m)
+
+	GetString("metrics_title")
+	Locale("mx").GetString("metrics_title")
+	Locale("mx").GetArrayString("metrics_days")
+	Locale("mx").GetQuantityString("metrics_files", 1)
+	GetString("metrics_missing")
+	GetQuantityString("metrics_missing", 1)
+
+	want := []lookupEvent{
+		{"", KindString, "metrics_title", LookupHit},
+		{"mx", KindString, "metrics_title", LookupHit},
+		{"mx", KindStringArray, "metrics_days", LookupFallback},
+		{"mx", KindPlurals, "metrics_files", LookupFallback},
+		{"", KindString, "metrics_missing", LookupMiss},
+		{"", KindPlurals, "metrics_missing", LookupMiss},
+	}
+	if !reflect.DeepEqual(m.events, want) {
+		t.Errorf("Lookup() events = %v, want %v", m.events, want)
+	}
+
+	unused := Report()
+	if !contains(unused[KindString], "metrics_unused") || contains(unused[KindString], "metrics_title") {
+		t.Errorf("Report() strings = %v, want metrics_unused and not metrics_title", unused[KindString])
+	}
+	if contains(unused[KindStringArray], "metrics_days") || contains(unused[KindPlurals], "metrics_files") {
+		t.Errorf("Report() = %v, listing resources looked up", unused)
+	}
+
+	SetMetrics(NewExpvarMetrics("stres_test"))
+	GetString("metrics_title")
+	Locale("mx").GetString("metrics_title")
+	Locale("mx").GetArrayString("metrics_days")
+	GetString("metrics_missing")
+
+	var published struct {
+		Hits, Fallbacks, Misses map[string]int
+		Unused                  map[string][]string
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("stres_test").String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(published.Hits, map[string]int{"default": 1, "mx": 1}) ||
+		!reflect.DeepEqual(published.Fallbacks, map[string]int{"mx": 1}) ||
+		!reflect.DeepEqual(published.Misses, map[string]int{"default": 1}) {
+		t.Errorf("published counters = %+v", published)
+	}
+	if !contains(published.Unused[KindPlurals], "metrics_files") {
+		t.Errorf("published unused = %v, want metrics_files as tracking restarted", published.Unused)
+	}
+}
+
+func TestMetrics_unloadedLocale(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetMetrics(nil)
+		SetMissingHandler(nil)
+		SetResourceType(XML)
+	})
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="unloaded_title">Title</string>
+	<plurals name="unloaded_files"><item quantity="one">One file</item></plurals>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &recordingMetrics{}
+	SetMetrics(m)
+	var missingLocale string
+	SetMissingHandler(func(locale, kind, name string) string {
+		missingLocale = locale
+		return ""
+	})
+
+	l := Locale("unloaded-xx")
+	if got := l.Locale(); got != "unloaded-xx" {
+		t.Errorf("Locale() = %q, want unloaded-xx", got)
+	}
+	if got := l.GetString("unloaded_title"); got != "Title" {
+		t.Errorf("GetString() = %q, want Title", got)
+	}
+	if got := l.GetQuantityString("unloaded_files", 1); got != "One file" {
+		t.Errorf("GetQuantityString() = %q, want One file", got)
+	}
+	l.GetString("unloaded_missing")
+
+	want := []lookupEvent{
+		{"unloaded-xx", KindString, "unloaded_title", LookupFallback},
+		{"unloaded-xx", KindPlurals, "unloaded_files", LookupFallback},
+		{"unloaded-xx", KindString, "unloaded_missing", LookupMiss},
+	}
+	if !reflect.DeepEqual(m.events, want) {
+		t.Errorf("events = %v, want %v", m.events, want)
+	}
+	if missingLocale != "unloaded-xx" {
+		t.Errorf("missing handler locale = %q, want unloaded-xx", missingLocale)
+	}
+}
+
+func TestSetMetrics_concurrent(t *testing.T) {
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			GetString("concurrent_metrics")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetMetrics(&recordingMetrics{})
+		SetMetrics(nil)
+	}
+	wg.Wait()
+}
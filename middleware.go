@@ -0,0 +1,178 @@
+package stres
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Name of the query parameter and of the cookie overriding the Accept-Language header.
+const LanguageParam = "lang"
+
+type localizerKey struct{}
+
+var defaultLocale = ""
+
+/*
+	Sets the language tag of the default resources (e.g. "en"), so that NegotiateLocale selects them when the tag
+	matches the request better than the loaded locales, at its place in the preferences. An empty tag leaves the
+	default resources to requests matching no locale. (default value: "")
+*/
+func SetDefaultLocale(tag string) {
+	defaultLocale = tag
+}
+
+/*
+	Returns the loaded locales, sorted by name. The default locale is not included.
+*/
+func LoadedLocales() []string {
+	locale_mu.RLock()
+	defer locale_mu.RUnlock()
+
+	locales := make([]string, 0, len(locale_dictionaries))
+	for locale := range locale_dictionaries {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+/*
+	Returns a copy of ctx carrying the given localizer.
+*/
+func NewContext(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey{}, l)
+}
+
+/*
+	Returns the localizer stored in ctx by Middleware or NewContext. If none, returns the default locale's localizer.
+*/
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(localizerKey{}).(*Localizer); ok {
+		return l
+	}
+	return Locale("")
+}
+
+/*
+	Wraps an HTTP handler so that every request context carries the localizer of the best loaded locale for the caller,
+	retrievable with FromContext. The "lang" query parameter and cookie take precedence over the Accept-Language header.
+*/
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := NegotiateLocale(r)
+
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Add("Vary", "Cookie")
+		if locale != "" {
+			w.Header().Set("Content-Language", locale)
+		} else if defaultLocale != "" {
+			w.Header().Set("Content-Language", defaultLocale)
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), Locale(locale))))
+	})
+}
+
+/*
+	Returns the loaded locale best matching the request's "lang" query parameter, "lang" cookie or Accept-Language header,
+	in this order. Returns empty string (default locale) if the tag set with SetDefaultLocale matches first or if none matches.
+*/
+func NegotiateLocale(r *http.Request) string {
+	var candidates []string
+	if lang := r.URL.Query().Get(LanguageParam); lang != "" {
+		candidates = append(candidates, lang)
+	}
+	if c, err := r.Cookie(LanguageParam); err == nil && c.Value != "" {
+		candidates = append(candidates, c.Value)
+	}
+	candidates = append(candidates, parseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+
+	loaded := LoadedLocales()
+	locales := loaded
+	if defaultLocale != "" {
+		locales = append(loaded[:len(loaded):len(loaded)], defaultLocale)
+	}
+
+	locale := matchLocale(candidates, locales)
+	if locale == defaultLocale && !slices.Contains(loaded, locale) {
+		return ""
+	}
+	return locale
+}
+
+// parseAcceptLanguage returns the language ranges of an Accept-Language header by decreasing quality.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var ranges []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		ranges = append(ranges, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	tags := make([]string, len(ranges))
+	for i, r := range ranges {
+		tags[i] = r.tag
+	}
+	return tags
+}
+
+// matchLocale returns the first locale matching a candidate exactly, by base language,
+// or sharing its base language ("en-US" matches "en-US", then "en", then "en-GB").
+func matchLocale(candidates, locales []string) string {
+	normalize := func(tag string) string {
+		return strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	}
+	base := func(tag string) string {
+		return strings.SplitN(tag, "-", 2)[0]
+	}
+
+	for _, c := range candidates {
+		c = normalize(c)
+
+		for _, l := range locales {
+			if normalize(l) == c {
+				return l
+			}
+		}
+		for _, l := range locales {
+			if normalize(l) == base(c) {
+				return l
+			}
+		}
+		for _, l := range locales {
+			if base(normalize(l)) == base(c) {
+				return l
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package stres
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	NewString("middleware_greeting", "Hello")
+
+	inTempDir(t)
+	writeFile(t, "strings-it/strings.xml", `<resources><string name="middleware_greeting">Ciao</string></resources>`)
+	writeFile(t, "strings-pt-BR/strings.xml", `<resources><string name="middleware_greeting">Olá</string></resources>`)
+	for _, locale := range []string{"it", "pt-BR"} {
+		if err := LoadLocale(locale, XML); err != nil {
+			t.Fatalf("LoadLocale() error = %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, FromContext(r.Context()).GetString("middleware_greeting"))
+	})))
+	defer srv.Close()
+
+	tests := []struct {
+		name           string
+		query          string
+		cookie         string
+		acceptLanguage string
+		want           string
+		wantLanguage   string
+	}{
+		{
+			name: "test_default",
+			want: "Hello",
+		},
+		{
+			name:           "test_accept_language_quality",
+			acceptLanguage: "fr;q=0.9, it;q=0.8, en;q=0.5",
+			want:           "Ciao",
+			wantLanguage:   "it",
+		},
+		{
+			name:           "test_accept_language_region",
+			acceptLanguage: "pt-PT, en",
+			want:           "Olá",
+			wantLanguage:   "pt-BR",
+		},
+		{
+			name:           "test_accept_language_unmatched",
+			acceptLanguage: "de-DE, *;q=0.1",
+			want:           "Hello",
+		},
+		{
+			name:           "test_query_override",
+			query:          "?lang=pt_br",
+			acceptLanguage: "it",
+			want:           "Olá",
+			wantLanguage:   "pt-BR",
+		},
+		{
+			name:           "test_cookie_override",
+			cookie:         "it",
+			acceptLanguage: "pt-BR",
+			want:           "Ciao",
+			wantLanguage:   "it",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: LanguageParam, Value: tt.cookie})
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if got := string(body); got != tt.want {
+				t.Errorf("GetString() = %q, want %q", got, tt.want)
+			}
+			if got := resp.Header.Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLanguage)
+			}
+			if got := resp.Header.Values("Vary"); !reflect.DeepEqual(got, []string{"Accept-Language", "Cookie"}) {
+				t.Errorf("Vary = %q, want [Accept-Language Cookie]", got)
+			}
+		})
+	}
+}
+
+func TestNegotiateLocale_defaultLocale(t *testing.T) {
+	inTempDir(t)
+	writeFile(t, "strings-it/strings.xml", `<resources><string name="negotiate_greeting">Ciao</string></resources>`)
+	if err := LoadLocale("it", XML); err != nil {
+		t.Fatal(err)
+	}
+	SetDefaultLocale("en")
+	t.Cleanup(func() { SetDefaultLocale("") })
+
+	tests := []struct {
+		name           string
+		query          string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "test_accept_language_default_first", acceptLanguage: "en, it;q=0.5", want: ""},
+		{name: "test_accept_language_default_region", acceptLanguage: "en-US, it;q=0.5", want: ""},
+		{name: "test_accept_language_default_lower", acceptLanguage: "it, en;q=0.5", want: "it"},
+		{name: "test_query_default", query: "?lang=en", acceptLanguage: "it", want: ""},
+		{name: "test_query_locale", query: "?lang=it", acceptLanguage: "en", want: "it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			if got := NegotiateLocale(req); got != tt.want {
+				t.Errorf("NegotiateLocale() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package stres
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+/*
+	Returns the value used in place of a missing resource of the given kind ("string", "string-array", "plurals",
+	"integer", "bool", "integer-array" or "array"). Locale is empty for the default locale. The value of integer
+	and bool resources is used only when it parses as one.
+*/
+type MissingHandler func(locale, kind, name string) string
+
+var missingHandler MissingHandler = MissingEmpty
+
+/*
+	Sets the handler invoked by GetString, GetArrayString, GetQuantityString, Format, GetInt, GetBool, GetIntArray
+	and GetArray when a resource is missing.
+	A nil handler restores the default one. (default value: MissingEmpty)
+*/
+func SetMissingHandler(h MissingHandler) {
+	if h == nil {
+		h = MissingEmpty
+	}
+	missingHandler = h
+}
+
+/*
+	Returns empty string, the behaviour of lookups before missing handlers existed.
+*/
+func MissingEmpty(locale, kind, name string) string {
+	return ""
+}
+
+/*
+	Returns the name of the missing resource.
+*/
+func MissingKey(locale, kind, name string) string {
+	return name
+}
+
+/*
+	Returns the name of the missing resource between double square brackets ("[[name]]"), easy to spot in a UI.
+*/
+func MissingBrackets(locale, kind, name string) string {
+	return "[[" + name + "]]"
+}
+
+/*
+	Panics reporting the missing resource. Meant for development and tests.
+*/
+func MissingPanic(locale, kind, name string) string {
+	if locale == "" {
+		panic(fmt.Sprintf("stres: missing %s %q", kind, name))
+	}
+	panic(fmt.Sprintf("stres: missing %s %q in locale %s", kind, name, locale))
+}
+
+/*
+	Returns a handler logging missing resources as warnings with the given logger (slog.Default() if nil),
+	then returning the value of next (MissingEmpty if nil).
+*/
+func MissingLogger(logger *slog.Logger, next MissingHandler) MissingHandler {
+	if next == nil {
+		next = MissingEmpty
+	}
+	return func(locale, kind, name string) string {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+		l.Warn("stres: missing resource", "locale", locale, "kind", kind, "name", name)
+		return next(locale, kind, name)
+	}
+}
+
+func (l *Localizer) missing(kind, name string) string {
+	return missingHandler(l.locale, kind, name)
+}
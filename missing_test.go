@@ -0,0 +1,143 @@
+package stres
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetMissingHandler(t *testing.T) {
+	defer SetMissingHandler(nil)
+
+	tests := []struct {
+		name      string
+		handler   MissingHandler
+		want      string
+		wantArray []string
+	}{
+		{
+			name:      "test_empty",
+			handler:   nil,
+			want:      "",
+			wantArray: nil,
+		},
+		{
+			name:      "test_key",
+			handler:   MissingKey,
+			want:      "missing_name",
+			wantArray: []string{"missing_name"},
+		},
+		{
+			name:      "test_brackets",
+			handler:   MissingBrackets,
+			want:      "[[missing_name]]",
+			wantArray: []string{"[[missing_name]]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMissingHandler(tt.handler)
+			if got := GetString("missing_name"); got != tt.want {
+				t.Errorf("GetString() = %v, want %v", got, tt.want)
+			}
+			if got := GetQuantityString("missing_name", 3); got != tt.want {
+				t.Errorf("GetQuantityString() = %v, want %v", got, tt.want)
+			}
+			if got := GetArrayString("missing_name"); !reflect.DeepEqual(got, tt.wantArray) {
+				t.Errorf("GetArrayString() = %v, want %v", got, tt.wantArray)
+			}
+		})
+	}
+}
+
+func TestMissingPanic(t *testing.T) {
+	SetMissingHandler(MissingPanic)
+	defer SetMissingHandler(nil)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("GetString() did not panic")
+		}
+	}()
+	GetString("missing_name")
+}
+
+func TestMissingPanic_quantity(t *testing.T) {
+	NewQuantityString("missing_quantity", []string{"", "one file"})
+	SetMissingHandler(MissingPanic)
+	defer SetMissingHandler(nil)
+
+	if got := GetQuantityString("missing_quantity", 5); got != "" {
+		t.Errorf("GetQuantityString() without the quantity = %q, want empty", got)
+	}
+}
+
+func TestMissingLogger(t *testing.T) {
+	buf := bytes.Buffer{}
+	SetMissingHandler(MissingLogger(slog.New(slog.NewTextHandler(&buf, nil)), MissingKey))
+	defer SetMissingHandler(nil)
+
+	if got := GetString("missing_logged"); got != "missing_logged" {
+		t.Errorf("GetString() = %v, want %v", got, "missing_logged")
+	}
+	if !strings.Contains(buf.String(), "name=missing_logged") {
+		t.Errorf("MissingLogger() logged %q", buf.String())
+	}
+}
+
+func TestLookupString(t *testing.T) {
+	NewString("lookup_empty", "")
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr error
+	}{
+		{name: "lookup_empty", want: "", wantErr: nil},
+		{name: "lookup_missing", want: "", wantErr: ErrorNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupString(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LookupString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("LookupString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupQuantityString(t *testing.T) {
+	NewQuantityString("lookup_plural", []string{"zero", "one"})
+
+	tests := []struct {
+		name    string
+		count   int
+		want    string
+		wantErr error
+	}{
+		{name: "lookup_plural", count: 1, want: "one", wantErr: nil},
+		{name: "lookup_plural", count: 5, want: "", wantErr: ErrorQuantityStringPluralNotFound},
+		{name: "lookup_missing", count: 1, want: "", wantErr: ErrorNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupQuantityString(tt.name, tt.count)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LookupQuantityString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("LookupQuantityString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LookupArrayString("lookup_missing"); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("LookupArrayString() error = %v, wantErr %v", err, ErrorNotFound)
+	}
+}
@@ -0,0 +1,287 @@
+package stres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+func findString(n *types.Nesting, name string) *types.String {
+	for _, s := range n.Strings {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func findStringArray(n *types.Nesting, name string) *types.StringArray {
+	for _, sa := range n.StringsArray {
+		if sa.Name == name {
+			return sa
+		}
+	}
+	return nil
+}
+
+func findPlural(n *types.Nesting, name string) *types.Plural {
+	for _, pl := range n.Plurals {
+		if pl.Name == name {
+			return pl
+		}
+	}
+	return nil
+}
+
+func findInteger(n *types.Nesting, name string) *types.Integer {
+	for _, i := range n.Integers {
+		if i.Name == name {
+			return i
+		}
+	}
+	return nil
+}
+
+func findBool(n *types.Nesting, name string) *types.Bool {
+	for _, b := range n.Bools {
+		if b.Name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+func findIntegerArray(n *types.Nesting, name string) *types.IntegerArray {
+	for _, ia := range n.IntegerArrays {
+		if ia.Name == name {
+			return ia
+		}
+	}
+	return nil
+}
+
+func findArray(n *types.Nesting, name string) *types.Array {
+	for _, a := range n.Arrays {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// findResource returns the resource of the given kind, nil if missing.
+func findResource(n *types.Nesting, kind, name string) interface{} {
+	switch kind {
+	case KindString:
+		if s := findString(n, name); s != nil {
+			return s
+		}
+	case KindStringArray:
+		if sa := findStringArray(n, name); sa != nil {
+			return sa
+		}
+	case KindPlurals:
+		if pl := findPlural(n, name); pl != nil {
+			return pl
+		}
+	case KindInteger:
+		if i := findInteger(n, name); i != nil {
+			return i
+		}
+	case KindBool:
+		if b := findBool(n, name); b != nil {
+			return b
+		}
+	case KindIntegerArray:
+		if ia := findIntegerArray(n, name); ia != nil {
+			return ia
+		}
+	case KindArray:
+		if a := findArray(n, name); a != nil {
+			return a
+		}
+	}
+	return nil
+}
+
+func findPluralItem(pl *types.Plural, quantity string) *types.PluralItem {
+	for _, item := range pl.Items {
+		if item.Quantity == quantity {
+			return item
+		}
+	}
+	return nil
+}
+
+func quantityIndex(quantity string) int {
+	for i, q := range quantityValues {
+		if q == quantity {
+			return i
+		}
+	}
+	return -1
+}
+
+// setString sets the value of a string, adding it if needed, and returns the previous value.
+func setString(n *types.Nesting, name, value string) (string, bool) {
+	if s := findString(n, name); s != nil {
+		old := s.Value
+		s.Value = value
+		return old, true
+	}
+	n.Strings = append(n.Strings, &types.String{Name: name, Value: value})
+	return "", false
+}
+
+// setArrayItem sets the value of a string-array item, adding the array or appending the item
+// as needed, and returns the previous value. An index past the end of the items is an error:
+// filling the gap would add empty items.
+func setArrayItem(n *types.Nesting, name string, idx int, value string) (string, bool, error) {
+	sa := findStringArray(n, name)
+	if sa == nil {
+		sa = &types.StringArray{Name: name}
+	}
+
+	if idx < len(sa.Items) {
+		old := sa.Items[idx].Value
+		sa.Items[idx].Value = value
+		return old, true, nil
+	}
+	if idx > len(sa.Items) {
+		return "", false, fmt.Errorf("index %d past the end of string-array %q (%d items)", idx, name, len(sa.Items))
+	}
+
+	if len(sa.Items) == 0 && findStringArray(n, name) == nil {
+		n.StringsArray = append(n.StringsArray, sa)
+	}
+	sa.Items = append(sa.Items, &types.Item{Value: value})
+	return "", false, nil
+}
+
+// setPluralItem sets the value of a quantity string's quantity, adding the plural and the item
+// as needed while keeping quantities in zero→many order, and returns the previous value.
+func setPluralItem(n *types.Nesting, name, quantity, value string) (string, bool) {
+	pl := findPlural(n, name)
+	if pl == nil {
+		pl = &types.Plural{Name: name}
+		n.Plurals = append(n.Plurals, pl)
+	}
+
+	if item := findPluralItem(pl, quantity); item != nil {
+		old := item.Value
+		item.Value = value
+		return old, true
+	}
+
+	item := &types.PluralItem{Quantity: quantity, Value: value}
+	pos := len(pl.Items)
+	for i, it := range pl.Items {
+		if quantityIndex(it.Quantity) > quantityIndex(quantity) {
+			pos = i
+			break
+		}
+	}
+	pl.Items = append(pl.Items, nil)
+	copy(pl.Items[pos+1:], pl.Items[pos:])
+	pl.Items[pos] = item
+	return "", false
+}
+
+// setInteger sets the value of an integer to the one of from, a number or a reference, adding it if needed.
+func setInteger(n *types.Nesting, from *types.Integer) {
+	if i := findInteger(n, from.Name); i != nil {
+		i.Value, i.Ref = from.Value, from.Ref
+		return
+	}
+	n.Integers = append(n.Integers, from)
+}
+
+// setBool sets the value of a bool to the one of from, a bool or a reference, adding it if needed.
+func setBool(n *types.Nesting, from *types.Bool) {
+	if b := findBool(n, from.Name); b != nil {
+		b.Value, b.Ref = from.Value, from.Ref
+		return
+	}
+	n.Bools = append(n.Bools, from)
+}
+
+// maxReferenceDepth bounds the chains of references followed, so that cycles end.
+const maxReferenceDepth = 16
+
+// resolveReferences returns the integers, bools and integer-arrays of n with the references to other resources
+// of n (e.g. "@integer/max") replaced by their values. Resources referring to what can't be resolved, like
+// framework resources and theme attributes, are left out.
+func resolveReferences(n *types.Nesting) ([]*types.Integer, []*types.Bool, []*types.IntegerArray) {
+	var integers []*types.Integer
+	for _, i := range n.Integers {
+		if i.Ref == "" {
+			integers = append(integers, i)
+		} else if v, ok := resolveInteger(n, i.Ref, 0); ok {
+			r := *i
+			r.Value, r.Ref = v, ""
+			integers = append(integers, &r)
+		}
+	}
+
+	var bools []*types.Bool
+	for _, b := range n.Bools {
+		if b.Ref == "" {
+			bools = append(bools, b)
+		} else if v, ok := resolveBool(n, b.Ref, 0); ok {
+			r := *b
+			r.Value, r.Ref = v, ""
+			bools = append(bools, &r)
+		}
+	}
+
+	var integerArrays []*types.IntegerArray
+arrays:
+	for _, ia := range n.IntegerArrays {
+		r := *ia
+		r.Items = make([]*types.IntegerItem, 0, len(ia.Items))
+		for _, item := range ia.Items {
+			if item.Ref == "" {
+				r.Items = append(r.Items, item)
+				continue
+			}
+			v, ok := resolveInteger(n, item.Ref, 0)
+			if !ok {
+				continue arrays
+			}
+			r.Items = append(r.Items, &types.IntegerItem{Value: v})
+		}
+		integerArrays = append(integerArrays, &r)
+	}
+	return integers, bools, integerArrays
+}
+
+func resolveInteger(n *types.Nesting, ref string, depth int) (int, bool) {
+	name, ok := strings.CutPrefix(ref, "@integer/")
+	if !ok || depth == maxReferenceDepth {
+		return 0, false
+	}
+	i := findInteger(n, name)
+	if i == nil {
+		return 0, false
+	}
+	if i.Ref == "" {
+		return i.Value, true
+	}
+	return resolveInteger(n, i.Ref, depth+1)
+}
+
+func resolveBool(n *types.Nesting, ref string, depth int) (bool, bool) {
+	name, ok := strings.CutPrefix(ref, "@bool/")
+	if !ok || depth == maxReferenceDepth {
+		return false, false
+	}
+	b := findBool(n, name)
+	if b == nil {
+		return false, false
+	}
+	if b.Ref == "" {
+		return b.Value, true
+	}
+	return resolveBool(n, b.Ref, depth+1)
+}
@@ -0,0 +1,243 @@
+package stres
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+/*
+	Options of the pseudo-localization of resource values.
+*/
+type PseudoOptions struct {
+	Accents   bool    // replace ASCII letters with accented look-alikes
+	Expansion float64 // ratio of padding added to the translatable text (0.3 makes it 30% longer)
+	Brackets  bool    // surround values with "[" and "]" to spot truncation and concatenation
+	Mirror    bool    // wrap words with right-to-left override characters
+}
+
+var (
+	// Accented pseudo-locale, like Android's en-XA.
+	PseudoAccented = PseudoOptions{Accents: true, Expansion: 0.3, Brackets: true}
+	// Right-to-left pseudo-locale, like Android's ar-XB.
+	PseudoBidi = PseudoOptions{Mirror: true}
+)
+
+// Parts of a value that must not be pseudo-localized: format specifiers, markup, entities,
+// escapes and i18next interpolations. ICU arguments are handled by pseudoSegments.
+var pseudoProtected = regexp.MustCompile(`^(?:%(\d+\$)?[-#+ 0,(]*\d*(\.\d+)?[a-zA-Z%]|<[^>]*>|&[a-zA-Z0-9#]+;|\\.|\{\{[^{}]*\}\})`)
+
+// pseudoProtected without format specifiers, for values marked formatted="false" where '%' is text.
+var pseudoProtectedUnformatted = regexp.MustCompile(`^(?:<[^>]*>|&[a-zA-Z0-9#]+;|\\.|\{\{[^{}]*\}\})`)
+
+var pseudoAccents = map[rune]rune{
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Đ', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ', 'H': 'Ĥ', 'I': 'Î',
+	'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ', 'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ',
+	'S': 'Š', 'T': 'Ţ', 'U': 'Û', 'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+	'a': 'å', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ', 'h': 'ĥ', 'i': 'î',
+	'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ', 'o': 'ö', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ',
+	's': 'š', 't': 'ţ', 'u': 'û', 'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+}
+
+var pseudoPadding = []string{"one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+
+const (
+	rightToLeftOverride      = "\u202e"
+	popDirectionalFormatting = "\u202c"
+)
+
+type pseudoSegment struct {
+	text         string
+	translatable bool
+}
+
+/*
+	Returns the pseudo-localized version of a resource value. Format specifiers ("%1$s"), markup ("<b>"),
+	entities, escapes and ICU or i18next arguments are preserved.
+*/
+func Pseudolocalize(value string, opts PseudoOptions) string {
+	segments := pseudoSegments(value)
+
+	b := strings.Builder{}
+	if opts.Brackets {
+		b.WriteString("[")
+	}
+
+	length := 0
+	for _, seg := range segments {
+		if !seg.translatable {
+			b.WriteString(seg.text)
+			continue
+		}
+		length += utf8.RuneCountInString(seg.text)
+		b.WriteString(pseudoText(seg.text, opts))
+	}
+
+	if padding := int(math.Ceil(float64(length) * opts.Expansion)); padding > 0 {
+		b.WriteString(pseudoText(" "+pseudoPad(padding), PseudoOptions{Mirror: opts.Mirror}))
+	}
+
+	if opts.Brackets {
+		b.WriteString("]")
+	}
+	return b.String()
+}
+
+/*
+	Returns a copy of n with every value pseudo-localized. Resources marked translatable="false" are left out,
+	so that lookups fall back to the default ones.
+*/
+func PseudolocalizeNesting(n *types.Nesting, opts PseudoOptions) *types.Nesting {
+	out := &types.Nesting{}
+	for _, s := range n.Strings {
+		if !s.Meta.IsTranslatable() {
+			continue
+		}
+		out.Strings = append(out.Strings, &types.String{Name: s.Name, Value: Pseudolocalize(s.Value, opts)})
+	}
+	for _, sa := range n.StringsArray {
+		if !sa.Meta.IsTranslatable() {
+			continue
+		}
+		arr := &types.StringArray{Name: sa.Name}
+		for _, item := range sa.Items {
+			arr.Items = append(arr.Items, &types.Item{Value: Pseudolocalize(item.Value, opts)})
+		}
+		out.StringsArray = append(out.StringsArray, arr)
+	}
+	for _, pl := range n.Plurals {
+		if !pl.Meta.IsTranslatable() {
+			continue
+		}
+		plural := &types.Plural{Name: pl.Name}
+		for _, item := range pl.Items {
+			plural.Items = append(plural.Items, &types.PluralItem{Quantity: item.Quantity, Value: Pseudolocalize(item.Value, opts)})
+		}
+		out.Plurals = append(out.Plurals, plural)
+	}
+	return out
+}
+
+/*
+	Derives a pseudo-locale from the loaded default resources and loads it in memory under the given locale
+	(e.g. "en-XA" with PseudoAccented, "ar-XB" with PseudoBidi).
+*/
+func LoadPseudoLocale(locale string, opts PseudoOptions) {
+	d := newDictionary()
+	d.load(PseudolocalizeNesting(defaultDictionary.nesting(), opts))
+	setLocaleDictionary(locale, d)
+}
+
+/*
+	Derives a pseudo-locale from the default resource file and writes it as the resource file of the given locale.
+	Uses setted resource file extension.
+*/
+func WritePseudoLocale(locale string, opts PseudoOptions) error {
+	n, _, err := readResources("", fileType)
+	if err != nil {
+		return err
+	}
+
+	return writeNesting(ResourcePath(locale, fileType), fileType, PseudolocalizeNesting(n, opts))
+}
+
+// pseudoSegments splits a value into translatable text and protected parts.
+// Inside ICU arguments, names, types and selectors are protected while case messages are translatable.
+func pseudoSegments(value string) []pseudoSegment {
+	return segmentsOf(value, pseudoProtected)
+}
+
+// segmentsOf is pseudoSegments protecting the parts matched by protected.
+func segmentsOf(value string, protected *regexp.Regexp) []pseudoSegment {
+	var segments []pseudoSegment
+	add := func(text string, translatable bool) {
+		if text == "" {
+			return
+		}
+		if last := len(segments) - 1; last >= 0 && segments[last].translatable == translatable {
+			segments[last].text += text
+			return
+		}
+		segments = append(segments, pseudoSegment{text: text, translatable: translatable})
+	}
+
+	// Open braces: true for ICU arguments, false for case messages.
+	var stack []bool
+	inArgument := func() bool {
+		return len(stack) > 0 && stack[len(stack)-1]
+	}
+
+	for len(value) > 0 {
+		if loc := protected.FindStringIndex(value); loc != nil && !inArgument() {
+			add(value[:loc[1]], false)
+			value = value[loc[1]:]
+			continue
+		}
+
+		c := value[0]
+		switch {
+		case c == '{':
+			// A brace in text opens an argument, a brace after a selector opens a case message.
+			stack = append(stack, !inArgument())
+			add("{", false)
+		case c == '}' && len(stack) > 0:
+			stack = stack[:len(stack)-1]
+			add("}", false)
+		case c == '#' && len(stack) > 0:
+			add("#", false)
+		default:
+			_, size := utf8.DecodeRuneInString(value)
+			add(value[:size], !inArgument())
+			value = value[size:]
+			continue
+		}
+		value = value[1:]
+	}
+	return segments
+}
+
+func pseudoText(text string, opts PseudoOptions) string {
+	if opts.Accents {
+		text = strings.Map(func(r rune) rune {
+			if a, ok := pseudoAccents[r]; ok {
+				return a
+			}
+			return r
+		}, text)
+	}
+
+	if !opts.Mirror {
+		return text
+	}
+
+	b := strings.Builder{}
+	inWord := false
+	for _, r := range text {
+		if space := unicode.IsSpace(r); space && inWord {
+			b.WriteString(popDirectionalFormatting)
+			inWord = false
+		} else if !space && !inWord {
+			b.WriteString(rightToLeftOverride)
+			inWord = true
+		}
+		b.WriteRune(r)
+	}
+	if inWord {
+		b.WriteString(popDirectionalFormatting)
+	}
+	return b.String()
+}
+
+func pseudoPad(length int) string {
+	var words []string
+	for n, i := 0, 0; n < length; i++ {
+		w := pseudoPadding[i%len(pseudoPadding)]
+		words = append(words, w)
+		n += len(w) + 1
+	}
+	return strings.Join(words, " ")
+}
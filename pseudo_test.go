@@ -0,0 +1,85 @@
+package stres
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPseudolocalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		opts  PseudoOptions
+		want  string
+	}{
+		{
+			name:  "test_accents",
+			value: "Hello world",
+			opts:  PseudoOptions{Accents: true},
+			want:  "Ĥéļļö ŵöŕļð",
+		},
+		{
+			name:  "test_expansion_brackets",
+			value: "Hello world",
+			opts:  PseudoAccented,
+			want:  "[Ĥéļļö ŵöŕļð one]",
+		},
+		{
+			name:  "test_placeholders",
+			value: "Hi %1$s, you have %d <b>new</b> &amp; {count} \\'items\\'",
+			opts:  PseudoOptions{Accents: true},
+			want:  "Ĥî %1$s, ýöû ĥåṽé %d <b>ñéŵ</b> &amp; {count} \\'îţéɱš\\'",
+		},
+		{
+			name:  "test_icu",
+			value: "{count, plural, one {# file} other {# files}} in {folder}",
+			opts:  PseudoOptions{Accents: true},
+			want:  "{count, plural, one {# ƒîļé} other {# ƒîļéš}} îñ {folder}",
+		},
+		{
+			name:  "test_i18next",
+			value: "Hello {{name}}",
+			opts:  PseudoOptions{Accents: true},
+			want:  "Ĥéļļö {{name}}",
+		},
+		{
+			name:  "test_mirror",
+			value: "Hello %s world",
+			opts:  PseudoBidi,
+			want:  "\u202eHello\u202c %s \u202eworld\u202c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Pseudolocalize(tt.value, tt.opts); got != tt.want {
+				t.Errorf("Pseudolocalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPseudoLocale(t *testing.T) {
+	NewString("pseudo_title", "Settings")
+
+	LoadPseudoLocale("en-XA", PseudoAccented)
+	if got, want := Locale("en-XA").GetString("pseudo_title"), "[Šéţţîñĝš one]"; got != want {
+		t.Errorf("GetString() = %q, want %q", got, want)
+	}
+}
+
+func TestWritePseudoLocale(t *testing.T) {
+	inTempDir(t)
+	writeFile(t, "strings/strings.xml", `<resources><string name="title">Settings</string></resources>`)
+
+	if err := WritePseudoLocale("ar-XB", PseudoBidi); err != nil {
+		t.Fatalf("WritePseudoLocale() error = %v", err)
+	}
+	data, err := os.ReadFile("strings-ar-XB/strings.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\u202eSettings\u202c") {
+		t.Errorf("WritePseudoLocale() wrote %q", data)
+	}
+}
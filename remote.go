@@ -0,0 +1,326 @@
+package stres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+/*
+	Loads the resources of a locale from a resource file served over HTTP, so that they can be updated
+	without redeploying. Requests are conditional on the ETag of the last good file and the refresh delay
+	follows the max-age of Cache-Control. The last good file is kept in an optional on-disk cache for
+	offline starts; on network or decode errors the last good resources stay loaded. Each load replaces all of
+	the resources of the locale, the ones loaded from resource files included.
+
+	When a verification key is set with SetVerificationKey, the signature of the file written by SignFile is
+	fetched from "<URL>.sig" and cached along with the file: files without a valid signature are refused with an
+	error wrapping ErrorSignature, as resource directories without a valid manifest are.
+*/
+type RemoteLoader struct {
+	URL      string
+	Locale   string          // locale the resources are loaded into, empty for the default one
+	Type     types.FileType  // format of the served file
+	Cache    string          // path of the on-disk cache of the last good file, none if empty
+	Interval time.Duration   // refresh delay when the response has no max-age (default: 5 minutes)
+	Client   *http.Client    // client sending the requests (default: http.DefaultClient)
+	OnError  func(err error) // called with the errors of Load falling back to the cache and of Start's refreshes
+
+	mu     sync.Mutex
+	etag   string
+	maxAge time.Duration
+	loaded bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+/*
+	Returns a loader of the resource file at url, in format t, into the given locale (empty for the default one).
+*/
+func NewRemoteLoader(url, locale string, t types.FileType) *RemoteLoader {
+	return &RemoteLoader{URL: url, Locale: locale, Type: t}
+}
+
+/*
+	Loads the resources from the remote file. If it can't be fetched or decoded, falls back to the on-disk cache,
+	returning an error only if neither could be loaded.
+*/
+func (r *RemoteLoader) Load(ctx context.Context) error {
+	err := r.Refresh(ctx)
+	if err == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	loaded := r.loaded
+	r.mu.Unlock()
+	if !loaded {
+		if cacheErr := r.loadCache(); cacheErr != nil {
+			return err
+		}
+	}
+
+	if r.OnError != nil {
+		r.OnError(err)
+	}
+	return nil
+}
+
+/*
+	Fetches the remote file, unless unchanged since the last good one, and loads its resources.
+	On errors the resources already loaded are kept.
+*/
+func (r *RemoteLoader) Refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.loaded && r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	r.mu.Unlock()
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	maxAge, noStore := cacheControl(resp.Header.Get("Cache-Control"))
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		r.mu.Lock()
+		r.maxAge = maxAge
+		r.mu.Unlock()
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("stres: fetching %s: %s", r.URL, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var sig []byte
+	if verificationKey != nil {
+		if sig, err = r.fetchSignature(ctx, client, data); err != nil {
+			return err
+		}
+	}
+	n, err := decodeNesting(r.URL, r.Type, data)
+	if err != nil {
+		return err
+	}
+	r.apply(n)
+
+	etag := resp.Header.Get("ETag")
+	r.mu.Lock()
+	r.etag, r.maxAge, r.loaded = etag, maxAge, true
+	r.mu.Unlock()
+
+	if r.Cache != "" && !noStore {
+		return r.writeCache(data, sig, etag)
+	}
+	return nil
+}
+
+// writeCache writes a fetched file to the on-disk cache, with its ETag and signature. The files are written to
+// temporary files first, then renamed into place, the data last. If a rename fails, the cached ETag is removed,
+// so that the data left in the cache isn't revalidated with the ETag of another file.
+func (r *RemoteLoader) writeCache(data, sig []byte, etag string) error {
+	paths, contents := []string{r.Cache + ".etag"}, [][]byte{[]byte(etag)}
+	if sig != nil {
+		paths, contents = append(paths, r.Cache+".sig"), append(contents, sig)
+	}
+	paths, contents = append(paths, r.Cache), append(contents, data)
+
+	temps := make([]string, 0, len(paths))
+	defer func() {
+		for _, tmp := range temps {
+			os.Remove(tmp)
+		}
+	}()
+	for i, path := range paths {
+		tmp, err := writeTemp(path, contents[i])
+		if err != nil {
+			return err
+		}
+		temps = append(temps, tmp)
+	}
+
+	for i, path := range paths {
+		if err := os.Rename(temps[i], path); err != nil {
+			if i > 0 {
+				os.Remove(r.Cache + ".etag")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchSignature fetches the signature of the remote file, returning it if it's a valid signature of data.
+func (r *RemoteLoader) fetchSignature(ctx context.Context, client *http.Client, data []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL+".sig", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: fetching %s.sig: %s", ErrorSignature, r.URL, resp.Status)
+	}
+	sig, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !verifySignature(data, sig) {
+		return nil, fmt.Errorf("%w: bad signature of %s", ErrorSignature, r.URL)
+	}
+	return sig, nil
+}
+
+/*
+	Starts refreshing the resources in background, after the max-age of the last response or else the
+	interval, until Stop is called or ctx is done. Refresh errors are passed to OnError.
+*/
+func (r *RemoteLoader) Start(ctx context.Context) {
+	r.mu.Lock()
+	if r.stop != nil {
+		r.mu.Unlock()
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	r.stop, r.done = stop, done
+	r.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		for {
+			timer := time.NewTimer(r.delay())
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if err := r.Refresh(ctx); err != nil && r.OnError != nil {
+				r.OnError(err)
+			}
+		}
+	}()
+}
+
+/*
+	Stops the background refreshes started by Start, waiting for the running one to end.
+*/
+func (r *RemoteLoader) Stop() {
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// delay returns the time to wait before the next background refresh.
+func (r *RemoteLoader) delay() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case r.maxAge > 0:
+		return r.maxAge
+	case r.Interval > 0:
+		return r.Interval
+	}
+	return 5 * time.Minute
+}
+
+// loadCache loads the resources of the on-disk cache, with the ETag they were served with.
+func (r *RemoteLoader) loadCache() error {
+	if r.Cache == "" {
+		return os.ErrNotExist
+	}
+
+	data, err := os.ReadFile(r.Cache)
+	if err != nil {
+		return err
+	}
+	if verificationKey != nil {
+		sig, err := os.ReadFile(r.Cache + ".sig")
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrorSignature, err)
+		}
+		if !verifySignature(data, sig) {
+			return fmt.Errorf("%w: bad signature of %s", ErrorSignature, r.Cache)
+		}
+	}
+	n, err := decodeNesting(r.Cache, r.Type, data)
+	if err != nil {
+		return err
+	}
+	r.apply(n)
+
+	etag, _ := os.ReadFile(r.Cache + ".etag")
+	r.mu.Lock()
+	r.etag, r.loaded = string(etag), true
+	r.mu.Unlock()
+	return nil
+}
+
+// apply replaces the loaded resources of the locale with the ones in n, all at once, so that lookups running
+// meanwhile see either the old or the new resources and resources removed from the remote file are dropped.
+func (r *RemoteLoader) apply(n *types.Nesting) {
+	if r.Locale == "" {
+		defaultDictionary.replace(n)
+		return
+	}
+
+	d := newDictionary()
+	d.load(n)
+	setLocaleDictionary(r.Locale, d)
+}
+
+// cacheControl returns the max-age of a Cache-Control header and whether it forbids storing the response.
+func cacheControl(header string) (maxAge time.Duration, noStore bool) {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		case "no-store":
+			noStore = true
+		}
+	}
+	return maxAge, noStore
+}
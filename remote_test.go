@@ -0,0 +1,323 @@
+package stres
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+func TestRemoteLoader(t *testing.T) {
+	var mu sync.Mutex
+	body, etag, status := `<resources><string name="remote_title">v1</string></resources>`, `"v1"`, http.StatusOK
+	notModified := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		if req.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	serve := func(b, e string, s int) {
+		mu.Lock()
+		body, etag, status = b, e, s
+		mu.Unlock()
+	}
+
+	ctx := context.Background()
+	cache := filepath.Join(t.TempDir(), "remote.xml")
+	r := NewRemoteLoader(srv.URL+"/strings.xml", "remote-xx", XML)
+	r.Cache = cache
+
+	if err := r.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := Locale("remote-xx").GetString("remote_title"); got != "v1" {
+		t.Errorf("GetString() = %q, want v1", got)
+	}
+	if r.delay() != time.Minute {
+		t.Errorf("delay() = %v, want the max-age of 1m", r.delay())
+	}
+
+	if err := r.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if notModified != 1 {
+		t.Errorf("Refresh() of an unchanged file got %d Not Modified responses, want 1", notModified)
+	}
+
+	serve(`<resources><string name="remote_title">v2</string></resources>`, `"v2"`, http.StatusOK)
+	if err := r.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := Locale("remote-xx").GetString("remote_title"); got != "v2" {
+		t.Errorf("GetString() after a change = %q, want v2", got)
+	}
+
+	serve(`<resources><string`, `"v3"`, http.StatusOK)
+	if err := r.Refresh(ctx); err == nil {
+		t.Error("Refresh() of a malformed file error = nil")
+	}
+	serve("", "", http.StatusInternalServerError)
+	if err := r.Refresh(ctx); err == nil {
+		t.Error("Refresh() of a failing server error = nil")
+	}
+	if got := Locale("remote-xx").GetString("remote_title"); got != "v2" {
+		t.Errorf("GetString() after failed refreshes = %q, want the last good v2", got)
+	}
+
+	// Offline start from the on-disk cache
+	var errs []error
+	offline := NewRemoteLoader(srv.URL+"/strings.xml", "remote-yy", XML)
+	offline.Cache = cache
+	offline.OnError = func(err error) { errs = append(errs, err) }
+	if err := offline.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Load() from the cache reported %d errors, want 1", len(errs))
+	}
+	if got := Locale("remote-yy").GetString("remote_title"); got != "v2" {
+		t.Errorf("GetString() from the cache = %q, want v2", got)
+	}
+
+	uncached := NewRemoteLoader(srv.URL+"/strings.xml", "remote-zz", XML)
+	if err := uncached.Load(ctx); err == nil {
+		t.Error("Load() without server nor cache error = nil")
+	}
+
+	// Background refresh
+	serve(`<resources><string name="remote_title">v4</string></resources>`, `"v4"`, http.StatusOK)
+	offline.Interval = 10 * time.Millisecond
+	offline.mu.Lock()
+	offline.maxAge = 0
+	offline.mu.Unlock()
+	offline.Start(ctx)
+	defer offline.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for Locale("remote-yy").GetString("remote_title") != "v4" {
+		if time.Now().After(deadline) {
+			t.Fatal("Start() didn't refresh the resources")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	offline.Stop()
+}
+
+func TestRemoteLoader_defaultLocale(t *testing.T) {
+	t.Cleanup(func() { defaultDictionary.replace(&types.Nesting{}) })
+
+	var version atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		v := version.Add(1)
+		fmt.Fprintf(w, `<resources><string name="remote_default">v%d</string><string name="remote_only_%d">x</string></resources>`, v, v)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	r := NewRemoteLoader(srv.URL+"/strings.xml", "", XML)
+	r.Interval = time.Millisecond
+	if err := r.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	r.Start(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for version.Load() < 10 {
+				if GetString("remote_default") == "" {
+					t.Error("GetString() during a refresh = empty")
+					return
+				}
+				Locale("remote-none").GetString("remote_default")
+				runtime.Gosched()
+			}
+		}()
+	}
+	wg.Wait()
+	r.Stop()
+
+	if err := r.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	v := version.Load()
+	if got := GetString("remote_default"); got != fmt.Sprintf("v%d", v) {
+		t.Errorf("GetString() = %q, want v%d", got, v)
+	}
+	if _, err := LookupString(fmt.Sprintf("remote_only_%d", v-1)); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("LookupString() of a resource removed remotely error = %v, want %v", err, ErrorNotFound)
+	}
+}
+
+func TestRemoteLoader_signature(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetVerificationKey(nil)
+		locale_mu.Lock()
+		delete(locale_dictionaries, "remote-signed")
+		locale_mu.Unlock()
+	})
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "strings.xml", `<resources><string name="remote_signed">signed</string></resources>`)
+	if err = SignFile("strings.xml", priv); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.FileServer(http.Dir(".")))
+	defer srv.Close()
+
+	SetVerificationKey(pub)
+	ctx := context.Background()
+	r := NewRemoteLoader(srv.URL+"/strings.xml", "remote-signed", XML)
+	r.Cache = "cache.xml"
+	if err = r.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := Locale("remote-signed").GetString("remote_signed"); got != "signed" {
+		t.Errorf("GetString() = %q, want signed", got)
+	}
+
+	writeFile(t, "strings.xml", `<resources><string name="remote_signed">tampered</string></resources>`)
+	if err = r.Refresh(ctx); !errors.Is(err, ErrorSignature) {
+		t.Errorf("Refresh() of a tampered file error = %v, want %v", err, ErrorSignature)
+	}
+	writeFile(t, "unsigned.xml", `<resources><string name="remote_signed">unsigned</string></resources>`)
+	unsigned := NewRemoteLoader(srv.URL+"/unsigned.xml", "remote-signed", XML)
+	if err = unsigned.Refresh(ctx); !errors.Is(err, ErrorSignature) {
+		t.Errorf("Refresh() of an unsigned file error = %v, want %v", err, ErrorSignature)
+	}
+
+	writeFile(t, "cache.xml", `<resources><string name="remote_signed">tampered</string></resources>`)
+	if err = r.loadCache(); !errors.Is(err, ErrorSignature) {
+		t.Errorf("loadCache() of a tampered cache error = %v, want %v", err, ErrorSignature)
+	}
+	if got := Locale("remote-signed").GetString("remote_signed"); got != "signed" {
+		t.Errorf("GetString() after refused loads = %q, want signed", got)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		header  string
+		maxAge  time.Duration
+		noStore bool
+	}{
+		{"", 0, false},
+		{"max-age=30", 30 * time.Second, false},
+		{"public, MAX-AGE=\"5\"", 5 * time.Second, false},
+		{"no-cache, max-age=0", 0, false},
+		{"no-store", 0, true},
+	}
+	for _, tt := range tests {
+		maxAge, noStore := cacheControl(tt.header)
+		if maxAge != tt.maxAge || noStore != tt.noStore {
+			t.Errorf("cacheControl(%q) = %v, %v, want %v, %v", tt.header, maxAge, noStore, tt.maxAge, tt.noStore)
+		}
+	}
+}
+
+func TestRemoteLoader_cacheWriteError(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetVerificationKey(nil)
+		locale_mu.Lock()
+		delete(locale_dictionaries, "remote-cache")
+		locale_mu.Unlock()
+	})
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data := []byte(fmt.Sprintf(`<resources><string name="remote_cached">v%d</string></resources>`, version))
+		if filepath.Ext(req.URL.Path) == ".sig" {
+			w.Write(signature(priv, data))
+			return
+		}
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, version))
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	SetVerificationKey(pub)
+	ctx := context.Background()
+	r := NewRemoteLoader(srv.URL+"/strings.xml", "remote-cache", XML)
+	r.Cache = "cache.xml"
+	if err = r.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := os.ReadFile("cache.xml.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signature, second file written, can't replace a directory.
+	if err = os.Remove("cache.xml.sig"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "cache.xml.sig/keep", "")
+	version = 2
+	if err = r.Refresh(ctx); err == nil {
+		t.Fatal("Refresh() with an unwritable cache error = nil")
+	}
+
+	if data, _ := os.ReadFile("cache.xml"); !strings.Contains(string(data), "v1") {
+		t.Errorf("cache.xml = %s, want the previous file", data)
+	}
+	if _, err = os.Stat("cache.xml.etag"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("cache.xml.etag left after a failed write: %v", err)
+	}
+	if temps, _ := filepath.Glob(".cache.xml*.tmp"); len(temps) > 0 {
+		t.Errorf("temporary files left: %v", temps)
+	}
+
+	if err = os.RemoveAll("cache.xml.sig"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "cache.xml.sig", string(sig))
+	offline := NewRemoteLoader(srv.URL+"/strings.xml", "remote-cache", XML)
+	offline.Cache = "cache.xml"
+	if err = offline.loadCache(); err != nil {
+		t.Fatalf("loadCache() of the previous file error = %v", err)
+	}
+	if got := Locale("remote-cache").GetString("remote_cached"); got != "v1" {
+		t.Errorf("GetString() = %q, want v1", got)
+	}
+	if offline.etag != "" {
+		t.Errorf("cached ETag = %q, want none", offline.etag)
+	}
+}
@@ -0,0 +1,740 @@
+package stres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Vinetwigs/stres/icu"
+	"github.com/Vinetwigs/stres/types"
+)
+
+// Name of the default locale in the URLs of APIHandler.
+const apiDefaultLocale = "default"
+
+// Maximum size of request bodies.
+const apiMaxBodySize = 1 << 20
+
+var apiLocalePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// apiResource is the JSON form of a string, string-array or quantity string resource.
+type apiResource struct {
+	Name       string            `json:"name"`
+	Value      *string           `json:"value,omitempty"`
+	Items      []string          `json:"items,omitempty"`
+	Quantities map[string]string `json:"quantities,omitempty"`
+	Meta       *types.Meta       `json:"meta,omitempty"` // read-only
+	ETag       string            `json:"etag,omitempty"`
+}
+
+// apiError is the JSON form of an error, sent with its HTTP status.
+type apiError struct {
+	status  int
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// apiServer serializes the requests, as the resource files and the default dictionary are shared.
+type apiServer struct {
+	mu sync.Mutex
+}
+
+/*
+	Returns an HTTP handler exposing CRUD over the string, string-array and quantity string resources of every
+	locale as JSON, "default" naming the default locale:
+
+		GET    /                        {"locales": ["default", "it", ...]}
+		GET    /{locale}/{kind}         {"resources": [...]}, kind being "string", "string-array" or "plurals"
+		POST   /{locale}/{kind}         creates the resource in the body
+		GET    /{locale}/{kind}/{name}  the resource, with its ETag
+		PUT    /{locale}/{kind}/{name}  creates or, with If-Match, replaces the resource
+		DELETE /{locale}/{kind}/{name}  removes the resource, with If-Match
+
+	Resources are {"name": ..., "value": "..."} for strings, {"name": ..., "items": [...]} for string-arrays and
+	{"name": ..., "quantities": {"one": ..., ...}} for quantity strings, only the given quantities being written.
+	Resources also carry their metadata, read-only ("meta"), and values longer than the max length of the resource,
+	or else of the default resource, are refused. Values of XML files are exchanged as text: entities are decoded
+	when read and '&', '<' and '>' escaped when written, except in well-formed markup tags like <b>. Values the
+	resource file can't hold are refused.
+	Replacing or removing a resource requires the If-Match header with its current ETag. Errors are sent as
+	{"error": {"code": ..., "message": ..., "field": ...}}.
+
+	Against cross-site requests, bodies must be sent as application/json, which browsers don't send across origins
+	without a CORS preflight, and changes whose Origin header is another host are refused. Bodies are limited to 1 MiB.
+
+	Resources are read from the resource files of the setted resource type. Writes to the default locale go through
+	NewString, UpdateString, RemoveString and the other New, Update and Remove functions, so LoadValues must be
+	called first; writes to loaded locales reload them.
+*/
+func APIHandler() http.Handler {
+	return &apiServer{}
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.serve(w, r); err != nil {
+		e := apiErrorOf(err)
+		writeJSON(w, e.status, map[string]*apiError{"error": e})
+	}
+}
+
+func (s *apiServer) serve(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if err := checkOrigin(r); err != nil {
+			return err
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, apiMaxBodySize)
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "" {
+		if r.Method != http.MethodGet {
+			return methodNotAllowed(w, http.MethodGet)
+		}
+		locales, err := Locales()
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, map[string][]string{"locales": append([]string{apiDefaultLocale}, locales...)})
+		return nil
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || len(parts) > 3 || !apiLocalePattern.MatchString(parts[0]) {
+		return &apiError{status: http.StatusNotFound, Code: "not_found", Message: "no such endpoint"}
+	}
+	locale, kind := parts[0], parts[1]
+	if locale == apiDefaultLocale {
+		locale = ""
+	}
+	if kind != KindString && kind != KindStringArray && kind != KindPlurals {
+		return &apiError{status: http.StatusNotFound, Code: "not_found", Message: fmt.Sprintf("unknown resource kind %q", kind)}
+	}
+
+	n, origins, err := readResources(locale, fileType)
+	if errors.Is(err, os.ErrNotExist) {
+		n, origins, err = &types.Nesting{}, nil, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(parts) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			resources := []*apiResource{}
+			for _, name := range resourceNames(n, kind) {
+				resources = append(resources, apiResourceOf(n, origins, kind, name))
+			}
+			writeJSON(w, http.StatusOK, map[string][]*apiResource{"resources": resources})
+			return nil
+		case http.MethodPost:
+			res, err := decodeAPIResource(r, kind, "")
+			if err != nil {
+				return err
+			}
+			if apiResourceOf(n, origins, kind, res.Name) != nil {
+				return fmt.Errorf("%w: %q", duplicateError(kind), res.Name)
+			}
+			return s.write(w, http.StatusCreated, locale, kind, res, n, origins)
+		}
+		return methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+
+	name := parts[2]
+	current := apiResourceOf(n, origins, kind, name)
+	ifMatch := r.Header.Get("If-Match")
+
+	switch r.Method {
+	case http.MethodGet:
+		if current == nil {
+			return fmt.Errorf("%w: %s %q", ErrorNotFound, kind, name)
+		}
+		w.Header().Set("ETag", current.ETag)
+		if r.Header.Get("If-None-Match") == current.ETag {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+		writeJSON(w, http.StatusOK, current)
+		return nil
+
+	case http.MethodPut:
+		res, err := decodeAPIResource(r, kind, name)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			if ifMatch != "" {
+				return errorPrecondition(kind, name)
+			}
+			return s.write(w, http.StatusCreated, locale, kind, res, n, origins)
+		}
+		if err = checkIfMatch(r, kind, current); err != nil {
+			return err
+		}
+		return s.write(w, http.StatusOK, locale, kind, res, n, origins)
+
+	case http.MethodDelete:
+		if current == nil {
+			return fmt.Errorf("%w: %s %q", ErrorNotFound, kind, name)
+		}
+		if err := checkIfMatch(r, kind, current); err != nil {
+			return err
+		}
+		if err := s.remove(locale, kind, name, origins); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+}
+
+// write creates or replaces a resource and sends it back.
+func (s *apiServer) write(w http.ResponseWriter, status int, locale, kind string, sent *apiResource, n *types.Nesting, origins map[resourceKey]resourceFile) error {
+	exists := apiResourceOf(n, origins, kind, sent.Name) != nil
+
+	def := findResource(n, kind, sent.Name)
+	if locale != "" {
+		d, err := getEntry("", kind, sent.Name)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		def = d
+	}
+	if err := apiCheckMaxLength(sent, kind, maxLength(findResource(n, kind, sent.Name), def)); err != nil {
+		return err
+	}
+
+	res, err := apiStoredResource(sent, kind, apiFileType(origins, kind, sent.Name))
+	if err != nil {
+		return err
+	}
+
+	if locale == "" {
+		switch {
+		case kind == KindString && exists:
+			_, err = UpdateString(res.Name, *res.Value)
+		case kind == KindString:
+			_, err = NewString(res.Name, *res.Value)
+		case kind == KindStringArray && exists:
+			_, err = UpdateStringArray(res.Name, res.Items)
+		case kind == KindStringArray:
+			_, err = NewStringArray(res.Name, res.Items)
+		default:
+			err = setQuantityString(apiPlural(res, findPlural(n, res.Name)))
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		var value interface{}
+		switch kind {
+		case KindString:
+			str := &types.String{Name: res.Name, Value: *res.Value}
+			if old := findString(n, res.Name); old != nil {
+				str.Meta = old.Meta
+			}
+			value = str
+		case KindStringArray:
+			value = apiStringArray(res, findStringArray(n, res.Name))
+		case KindPlurals:
+			value = apiPlural(res, findPlural(n, res.Name))
+		}
+
+		if _, err := writeEdits(locale, origins, []resourceEdit{{kind: kind, name: res.Name, value: value}}); err != nil {
+			return err
+		}
+		if err := reloadLocale(locale); err != nil {
+			return err
+		}
+	}
+
+	n, origins, err = readResources(locale, fileType)
+	if err != nil {
+		return err
+	}
+	written := apiResourceOf(n, origins, kind, res.Name)
+	if written == nil {
+		return fmt.Errorf("%w: %s %q", ErrorNotFound, kind, res.Name)
+	}
+
+	w.Header().Set("ETag", written.ETag)
+	if status == http.StatusCreated {
+		w.Header().Set("Location", "/"+apiLocaleName(locale)+"/"+kind+"/"+res.Name)
+	}
+	writeJSON(w, status, written)
+	return nil
+}
+
+// remove deletes a resource.
+func (s *apiServer) remove(locale, kind, name string, origins map[resourceKey]resourceFile) error {
+	if locale == "" {
+		switch kind {
+		case KindString:
+			return RemoveString(name)
+		case KindStringArray:
+			return RemoveStringArray(name)
+		}
+		return RemoveQuantityString(name)
+	}
+
+	if _, err := writeEdits(locale, origins, []resourceEdit{{kind: kind, name: name}}); err != nil {
+		return err
+	}
+	return reloadLocale(locale)
+}
+
+// reloadLocale reloads the resources of a locale, if loaded.
+func reloadLocale(locale string) error {
+	locale_mu.RLock()
+	_, ok := locale_dictionaries[locale]
+	locale_mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	return LoadLocale(locale, fileType)
+}
+
+// apiStringArray returns the string-array of a resource, keeping the metadata of old.
+func apiStringArray(res *apiResource, old *types.StringArray) *types.StringArray {
+	sa := &types.StringArray{Name: res.Name}
+	if old != nil {
+		sa.Meta = old.Meta
+	}
+	for _, item := range res.Items {
+		sa.Items = append(sa.Items, &types.Item{Value: item})
+	}
+	return sa
+}
+
+// apiPlural returns the quantity string of a resource, with only its quantities, keeping the metadata of old.
+func apiPlural(res *apiResource, old *types.Plural) *types.Plural {
+	pl := &types.Plural{Name: res.Name}
+	if old != nil {
+		pl.Meta = old.Meta
+	}
+	for _, q := range quantityValues {
+		if v, ok := res.Quantities[q]; ok {
+			pl.Items = append(pl.Items, &types.PluralItem{Quantity: q, Value: v})
+		}
+	}
+	return pl
+}
+
+// checkOrigin refuses the requests sent by pages of another host, as told by the Origin header of browsers.
+func checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return nil
+	}
+	return &apiError{status: http.StatusForbidden, Code: "forbidden", Message: fmt.Sprintf("cross-origin request from %s", origin)}
+}
+
+// decodeAPIResource decodes and validates the resource in the request body. The name in the URL, if any, is
+// the default one and must match the body's.
+func decodeAPIResource(r *http.Request, kind, name string) (*apiResource, error) {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return nil, &apiError{status: http.StatusUnsupportedMediaType, Code: "unsupported_media_type", Message: "request body must be application/json"}
+	}
+
+	res := &apiResource{}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(res); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, &apiError{status: http.StatusRequestEntityTooLarge, Code: "too_large", Message: fmt.Sprintf("request body larger than %d bytes", tooLarge.Limit)}
+		}
+		return nil, &apiError{status: http.StatusBadRequest, Code: "bad_request", Message: "malformed JSON: " + err.Error()}
+	}
+
+	invalid := func(field, format string, args ...interface{}) error {
+		return &apiError{status: http.StatusUnprocessableEntity, Code: "invalid", Message: fmt.Sprintf(format, args...), Field: field}
+	}
+
+	if res.Name == "" {
+		res.Name = name
+	}
+	switch {
+	case strings.TrimSpace(res.Name) == "":
+		return nil, invalid("name", "name is required")
+	case name != "" && res.Name != name:
+		return nil, invalid("name", "name %q doesn't match the URL's %q", res.Name, name)
+	}
+
+	switch kind {
+	case KindString:
+		if res.Value == nil || res.Items != nil || res.Quantities != nil {
+			return nil, invalid("value", "a string requires a value only")
+		}
+		if messageFormat {
+			if _, err := icu.Parse(*res.Value); err != nil {
+				return nil, invalid("value", "invalid message: %v", err)
+			}
+		}
+	case KindStringArray:
+		if res.Items == nil || res.Value != nil || res.Quantities != nil {
+			return nil, invalid("items", "a string-array requires items only")
+		}
+	case KindPlurals:
+		if len(res.Quantities) == 0 || res.Value != nil || res.Items != nil {
+			return nil, invalid("quantities", "a quantity string requires quantities only")
+		}
+		for q := range res.Quantities {
+			if quantityIndex(q) < 0 {
+				return nil, invalid("quantities."+q, "unknown quantity %q, expected one of %s", q, strings.Join(quantityValues[:], ", "))
+			}
+		}
+	}
+	return res, nil
+}
+
+// checkIfMatch requires the If-Match header of r to match the ETag of the current resource.
+func checkIfMatch(r *http.Request, kind string, current *apiResource) error {
+	switch ifMatch := r.Header.Get("If-Match"); ifMatch {
+	case "":
+		return &apiError{status: http.StatusPreconditionRequired, Code: "precondition_required", Message: "If-Match header required to change an existing resource"}
+	case "*", current.ETag:
+		return nil
+	}
+	return errorPrecondition(kind, current.Name)
+}
+
+func errorPrecondition(kind, name string) error {
+	return &apiError{status: http.StatusPreconditionFailed, Code: "precondition_failed", Message: fmt.Sprintf("%s %q was changed", kind, name)}
+}
+
+// apiResourceOf returns the JSON form of a resource of n, or nil if there is none. Values of XML files are sent
+// as text, see apiStoredResource.
+func apiResourceOf(n *types.Nesting, origins map[resourceKey]resourceFile, kind, name string) *apiResource {
+	text := func(value string) string { return value }
+	if apiFileType(origins, kind, name) == XML {
+		text = xmlText
+	}
+
+	res := &apiResource{Name: name}
+	switch kind {
+	case KindString:
+		s := findString(n, name)
+		if s == nil {
+			return nil
+		}
+		value := text(s.Value)
+		res.Value = &value
+	case KindStringArray:
+		sa := findStringArray(n, name)
+		if sa == nil {
+			return nil
+		}
+		res.Items = []string{}
+		for _, item := range sa.Items {
+			res.Items = append(res.Items, text(item.Value))
+		}
+	case KindPlurals:
+		pl := findPlural(n, name)
+		if pl == nil {
+			return nil
+		}
+		res.Quantities = map[string]string{}
+		for _, item := range pl.Items {
+			if item.Value != "" {
+				res.Quantities[item.Quantity] = text(item.Value)
+			}
+		}
+	default:
+		return nil
+	}
+	if meta := resourceMeta(findResource(n, kind, name)); !meta.IsZero() {
+		res.Meta = &meta
+	}
+	res.ETag = apiETag(res)
+	return res
+}
+
+// apiCheckMaxLength refuses the values of a resource longer than max.
+func apiCheckMaxLength(res *apiResource, kind string, max int) error {
+	check := func(field, value string) error {
+		if err := checkMaxLength(max, kind, res.Name, value); err != nil {
+			return &apiError{status: http.StatusUnprocessableEntity, Code: "too_long", Message: err.Error(), Field: field}
+		}
+		return nil
+	}
+
+	switch kind {
+	case KindString:
+		return check("value", *res.Value)
+	case KindStringArray:
+		for i, item := range res.Items {
+			if err := check("items."+strconv.Itoa(i), item); err != nil {
+				return err
+			}
+		}
+	case KindPlurals:
+		for _, q := range quantityValues {
+			if item, ok := res.Quantities[q]; ok {
+				if err := check("quantities."+q, item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// apiFileType returns the type of the file a resource is read from or written to.
+func apiFileType(origins map[resourceKey]resourceFile, kind, name string) types.FileType {
+	if f, ok := origins[resourceKey{kind, name}]; ok {
+		return f.t
+	}
+	return fileType
+}
+
+// apiStoredResource returns the resource sent to the API with its values as written to a file of type t.
+// XML values are inner XML: their text is escaped, keeping the well-formed markup tags. Resources the file type
+// can't encode and decode back are refused, before any file is written.
+func apiStoredResource(sent *apiResource, kind string, t types.FileType) (*apiResource, error) {
+	res := &apiResource{Name: sent.Name, Quantities: map[string]string{}}
+	store := func(field, value string) (string, error) {
+		if t != XML {
+			return value, nil
+		}
+		inner, err := xmlInner(value)
+		if err != nil {
+			return "", &apiError{status: http.StatusUnprocessableEntity, Code: "invalid", Message: fmt.Sprintf("value can't be written to an XML file: %v", err), Field: field}
+		}
+		return inner, nil
+	}
+
+	switch kind {
+	case KindString:
+		value, err := store("value", *sent.Value)
+		if err != nil {
+			return nil, err
+		}
+		res.Value = &value
+	case KindStringArray:
+		res.Items = []string{}
+		for i, item := range sent.Items {
+			value, err := store("items."+strconv.Itoa(i), item)
+			if err != nil {
+				return nil, err
+			}
+			res.Items = append(res.Items, value)
+		}
+	case KindPlurals:
+		for q, item := range sent.Quantities {
+			value, err := store("quantities."+q, item)
+			if err != nil {
+				return nil, err
+			}
+			res.Quantities[q] = value
+		}
+	}
+
+	// Round trip through the file type.
+	n := &types.Nesting{}
+	switch kind {
+	case KindString:
+		n.Strings = append(n.Strings, &types.String{Name: res.Name, Value: *res.Value})
+	case KindStringArray:
+		n.StringsArray = append(n.StringsArray, apiStringArray(res, nil))
+	case KindPlurals:
+		n.Plurals = append(n.Plurals, apiPlural(res, nil))
+	}
+	ed := types.EncoderDecoder{}
+	ed.SetStrategy(strategyFor(t))
+	data, err := ed.Encode(n)
+	decoded := &types.Nesting{}
+	if err == nil {
+		err = ed.Decode(data, &decoded)
+	}
+	if err == nil {
+		if got := apiResourceOf(decoded, nil, kind, res.Name); got == nil || apiETag(got) != apiETag(apiResourceOf(n, nil, kind, res.Name)) {
+			err = errors.New("value changed when read back")
+		}
+	}
+	if err != nil {
+		return nil, &apiError{status: http.StatusUnprocessableEntity, Code: "invalid", Message: fmt.Sprintf("%s %q can't be written to a %s file: %v", kind, res.Name, t, err)}
+	}
+	return res, nil
+}
+
+// xmlTag matches a well-formed start, end or empty-element tag.
+var xmlTag = regexp.MustCompile(`^</?[A-Za-z_][-A-Za-z0-9_.:]*(\s+[A-Za-z_][-A-Za-z0-9_.:]*\s*=\s*("[^"<&]*"|'[^'<&]*'))*\s*/?>`)
+
+// xmlInner returns text as the inner XML of an element: markup tags are kept, '&' and the other '<' and '>'
+// escaped. If the tags aren't well-formed, like an unclosed <b>, every '<' is escaped. Text with characters XML
+// doesn't allow is an error.
+func xmlInner(text string) (string, error) {
+	escape := func(keepTags bool) string {
+		b := strings.Builder{}
+		for i := 0; i < len(text); i++ {
+			switch text[i] {
+			case '<':
+				if tag := xmlTag.FindString(text[i:]); keepTags && tag != "" {
+					b.WriteString(tag)
+					i += len(tag) - 1
+				} else {
+					b.WriteString("&lt;")
+				}
+			case '>':
+				b.WriteString("&gt;")
+			case '&':
+				b.WriteString("&amp;")
+			default:
+				b.WriteByte(text[i])
+			}
+		}
+		return b.String()
+	}
+
+	inner := escape(true)
+	if wellFormed(inner) != nil {
+		inner = escape(false)
+	}
+	return inner, wellFormed(inner)
+}
+
+// wellFormed checks the inner XML of an element.
+func wellFormed(inner string) error {
+	dec := xml.NewDecoder(strings.NewReader("<value>" + inner + "</value>"))
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// xmlText returns the inner XML of an element as text, the inverse of xmlInner: entities are decoded and markup
+// tags kept. Inner XML not well-formed is returned unchanged.
+func xmlText(inner string) string {
+	data := "<value>" + inner + "</value>"
+	dec := xml.NewDecoder(strings.NewReader(data))
+	b := strings.Builder{}
+	depth := 0
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			return b.String()
+		}
+		if err != nil {
+			return inner
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				continue
+			}
+		case xml.CharData:
+			b.Write(tok)
+			continue
+		}
+		b.WriteString(data[offset:dec.InputOffset()])
+	}
+}
+
+// apiETag returns the ETag of a resource without one.
+func apiETag(res *apiResource) string {
+	data, _ := json.Marshal(res)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// resourceNames returns the names of the resources of a kind in n, sorted.
+func resourceNames(n *types.Nesting, kind string) []string {
+	var names []string
+	switch kind {
+	case KindString:
+		for _, s := range n.Strings {
+			names = append(names, s.Name)
+		}
+	case KindStringArray:
+		for _, sa := range n.StringsArray {
+			names = append(names, sa.Name)
+		}
+	case KindPlurals:
+		for _, pl := range n.Plurals {
+			names = append(names, pl.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func apiLocaleName(locale string) string {
+	if locale == "" {
+		return apiDefaultLocale
+	}
+	return locale
+}
+
+// apiErrorOf returns the JSON form of err.
+func apiErrorOf(err error) *apiError {
+	var e *apiError
+	var pe *ParseError
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, ErrorNotFound):
+		return &apiError{status: http.StatusNotFound, Code: "not_found", Message: err.Error()}
+	case errors.Is(err, ErrorDuplicateStringName), errors.Is(err, ErrorDuplicateStringArrayName),
+		errors.Is(err, ErrorDuplicateQuantityStringName):
+		return &apiError{status: http.StatusConflict, Code: "conflict", Message: err.Error(), Field: "name"}
+	case errors.Is(err, ErrorEmptyStringName), errors.Is(err, ErrorEmptyStringArrayName),
+		errors.Is(err, ErrorEmptyQuantityStringName):
+		return &apiError{status: http.StatusUnprocessableEntity, Code: "invalid", Message: err.Error(), Field: "name"}
+	case errors.Is(err, ErrorQuantityStringEmptyValues):
+		return &apiError{status: http.StatusUnprocessableEntity, Code: "invalid", Message: err.Error(), Field: "quantities"}
+	case errors.As(err, &pe) && pe.Path == "":
+		return &apiError{status: http.StatusUnprocessableEntity, Code: "invalid", Message: err.Error(), Field: "value"}
+	}
+	return &apiError{status: http.StatusInternalServerError, Code: "internal", Message: err.Error()}
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) error {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	return &apiError{status: http.StatusMethodNotAllowed, Code: "method_not_allowed", Message: "allowed methods: " + strings.Join(methods, ", ")}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,429 @@
+package stres
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAPIHandler(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetResourceType(XML) })
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="api_title" translatable="false">Title</string>
+	<plurals name="api_apples">
+		<item quantity="one">One apple</item>
+		<item quantity="many">%d apples</item>
+	</plurals>
+</resources>`)
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string name="api_title">Titolo</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLocale("it", XML); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(APIHandler())
+	defer srv.Close()
+
+	do := func(method, path, etag, body string) (*http.Response, map[string]interface{}) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if etag != "" {
+			req.Header.Set("If-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		data, _ := io.ReadAll(resp.Body)
+		var v map[string]interface{}
+		json.Unmarshal(data, &v)
+		return resp, v
+	}
+	errorOf := func(v map[string]interface{}) map[string]interface{} {
+		e, _ := v["error"].(map[string]interface{})
+		return e
+	}
+
+	resp, v := do("GET", "/", "", "")
+	if resp.StatusCode != http.StatusOK || !reflect.DeepEqual(v["locales"], []interface{}{"default", "it"}) {
+		t.Errorf("GET / = %d %v", resp.StatusCode, v)
+	}
+
+	resp, v = do("GET", "/default/string/api_title", "", "")
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || v["value"] != "Title" || etag == "" || v["etag"] != etag {
+		t.Fatalf("GET string = %d %v, ETag %q", resp.StatusCode, v, etag)
+	}
+
+	resp, v = do("PUT", "/default/string/api_title", "", `{"value": "New title"}`)
+	if resp.StatusCode != http.StatusPreconditionRequired || errorOf(v)["code"] != "precondition_required" {
+		t.Errorf("PUT without If-Match = %d %v", resp.StatusCode, v)
+	}
+	resp, v = do("PUT", "/default/string/api_title", `"stale"`, `{"value": "New title"}`)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("PUT with a stale ETag = %d %v", resp.StatusCode, v)
+	}
+	resp, v = do("PUT", "/default/string/api_title", etag, `{"value": "New title"}`)
+	if resp.StatusCode != http.StatusOK || v["value"] != "New title" || resp.Header.Get("ETag") == etag {
+		t.Errorf("PUT = %d %v", resp.StatusCode, v)
+	}
+	if GetString("api_title") != "New title" {
+		t.Errorf("GetString() after PUT = %q", GetString("api_title"))
+	}
+	if meta := GetMeta("api_title"); meta.Translatable == nil || *meta.Translatable {
+		t.Errorf("PUT lost the metadata: %+v", meta)
+	}
+
+	resp, v = do("POST", "/default/string-array", "", `{"name": "api_days", "items": ["Mon", "Tue"]}`)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "/default/string-array/api_days" {
+		t.Errorf("POST = %d %v", resp.StatusCode, v)
+	}
+	if !reflect.DeepEqual(GetArrayString("api_days"), []string{"Mon", "Tue"}) {
+		t.Errorf("GetArrayString() after POST = %v", GetArrayString("api_days"))
+	}
+	resp, v = do("POST", "/default/string-array", "", `{"name": "api_days", "items": []}`)
+	if resp.StatusCode != http.StatusConflict || errorOf(v)["field"] != "name" {
+		t.Errorf("POST of an existing resource = %d %v", resp.StatusCode, v)
+	}
+
+	resp, v = do("GET", "/default/plurals", "", "")
+	resources, _ := v["resources"].([]interface{})
+	if resp.StatusCode != http.StatusOK || len(resources) != 1 {
+		t.Fatalf("GET plurals = %d %v", resp.StatusCode, v)
+	}
+	apples := resources[0].(map[string]interface{})
+	if !reflect.DeepEqual(apples["quantities"], map[string]interface{}{"one": "One apple", "many": "%d apples"}) {
+		t.Errorf("GET plurals quantities = %v", apples["quantities"])
+	}
+
+	resp, v = do("PUT", "/it/plurals/api_apples", "", `{"quantities": {"one": "Una mela", "many": "%d mele"}}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("PUT to a locale = %d %v", resp.StatusCode, v)
+	}
+	if got := Locale("it").GetQuantityString("api_apples", 1); got != "Una mela" {
+		t.Errorf("Locale(it).GetQuantityString() after PUT = %q", got)
+	}
+
+	resp, v = do("PUT", "/it/plurals/api_apples", "", `{"quantities": {"lots": "Tante mele"}}`)
+	if resp.StatusCode != http.StatusUnprocessableEntity || errorOf(v)["field"] != "quantities.lots" {
+		t.Errorf("PUT of an unknown quantity = %d %v", resp.StatusCode, v)
+	}
+	resp, v = do("POST", "/it/string", "", `{"name": " ", "value": "x"}`)
+	if resp.StatusCode != http.StatusUnprocessableEntity || errorOf(v)["field"] != "name" {
+		t.Errorf("POST without name = %d %v", resp.StatusCode, v)
+	}
+	resp, v = do("POST", "/it/string", "", `{"name": "api_x", "value": `)
+	if resp.StatusCode != http.StatusBadRequest || errorOf(v)["code"] != "bad_request" {
+		t.Errorf("POST of malformed JSON = %d %v", resp.StatusCode, v)
+	}
+
+	resp, _ = do("GET", "/it/string/api_title", "", "")
+	etag = resp.Header.Get("ETag")
+	resp, v = do("DELETE", "/it/string/api_title", etag, "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE = %d %v", resp.StatusCode, v)
+	}
+	if got := Locale("it").GetString("api_title"); got != "New title" {
+		t.Errorf("Locale(it).GetString() after DELETE = %q, want the default value", got)
+	}
+	resp, _ = do("GET", "/it/string/api_title", "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET of a deleted resource = %d", resp.StatusCode)
+	}
+
+	for _, path := range []string{"/it/integer/x", "/../string", "/it", "/it/string/a/b"} {
+		if resp, _ = do("GET", path, "", ""); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+	if resp, _ = do("PATCH", "/it/string/api_x", "", ""); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("PATCH = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestAPIHandler_writes(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="apiw_title">Title</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(APIHandler())
+	defer srv.Close()
+
+	do := func(method, path string, header map[string]string, body string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	jsonType := map[string]string{"Content-Type": "application/json"}
+
+	if got := do("POST", "/default/string", map[string]string{"Content-Type": "text/plain"}, `{"name": "apiw_csrf", "value": "x"}`); got != http.StatusUnsupportedMediaType {
+		t.Errorf("POST as text/plain = %d, want 415", got)
+	}
+	crossSite := map[string]string{"Content-Type": "application/json", "Origin": "https://evil.example"}
+	if got := do("POST", "/default/string", crossSite, `{"name": "apiw_csrf", "value": "x"}`); got != http.StatusForbidden {
+		t.Errorf("POST from another origin = %d, want 403", got)
+	}
+	if _, err := LookupString("apiw_csrf"); err == nil {
+		t.Error("refused POST created the resource")
+	}
+	sameSite := map[string]string{"Content-Type": "application/json; charset=utf-8", "Origin": srv.URL}
+	if got := do("POST", "/default/string", sameSite, `{"name": "apiw_same", "value": "x"}`); got != http.StatusCreated {
+		t.Errorf("POST from the same origin = %d, want 201", got)
+	}
+	large := `{"name": "apiw_large", "value": "` + strings.Repeat("x", apiMaxBodySize) + `"}`
+	if got := do("POST", "/default/string", jsonType, large); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST of a large body = %d, want 413", got)
+	}
+
+	if got := do("POST", "/default/plurals", jsonType, `{"name": "apiw_files", "quantities": {"many": "%d files"}}`); got != http.StatusCreated {
+		t.Fatalf("POST plurals = %d, want 201", got)
+	}
+	n, _, err := readResources("", XML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl := findPlural(n, "apiw_files"); pl == nil || len(pl.Items) != 1 || pl.Items[0].Quantity != "many" {
+		t.Errorf("POST plurals wrote %+v, want only the many quantity", pl)
+	}
+	if got := GetQuantityString("apiw_files", 100); got != "%d files" {
+		t.Errorf("GetQuantityString() = %q, want %%d files", got)
+	}
+}
+
+func TestAPIHandler_escaping(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="apie_title">Title</string>
+	<string name="apie_entity">Fish &amp; chips</string>
+</resources>`)
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string name="apie_title">Titolo</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(APIHandler())
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var v map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&v)
+		return resp.StatusCode, v
+	}
+
+	if _, v := do("GET", "/default/string/apie_entity", ""); v["value"] != "Fish & chips" {
+		t.Errorf("GET of an entity = %v, want Fish & chips", v["value"])
+	}
+
+	values := map[string]string{
+		"apie_terms":  "Terms & Conditions <3",
+		"apie_cdata":  "a ]]> b",
+		"apie_markup": "<b>Bold</b> & <i>italic</i> x < y",
+		"apie_text":   "<b>unclosed & <Conditions>",
+	}
+	for _, locale := range []string{"default", "it"} {
+		for name, value := range values {
+			body, _ := json.Marshal(map[string]string{"value": value})
+			if status, v := do("PUT", "/"+locale+"/string/"+name, string(body)); status != http.StatusCreated || v["value"] != value {
+				t.Errorf("PUT %s %s = %d %v, want 201 %q", locale, name, status, v, value)
+			}
+			if _, v := do("GET", "/"+locale+"/string/"+name, ""); v["value"] != value {
+				t.Errorf("GET %s %s = %v, want %q", locale, name, v["value"], value)
+			}
+		}
+	}
+	if err := LoadValues(XML); err != nil {
+		t.Fatalf("LoadValues() after escaped writes error = %v", err)
+	}
+	if got := GetString("apie_terms"); got != "Terms &amp; Conditions &lt;3" {
+		t.Errorf("GetString() = %q, want the inner XML", got)
+	}
+
+	before, _ := os.ReadFile("strings/strings.xml")
+	for _, body := range []string{`{"value": "nul \u0000"}`, `{"value": "bell \u0007"}`} {
+		if status, v := do("PUT", "/default/string/apie_invalid", body); status != http.StatusUnprocessableEntity {
+			t.Errorf("PUT %s = %d %v, want 422", body, status, v)
+		}
+	}
+	if status, _ := do("POST", "/it/string-array", `{"name": "apie_items", "items": ["ok", "\u0001"]}`); status != http.StatusUnprocessableEntity {
+		t.Errorf("POST of an invalid item = %d, want 422", status)
+	}
+	if after, _ := os.ReadFile("strings/strings.xml"); string(after) != string(before) {
+		t.Errorf("refused PUT wrote the resource file:\n%s", after)
+	}
+}
+
+func TestAPIHandler_maxLength(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="apim_title" maxLength="8" formatted="false">Title</string>
+	<plurals name="apim_files" maxLength="6"><item quantity="one">1 file</item></plurals>
+</resources>`)
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string name="apim_title">Titolo</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(APIHandler())
+	defer srv.Close()
+
+	do := func(method, path, etag, body string) (int, map[string]interface{}) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if etag != "" {
+			req.Header.Set("If-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var v map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&v)
+		return resp.StatusCode, v
+	}
+
+	if _, v := do("GET", "/default/string/apim_title", "", ""); !reflect.DeepEqual(v["meta"], map[string]interface{}{"formatted": false, "maxLength": 8.0}) {
+		t.Errorf("GET meta = %v", v["meta"])
+	}
+
+	tests := []struct {
+		path, etag, body string
+		wantStatus       int
+		wantField        string
+	}{
+		{"/default/string/apim_title", "*", `{"value": "Headline"}`, http.StatusOK, ""},
+		{"/default/string/apim_title", "*", `{"value": "Headline!"}`, http.StatusUnprocessableEntity, "value"},
+		{"/it/string/apim_title", "*", `{"value": "Intestazione"}`, http.StatusUnprocessableEntity, "value"},
+		{"/it/plurals/apim_files", "", `{"quantities": {"one": "1 file", "many": "%d file"}}`, http.StatusUnprocessableEntity, "quantities.many"},
+		{"/it/plurals/apim_files", "", `{"quantities": {"one": "1 file"}}`, http.StatusCreated, ""},
+	}
+	for _, tt := range tests {
+		status, v := do("PUT", tt.path, tt.etag, tt.body)
+		e, _ := v["error"].(map[string]interface{})
+		if status != tt.wantStatus || (tt.wantField != "" && (e["code"] != "too_long" || e["field"] != tt.wantField)) {
+			t.Errorf("PUT %s %s = %d %v, want %d %s", tt.path, tt.body, status, v, tt.wantStatus, tt.wantField)
+		}
+	}
+}
+
+func TestAPIHandler_concurrent(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="apic_title">Title</string>
+</resources>`)
+	writeFile(t, "strings/errors.xml", `<resources>
+	<string name="apic_error">Error</string>
+</resources>`)
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+
+	// Handlers of their own, like APIHandler and the one of EditorHandler, don't serialize each other's requests.
+	handlers := []http.Handler{APIHandler(), APIHandler(), APIHandler(), APIHandler()}
+	var wg sync.WaitGroup
+	for i, h := range handlers {
+		wg.Add(1)
+		go func(i int, h http.Handler) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				name := fmt.Sprintf("apic_%d_%d", i, j)
+				req := httptest.NewRequest("PUT", "/default/string/"+name, strings.NewReader(`{"value": "x"}`))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				if rec.Code != http.StatusCreated {
+					t.Errorf("PUT %s = %d %s", name, rec.Code, rec.Body)
+				}
+
+				req = httptest.NewRequest("PUT", "/default/string/apic_error", strings.NewReader(`{"value": "`+name+`"}`))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("If-Match", "*")
+				rec = httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Errorf("PUT apic_error = %d %s", rec.Code, rec.Body)
+				}
+			}
+		}(i, h)
+	}
+	wg.Wait()
+
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	for i := range handlers {
+		for j := 0; j < 5; j++ {
+			if name := fmt.Sprintf("apic_%d_%d", i, j); GetString(name) != "x" {
+				t.Errorf("GetString(%q) = %q, want x", name, GetString(name))
+			}
+		}
+	}
+	if data, _ := os.ReadFile("strings/errors.xml"); strings.Count(string(data), "apic_error") != 1 {
+		t.Errorf("errors.xml =\n%s\nwant apic_error once", data)
+	}
+}
@@ -0,0 +1,154 @@
+package stres
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+// Every resource directory of a signed bundle holds a manifest listing the SHA-256 hash of each resource file,
+// in sha256sum format after a header line naming the locale, and the base64 ed25519 signature of the manifest.
+// The header binds the manifest to its locale, so that a signed directory can't be copied over another one.
+const (
+	manifestName   = "stres.manifest"
+	signatureName  = "stres.manifest.sig"
+	manifestHeader = "# stres locale: "
+)
+
+var ErrorSignature error = errors.New("stres: resource signature verification failed")
+
+var verificationKey ed25519.PublicKey
+
+/*
+	Sets the public key verifying resource files before LoadValues, LoadLocale and RemoteLoader decode them. Every
+	resource directory must then hold a manifest written by SignResources with the matching private key, listing
+	exactly the files to load, and remote files a signature written by SignFile: missing or invalid signatures and
+	files not matching the manifest make loading fail with an error wrapping ErrorSignature. A nil key disables
+	verification. (default value: nil)
+*/
+func SetVerificationKey(key ed25519.PublicKey) {
+	verificationKey = key
+}
+
+/*
+	Signs the resource files of the default locale and of every translation, writing in each resource directory
+	a manifest of their SHA-256 hashes and its ed25519 signature. Uses setted resource file extension.
+*/
+func SignResources(key ed25519.PrivateKey) error {
+	locales, err := Locales()
+	if err != nil {
+		return err
+	}
+
+	for _, locale := range append([]string{""}, locales...) {
+		if err = signDir(locale, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func signDir(locale string, key ed25519.PrivateKey) error {
+	dir := resourceDirOf(locale)
+	files, err := resourceFiles(dir, fileType)
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+
+	manifest := bytes.Buffer{}
+	manifest.WriteString(manifestHeader + locale + "\n")
+	for _, f := range files {
+		data, err := store.Get(f.path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(&manifest, "%x  %s\n", sum, path.Base(f.path))
+	}
+
+	if err = store.Put(path.Join(dir, manifestName), manifest.Bytes()); err != nil {
+		return err
+	}
+	return store.Put(path.Join(dir, signatureName), signature(key, manifest.Bytes()))
+}
+
+/*
+	Signs a single resource file, like the ones loaded by RemoteLoader, writing the base64 ed25519 signature of its
+	content next to it, in "<path>.sig".
+*/
+func SignFile(path string, key ed25519.PrivateKey) error {
+	data, err := store.Get(path)
+	if err != nil {
+		return err
+	}
+	return store.Put(path+".sig", signature(key, data))
+}
+
+// signature returns the base64 ed25519 signature of data, as written in signature files.
+func signature(key ed25519.PrivateKey, data []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n")
+}
+
+// verifySignature reports whether encoded, the content of a signature file, is the signature of data
+// with the verification key.
+func verifySignature(data, encoded []byte) bool {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	return err == nil && ed25519.Verify(verificationKey, data, sig)
+}
+
+// readManifest returns the hashes of the resource files of a locale by file name, after verifying the manifest's
+// signature with the verification key and that it was signed for the locale.
+func readManifest(locale string) (map[string]string, error) {
+	dir := resourceDirOf(locale)
+	manifest, err := store.Get(path.Join(dir, manifestName))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorSignature, err)
+	}
+	encoded, err := store.Get(path.Join(dir, signatureName))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorSignature, err)
+	}
+	if !verifySignature(manifest, encoded) {
+		return nil, fmt.Errorf("%w: bad signature of %s", ErrorSignature, path.Join(dir, manifestName))
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(manifest))
+	if !s.Scan() || s.Text() != manifestHeader+locale {
+		return nil, fmt.Errorf("%w: %s isn't signed for this locale", ErrorSignature, path.Join(dir, manifestName))
+	}
+
+	hashes := map[string]string{}
+	for s.Scan() {
+		sum, name, ok := strings.Cut(s.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed %s", ErrorSignature, path.Join(dir, manifestName))
+		}
+		hashes[name] = sum
+	}
+	return hashes, nil
+}
+
+// readVerified decodes a resource file whose SHA-256 hash must be the one in hashes.
+func readVerified(f resourceFile, hashes map[string]string) (*types.Nesting, error) {
+	data, err := store.Get(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if want, ok := hashes[path.Base(f.path)]; !ok || want != hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("%w: %s doesn't match the manifest", ErrorSignature, f.path)
+	}
+	return decodeNesting(f.path, f.t, data)
+}
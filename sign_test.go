@@ -0,0 +1,110 @@
+package stres
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestSignResources(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetVerificationKey(nil)
+		SetResourceType(XML)
+	})
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="sign_app_name">stres</string>
+</resources>`)
+	writeFile(t, "strings/errors.xml", `<resources>
+	<string name="sign_error">Error</string>
+</resources>`)
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string name="sign_app_name">stres it</string>
+</resources>`)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetResourceType(XML)
+	if err = SignResources(priv); err != nil {
+		t.Fatal(err)
+	}
+
+	SetVerificationKey(pub)
+	if err = LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if err = LoadLocale("it", XML); err != nil {
+		t.Fatal(err)
+	}
+	if GetString("sign_error") != "Error" {
+		t.Errorf("GetString() = %q, want Error", GetString("sign_error"))
+	}
+
+	writeFile(t, "strings-it/strings.xml", `<resources>
+	<string name="sign_app_name">tampered</string>
+</resources>`)
+	if err = LoadLocale("it", XML); !errors.Is(err, ErrorSignature) {
+		t.Errorf("LoadLocale() of a tampered file error = %v, want %v", err, ErrorSignature)
+	}
+
+	writeFile(t, "strings/extra.xml", `<resources>
+	<string name="sign_extra">Extra</string>
+</resources>`)
+	if err = LoadValues(XML); !errors.Is(err, ErrorSignature) {
+		t.Errorf("LoadValues() with an unsigned file error = %v, want %v", err, ErrorSignature)
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	SetVerificationKey(other)
+	if err = SignResources(priv); err != nil {
+		t.Fatal(err)
+	}
+	if err = LoadValues(XML); !errors.Is(err, ErrorSignature) {
+		t.Errorf("LoadValues() with another key error = %v, want %v", err, ErrorSignature)
+	}
+
+	SetVerificationKey(pub)
+	if err = LoadValues(XML); err != nil {
+		t.Errorf("LoadValues() after signing again error = %v", err)
+	}
+}
+
+func TestSignResources_swappedLocale(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetVerificationKey(nil) })
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="sign_title">Title</string>
+</resources>`)
+	writeFile(t, "strings-fr/strings.xml", `<resources>
+	<string name="sign_title">Titre</string>
+</resources>`)
+	writeFile(t, "strings-de/strings.xml", `<resources>
+	<string name="sign_title">Titel</string>
+</resources>`)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = SignResources(priv); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"strings.xml", manifestName, signatureName} {
+		data, err := os.ReadFile("strings-fr/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, "strings-de/"+name, string(data))
+	}
+
+	SetVerificationKey(pub)
+	if err = LoadLocale("de", XML); !errors.Is(err, ErrorSignature) {
+		t.Errorf("LoadLocale() of another locale's signed files error = %v, want %v", err, ErrorSignature)
+	}
+}
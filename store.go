@@ -0,0 +1,361 @@
+package stres
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+/*
+	Storage backend of the resource files, which are addressed by slash-separated paths (e.g. "strings/strings.xml")
+	and read and written as raw bytes, compressed if the file is. The filesystem is used by default; other backends,
+	such as a key-value database or a test double, can be set with SetStore. Stat and Head let existence checks and
+	format detection skip reading whole files. Backends storing individual resources rather than files implement
+	EntryStore as well.
+*/
+type Store interface {
+	// Get returns the content of the file at path, or an error wrapping fs.ErrNotExist if there is none.
+	Get(path string) ([]byte, error)
+	// Stat describes the file or the directory at path, or returns an error wrapping fs.ErrNotExist if there is none.
+	Stat(path string) (fs.FileInfo, error)
+	// Head returns the first n bytes of the file at path, fewer if it is shorter, or an error wrapping
+	// fs.ErrNotExist if there is none.
+	Head(path string, n int) ([]byte, error)
+	// Put writes the file at path, creating its parent directories.
+	Put(path string, data []byte) error
+	// Delete removes the file or the empty directory at path, or returns an error wrapping fs.ErrNotExist if there
+	// is none.
+	Delete(path string) error
+	// List returns the names of the files in dir and, followed by a slash, of its subdirectories, sorted.
+	List(dir string) ([]string, error)
+	// Lock locks the file at path for a read-modify-write, until unlock is called.
+	Lock(path string) (unlock func(), err error)
+}
+
+/*
+	Store of individual resources, such as rows of a key-value database, rather than of resource files. When the
+	store set with SetStore is an EntryStore, resources are read, written and listed through its entry methods
+	(the Store ones remaining for the files written by FormatFile, SignResources, ...); the file stores, like
+	FSStore and MemoryStore, are used through their resource files instead. Signature verification needs files:
+	with an EntryStore, SetVerificationKey makes loads fail.
+*/
+type EntryStore interface {
+	Store
+	// Locales returns the locales holding resources, the default one (the empty string) excluded, sorted.
+	Locales() ([]string, error)
+	// Entries returns every resource of a locale, or an error wrapping fs.ErrNotExist if it has none.
+	Entries(locale string) (*types.Nesting, error)
+	// GetEntry returns the resource of a locale with the given kind (one of the Kind constants) and name, as a
+	// *types.String, *types.StringArray, *types.Plural, ..., or an error wrapping fs.ErrNotExist if there is none.
+	GetEntry(locale, kind, name string) (interface{}, error)
+	// PutEntry adds or replaces a resource of a locale, value being of the type GetEntry returns, or removes it
+	// if value is nil.
+	PutEntry(locale, kind, name string, value interface{}) error
+}
+
+var store Store = NewFSStore(".")
+
+// getEntry returns a resource of a locale from an EntryStore or else from the locale's resource files, or an
+// error wrapping fs.ErrNotExist if there is none.
+func getEntry(locale, kind, name string) (interface{}, error) {
+	if es, ok := store.(EntryStore); ok {
+		return es.GetEntry(locale, kind, name)
+	}
+
+	n, _, err := readResources(locale, fileType)
+	if err != nil {
+		return nil, err
+	}
+	if res := findResource(n, kind, name); res != nil {
+		return res, nil
+	}
+	return nil, fmt.Errorf("%w: %s %q in %s", fs.ErrNotExist, kind, name, resourceDirOf(locale))
+}
+
+/*
+	Sets the storage backend of the resource files used by every function. A nil store restores the default one,
+	the filesystem from the working directory.
+*/
+func SetStore(s Store) {
+	if s == nil {
+		s = NewFSStore(".")
+	}
+	store = s
+}
+
+// pathLocks are mutexes by path, held for read-modify-writes.
+type pathLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func (l *pathLocks) lock(path string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*sync.Mutex{}
+	}
+	m, ok := l.locks[path]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[path] = m
+	}
+	l.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
+
+/*
+	Store of resource files in the filesystem, with relative paths resolved from a root directory.
+	Locks only hold within the process.
+*/
+type FSStore struct {
+	root  string
+	locks pathLocks
+}
+
+/*
+	Returns a store of resource files in the filesystem under root.
+*/
+func NewFSStore(root string) *FSStore {
+	return &FSStore{root: root}
+}
+
+func (s *FSStore) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.root, filepath.FromSlash(p))
+}
+
+func (s *FSStore) Get(path string) ([]byte, error) {
+	return os.ReadFile(s.path(path))
+}
+
+func (s *FSStore) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(s.path(path))
+}
+
+func (s *FSStore) Head(path string, n int) ([]byte, error) {
+	f, err := os.Open(s.path(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, n)
+	read, err := io.ReadFull(f, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return head[:read], err
+}
+
+// Put writes a temporary file renamed over the one at path, so that concurrent readers never see it half written.
+func (s *FSStore) Put(path string, data []byte) error {
+	name := s.path(path)
+	if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := writeTemp(name, data)
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeTemp writes data to a new temporary file next to the file name, to be renamed over it.
+func writeTemp(name string, data []byte) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Chmod(0644)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (s *FSStore) Delete(path string) error {
+	return os.Remove(s.path(path))
+}
+
+func (s *FSStore) List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(s.path(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name()+"/")
+		} else {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (s *FSStore) Lock(path string) (func(), error) {
+	return s.locks.lock(s.path(path)), nil
+}
+
+/*
+	Store of resource files in memory, for tests or as a base for other backends. Directories exist as long as
+	they hold files.
+*/
+type MemoryStore struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+	locks pathLocks
+}
+
+/*
+	Returns an empty in-memory store.
+*/
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{files: map[string][]byte{}}
+}
+
+func (s *MemoryStore) Get(p string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.files[path.Clean(p)]
+	if !ok {
+		return nil, &fs.PathError{Op: "get", Path: p, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (s *MemoryStore) Stat(p string) (fs.FileInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p = path.Clean(p)
+	if data, ok := s.files[p]; ok {
+		return memoryFileInfo{name: path.Base(p), size: int64(len(data))}, nil
+	}
+	for name := range s.files {
+		if p == "." || strings.HasPrefix(name, p+"/") {
+			return memoryFileInfo{name: path.Base(p), dir: true}, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "stat", Path: p, Err: fs.ErrNotExist}
+}
+
+func (s *MemoryStore) Head(p string, n int) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.files[path.Clean(p)]
+	if !ok {
+		return nil, &fs.PathError{Op: "head", Path: p, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data[:min(n, len(data))]...), nil
+}
+
+func (s *MemoryStore) Put(p string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[path.Clean(p)] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *MemoryStore) Delete(p string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p = path.Clean(p)
+	if _, ok := s.files[p]; ok {
+		delete(s.files, p)
+		return nil
+	}
+	for name := range s.files {
+		if strings.HasPrefix(name, p+"/") {
+			return &fs.PathError{Op: "delete", Path: p, Err: errors.New("directory not empty")}
+		}
+	}
+	return &fs.PathError{Op: "delete", Path: p, Err: fs.ErrNotExist}
+}
+
+func (s *MemoryStore) List(dir string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefix := path.Clean(dir) + "/"
+	if prefix == "./" {
+		prefix = ""
+	}
+
+	seen := map[string]bool{}
+	for name := range s.files {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, prefix)
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			rest = rest[:i+1]
+		}
+		seen[rest] = true
+	}
+	if len(seen) == 0 && prefix != "" {
+		return nil, &fs.PathError{Op: "list", Path: dir, Err: fs.ErrNotExist}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *MemoryStore) Lock(p string) (func(), error) {
+	return s.locks.lock(path.Clean(p)), nil
+}
+
+// memoryFileInfo describes a file or a directory of a MemoryStore.
+type memoryFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi memoryFileInfo) Name() string       { return fi.name }
+func (fi memoryFileInfo) Size() int64        { return fi.size }
+func (fi memoryFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi memoryFileInfo) IsDir() bool        { return fi.dir }
+func (fi memoryFileInfo) Sys() any           { return nil }
+
+func (fi memoryFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
@@ -0,0 +1,288 @@
+package stres
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	SetStore(s)
+	t.Cleanup(func() {
+		SetStore(nil)
+		SetResourceType(XML)
+	})
+
+	s.Put("strings/strings.xml", []byte(`<resources>
+	<string name="store_title">Title</string>
+</resources>`))
+	s.Put("strings-it/strings.xml", []byte(`<resources>
+	<string name="store_title">Titolo</string>
+</resources>`))
+	s.Put("strings-it/notes.txt", []byte("not a resource file"))
+
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetString("store_title"); got != "Title" {
+		t.Errorf("GetString() = %q, want Title", got)
+	}
+
+	locales, err := Locales()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(locales, []string{"it"}) {
+		t.Errorf("Locales() = %v, want [it]", locales)
+	}
+	if err = LoadLocale("it", XML); err != nil {
+		t.Fatal(err)
+	}
+	if got := Locale("it").GetString("store_title"); got != "Titolo" {
+		t.Errorf("Locale(it).GetString() = %q, want Titolo", got)
+	}
+
+	if _, err = NewString("store_subtitle", "Subtitle"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.Get("strings/strings.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<string name="store_subtitle">Subtitle</string>`) {
+		t.Errorf("NewString() wrote %s", data)
+	}
+
+	if names, _ := s.List("."); !reflect.DeepEqual(names, []string{"strings-it/", "strings/"}) {
+		t.Errorf("List(.) = %v", names)
+	}
+	if err = s.Delete("strings"); err == nil {
+		t.Error("Delete() of a directory holding files error = nil")
+	}
+
+	if err = DeleteResourceFile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Get("strings/strings.xml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Get() of the deleted resource file error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if _, err = s.List("strings"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("List() of the deleted resource directory error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if err = LoadValues(XML); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadValues() without resource file error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestStore_deleteMissing(t *testing.T) {
+	for name, s := range map[string]Store{"memory": NewMemoryStore(), "fs": NewFSStore(t.TempDir())} {
+		if err := s.Delete("strings/missing.xml"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s Delete() of a missing file error = %v, want %v", name, err, fs.ErrNotExist)
+		}
+	}
+}
+
+// unavailableStore fails to read existing files.
+type unavailableStore struct {
+	*MemoryStore
+}
+
+func (s unavailableStore) Get(path string) ([]byte, error) {
+	if _, err := s.MemoryStore.Get(path); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("store unavailable")
+}
+
+func TestWriteEdits_getError(t *testing.T) {
+	s := NewMemoryStore()
+	SetStore(unavailableStore{s})
+	t.Cleanup(func() { SetStore(nil) })
+
+	original := []byte(`<resources><string name="store_kept">Kept</string></resources>`)
+	s.Put("strings/strings.xml", original)
+
+	_, err := writeEdits("", nil, []resourceEdit{{kind: KindString, name: "store_new", value: &types.String{Name: "store_new"}}})
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("writeEdits() error = %v, want the store's error", err)
+	}
+	if data, _ := s.Get("strings/strings.xml"); string(data) != string(original) {
+		t.Errorf("writeEdits() overwrote the file:\n%s", data)
+	}
+}
+
+func TestStore_statHead(t *testing.T) {
+	for name, s := range map[string]Store{"memory": NewMemoryStore(), "fs": NewFSStore(t.TempDir())} {
+		if err := s.Put("strings/strings.xml", []byte("<resources/>")); err != nil {
+			t.Fatal(err)
+		}
+
+		if fi, err := s.Stat("strings/strings.xml"); err != nil || fi.IsDir() || fi.Size() != 12 {
+			t.Errorf("%s Stat() of a file = %v, %v, want its size 12", name, fi, err)
+		}
+		if fi, err := s.Stat("strings"); err != nil || !fi.IsDir() {
+			t.Errorf("%s Stat() of a directory = %v, %v, want a directory", name, fi, err)
+		}
+		if _, err := s.Stat("strings/missing.xml"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s Stat() of a missing file error = %v, want %v", name, err, fs.ErrNotExist)
+		}
+
+		if head, err := s.Head("strings/strings.xml", 4); err != nil || string(head) != "<res" {
+			t.Errorf("%s Head(4) = %q, %v, want <res", name, head, err)
+		}
+		if head, err := s.Head("strings/strings.xml", 100); err != nil || string(head) != "<resources/>" {
+			t.Errorf("%s Head(100) = %q, %v, want the whole file", name, head, err)
+		}
+		if _, err := s.Head("strings/missing.xml", 4); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s Head() of a missing file error = %v, want %v", name, err, fs.ErrNotExist)
+		}
+	}
+}
+
+// entryStore is an EntryStore keeping the resources of every locale in memory, as a key-value database would.
+type entryStore struct {
+	*MemoryStore
+	mu      sync.Mutex
+	locales map[string]*types.Nesting
+}
+
+func (s *entryStore) Locales() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var locales []string
+	for locale := range s.locales {
+		if locale != "" {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return locales, nil
+}
+
+func (s *entryStore) Entries(locale string) (*types.Nesting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.locales[locale]
+	if !ok {
+		return nil, fmt.Errorf("%w: locale %q", fs.ErrNotExist, locale)
+	}
+	return n, nil
+}
+
+func (s *entryStore) GetEntry(locale, kind, name string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n, ok := s.locales[locale]; ok {
+		if res := findResource(n, kind, name); res != nil {
+			return res, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %q", fs.ErrNotExist, kind, name)
+}
+
+func (s *entryStore) PutEntry(locale, kind, name string, value interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.locales[locale]
+	if !ok {
+		n = &types.Nesting{}
+		s.locales[locale] = n
+	}
+	return applyEdit(n, resourceEdit{kind: kind, name: name, value: value})
+}
+
+func TestEntryStore(t *testing.T) {
+	s := &entryStore{MemoryStore: NewMemoryStore(), locales: map[string]*types.Nesting{
+		"": {Strings: []*types.String{
+			{Name: "entry_title", Value: "Title"},
+			{Name: "entry_short", Value: "Short", Meta: types.Meta{MaxLength: 8}},
+		}},
+		"it": {Strings: []*types.String{{Name: "entry_title", Value: "Titolo"}}},
+	}}
+	SetStore(s)
+	t.Cleanup(func() {
+		SetStore(nil)
+		locale_mu.Lock()
+		delete(locale_dictionaries, "it")
+		locale_mu.Unlock()
+	})
+
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetString("entry_title"); got != "Title" {
+		t.Errorf("GetString() = %q, want Title", got)
+	}
+	if locales, err := Locales(); err != nil || !reflect.DeepEqual(locales, []string{"it"}) {
+		t.Errorf("Locales() = %v, %v, want [it]", locales, err)
+	}
+	if err := LoadLocale("it", XML); err != nil {
+		t.Fatal(err)
+	}
+	if got := Locale("it").GetString("entry_title"); got != "Titolo" {
+		t.Errorf("Locale(it).GetString() = %q, want Titolo", got)
+	}
+
+	if _, err := NewString("entry_new", "New"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateString("entry_title", "Updated"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveString("entry_new"); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := s.GetEntry("", KindString, "entry_title"); err != nil || res.(*types.String).Value != "Updated" {
+		t.Errorf("GetEntry() after UpdateString() = %v, %v, want Updated", res, err)
+	}
+	if _, err := s.GetEntry("", KindString, "entry_new"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("GetEntry() after RemoveString() error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if names, _ := s.List("."); len(names) > 0 {
+		t.Errorf("resource files written to an EntryStore: %v", names)
+	}
+
+	// Translations written through the API get the max length of the default entry.
+	h := APIHandler()
+	for _, tc := range []struct {
+		value, ifMatch string
+		want           int
+	}{
+		{"Breve", "", http.StatusCreated},
+		{"Troppo lungo", "*", http.StatusUnprocessableEntity},
+	} {
+		req := httptest.NewRequest("PUT", "/it/string/entry_short", strings.NewReader(`{"value": "`+tc.value+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.ifMatch != "" {
+			req.Header.Set("If-Match", tc.ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("PUT %q = %d %s, want %d", tc.value, rec.Code, rec.Body, tc.want)
+		}
+	}
+	if res, err := s.GetEntry("it", KindString, "entry_short"); err != nil || res.(*types.String).Value != "Breve" {
+		t.Errorf("GetEntry(it) after PUT = %v, %v, want Breve", res, err)
+	}
+
+	SetVerificationKey(make([]byte, 32))
+	t.Cleanup(func() { SetVerificationKey(nil) })
+	if err := LoadValues(XML); !errors.Is(err, ErrorSignature) {
+		t.Errorf("LoadValues() with a verification key error = %v, want %v", err, ErrorSignature)
+	}
+}
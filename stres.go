@@ -2,11 +2,11 @@ package stres
 
 import (
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"os"
 	"strings"
-	"sync"
 
+	"github.com/Vinetwigs/stres/icu"
 	"github.com/Vinetwigs/stres/types"
 )
 
@@ -15,10 +15,13 @@ var encDec = types.EncoderDecoder{}
 
 var quantityValues = [...]string{"zero", "one", "two", "few", "many"}
 
-var data []byte
 var string_entries map[string]string = make(map[string]string)
 var string_array_entries map[string]types.StringArray = make(map[string]types.StringArray)
 var plural_string_entries map[string]types.Plural = make(map[string]types.Plural)
+var integer_entries map[string]types.Integer = make(map[string]types.Integer)
+var bool_entries map[string]types.Bool = make(map[string]types.Bool)
+var integer_array_entries map[string]types.IntegerArray = make(map[string]types.IntegerArray)
+var typed_array_entries map[string]types.Array = make(map[string]types.Array)
 
 var few_threshold = 20
 
@@ -29,71 +32,57 @@ const (
 	TOML    types.FileType = "toml"
 	WATSON  types.FileType = "watson"
 	MSGPACK types.FileType = "msgpack"
+	ARB     types.FileType = "arb"
+	I18NEXT types.FileType = "i18next.json"
 )
 
 var (
 	ErrorEmptyStringName         error = errors.New("stres: string name can't be empty")
 	ErrorEmptyStringArrayName    error = errors.New("stres: string-array name can't be empty")
 	ErrorEmptyQuantityStringName error = errors.New("stres: quantity string name can't be empty")
+	ErrorEmptyIntegerName        error = errors.New("stres: integer name can't be empty")
+	ErrorEmptyBoolName           error = errors.New("stres: bool name can't be empty")
+	ErrorEmptyIntegerArrayName   error = errors.New("stres: integer-array name can't be empty")
+	ErrorEmptyArrayName          error = errors.New("stres: array name can't be empty")
 
 	ErrorDuplicateStringName         error = errors.New("stres: string name already inserted")
 	ErrorDuplicateStringArrayName    error = errors.New("stres: string-array name already inserted")
 	ErrorDuplicateQuantityStringName error = errors.New("stres: quantity string name already inserted")
+	ErrorDuplicateIntegerName        error = errors.New("stres: integer name already inserted")
+	ErrorDuplicateBoolName           error = errors.New("stres: bool name already inserted")
+	ErrorDuplicateIntegerArrayName   error = errors.New("stres: integer-array name already inserted")
+	ErrorDuplicateArrayName          error = errors.New("stres: array name already inserted")
 
 	ErrorQuantityStringPluralNotFound error = errors.New("stres: plural not found for the given quantity")
 
+	ErrorNotFound error = errors.New("stres: resource not found")
+
 	ErrorQuantityStringEmptyValues error = errors.New("stres: provided empty array to quantity string creationg")
 )
 
 /*
-	Loads values from strings file into internal dictionaries.
+	Error returned when a resource file is malformed. Reports the file's path and format and, where known,
+	the line, column and name of the offending resource. Use errors.As to inspect it.
+*/
+type ParseError = types.ParseError
+
+/*
+	Loads values from strings files into internal dictionaries.
 	Needs to be invoked only one time (but before getting strings values).
 	Takes a FileType parameter to specify strings file format.
+	Every file of the resource directory with the FileType's extension is loaded (e.g. "strings.xml",
+	"errors.xml"), failing if a resource is defined by more than one file.
 */
 func LoadValues(t types.FileType) error {
 	SetResourceType(t)
 
-	var err error
-	n := &types.Nesting{}
-
-	data, err = readBytes(strings.Join([]string{"./strings/strings.", string(t)}, ""))
+	n, origins, err := readResources("", t)
 	if err != nil {
 		return err
 	}
 
-	err = encDec.Decode(data, &n)
-	if err != nil {
-		return err
-	}
-
-	var wg sync.WaitGroup
-	wg.Add(3)
-
-	// Load strings
-	go func() {
-		defer wg.Done()
-		for i := 0; i < len(n.Strings); i++ {
-			string_entries[n.Strings[i].Name] = n.Strings[i].Value
-		}
-	}()
-
-	// Load string arrays
-	go func() {
-		defer wg.Done()
-		for i := 0; i < len(n.StringsArray); i++ {
-			string_array_entries[n.StringsArray[i].Name] = *n.StringsArray[i]
-		}
-	}()
-
-	// Load quantity strings
-	go func() {
-		defer wg.Done()
-		for i := 0; i < len(n.Plurals); i++ {
-			plural_string_entries[n.Plurals[i].Name] = *n.Plurals[i]
-		}
-	}()
-
-	wg.Wait()
+	loadNesting(n)
+	setOrigins(origins)
 
 	return nil
 }
@@ -104,40 +93,39 @@ func LoadValues(t types.FileType) error {
 
 func SetResourceType(t types.FileType) {
 	switch t {
-	case XML:
-		xmlED := &types.XMLStrategy{}
-		encDec.SetStrategy(xmlED)
+	case XML, JSON, YAML, TOML, WATSON, MSGPACK, ARB, I18NEXT:
+		fileType = t
+	default:
 		fileType = XML
+	}
+	encDec.SetStrategy(strategyFor(fileType))
+}
+
+func strategyFor(t types.FileType) types.StrategyAlgo {
+	switch t {
 	case JSON:
-		jsonED := &types.JSONStrategy{}
-		encDec.SetStrategy(jsonED)
-		fileType = JSON
+		return &types.JSONStrategy{}
 	case YAML:
-		yamlED := &types.YAMLStrategy{}
-		encDec.SetStrategy(yamlED)
-		fileType = YAML
+		return &types.YAMLStrategy{}
 	case TOML:
-		tomlED := &types.TOMLStrategy{}
-		encDec.SetStrategy(tomlED)
-		fileType = TOML
+		return &types.TOMLStrategy{}
 	case WATSON:
-		watsonED := &types.WatsonStrategy{}
-		encDec.SetStrategy(watsonED)
-		fileType = WATSON
+		return &types.WatsonStrategy{}
 	case MSGPACK:
-		msgpackED := &types.MsgPackStrategy{}
-		encDec.SetStrategy(msgpackED)
-		fileType = MSGPACK
+		return &types.MsgPackStrategy{}
+	case ARB:
+		return &types.ARBStrategy{}
+	case I18NEXT:
+		return &types.I18nextStrategy{}
 	default:
-		xmlED := &types.XMLStrategy{}
-		encDec.SetStrategy(xmlED)
-		fileType = XML
+		return &types.XMLStrategy{}
 	}
 }
 
 /*
 	Creates strings resource file in "strings" directory, throws an error otherwise.
 	Takes a FileType parameter to specify strings file format.
+	With a store other than the filesystem, the returned file is nil.
 */
 func CreateResourceFile(t types.FileType) (*os.File, error) {
 
@@ -145,9 +133,8 @@ func CreateResourceFile(t types.FileType) (*os.File, error) {
 
 	SetResourceType(t)
 
-	os.Mkdir("strings", os.ModePerm)
-
-	file, err := os.Create("strings/strings." + string(fileType))
+	path := ResourcePath("", fileType)
+	err := store.Put(path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -157,21 +144,26 @@ func CreateResourceFile(t types.FileType) (*os.File, error) {
 		return nil, err
 	}
 
-	return file, nil
+	s, ok := store.(*FSStore)
+	if !ok {
+		return nil, nil
+	}
+	return os.OpenFile(s.path(path), os.O_RDWR, 0)
 }
 
 /*
-	Deletes resource file if exists, throws an error otherwise.
+	Deletes resource file if exists, compressed or not, throws an error otherwise.
 	Uses setted resource file extension.
 */
 func DeleteResourceFile() error {
-	err := os.Remove("strings/strings." + string(fileType))
+	err := store.Delete(compressedPath(ResourcePath("", fileType)))
 	if err != nil {
 		return err
 	}
 
-	err = os.Remove("strings")
-	if err != nil {
+	// Stores without directories of their own, like MemoryStore, have already dropped it with its last file.
+	err = store.Delete(resourceDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
 
@@ -187,38 +179,23 @@ func NewString(name, value string) (types.String, error) {
 	}
 
 	if isDuplicateString(name) {
-		return *new(types.String), ErrorDuplicateStringName
+		return *new(types.String), fmt.Errorf("%w: %q", ErrorDuplicateStringName, name)
 	}
 
-	string_entries[name] = value
+	if messageFormat {
+		if _, err := defaultDictionary.message(name, value); err != nil {
+			return *new(types.String), parseError("", fileType, err)
+		}
+	}
 
-	var err error
+	editDefault(func() { string_entries[name] = value })
 
 	s := types.String{
 		Name:  name,
 		Value: value,
 	}
 
-	n := &types.Nesting{}
-
-	data, err = readBytes(strings.Join([]string{"./strings/strings.", string(fileType)}, ""))
-	if err != nil {
-		return *new(types.String), err
-	}
-
-	err = encDec.Decode(data, &n)
-	if err != nil {
-		return *new(types.String), err
-	}
-	n.Strings = append(n.Strings, &s)
-
-	data, err = encDec.Encode(n)
-
-	if err != nil {
-		return *new(types.String), err
-	}
-
-	err = writeBytes(strings.Join([]string{"./strings/strings.", string(fileType)}, ""), data)
+	err := editOrigin(resourceEdit{kind: KindString, name: name, value: &s})
 	if err != nil {
 		return *new(types.String), err
 	}
@@ -235,7 +212,7 @@ func NewStringArray(name string, values []string) (types.StringArray, error) {
 	}
 
 	if isDuplicateStringArray(name) {
-		return *new(types.StringArray), ErrorDuplicateStringArrayName
+		return *new(types.StringArray), fmt.Errorf("%w: %q", ErrorDuplicateStringArrayName, name)
 	}
 
 	sa := &types.StringArray{Name: name}
@@ -246,29 +223,9 @@ func NewStringArray(name string, values []string) (types.StringArray, error) {
 		sa.Items = append(sa.Items, item)
 	}
 
-	string_array_entries[name] = *sa
-
-	var err error
-
-	n := &types.Nesting{}
-
-	data, err = readBytes("./strings/strings." + string(fileType))
-	if err != nil {
-		return *new(types.StringArray), err
-	}
-
-	err = encDec.Decode(data, &n)
-	if err != nil {
-		return *new(types.StringArray), err
-	}
-	n.StringsArray = append(n.StringsArray, sa)
-
-	data, err = encDec.Encode(n)
-	if err != nil {
-		return *new(types.StringArray), err
-	}
+	editDefault(func() { string_array_entries[name] = *sa })
 
-	err = writeBytes("strings/strings."+string(fileType), data)
+	err := editOrigin(resourceEdit{kind: KindStringArray, name: name, value: sa})
 	if err != nil {
 		return *new(types.StringArray), err
 	}
@@ -288,15 +245,15 @@ func NewStringArray(name string, values []string) (types.StringArray, error) {
 */
 func NewQuantityString(name string, values []string) (types.Plural, error) {
 	if strings.TrimSpace(name) == "" {
-		return *new(types.Plural), ErrorEmptyStringArrayName
+		return *new(types.Plural), ErrorEmptyQuantityStringName
 	}
 
 	if len(values) == 0 {
-		return *new(types.Plural), ErrorQuantityStringEmptyValues
+		return *new(types.Plural), fmt.Errorf("%w: %q", ErrorQuantityStringEmptyValues, name)
 	}
 
 	if isDuplicateQuantityString(name) {
-		return *new(types.Plural), ErrorDuplicateQuantityStringName
+		return *new(types.Plural), fmt.Errorf("%w: %q", ErrorDuplicateQuantityStringName, name)
 	}
 
 	pl := &types.Plural{Name: name}
@@ -308,36 +265,169 @@ func NewQuantityString(name string, values []string) (types.Plural, error) {
 		pl.Items = append(pl.Items, item)
 	}
 
-	plural_string_entries[name] = *pl
-
-	var err error
+	editDefault(func() { plural_string_entries[name] = *pl })
 
-	n := &types.Nesting{}
-
-	data, err = readBytes("./strings/strings." + string(fileType))
+	err := editOrigin(resourceEdit{kind: KindPlurals, name: name, value: pl})
 	if err != nil {
 		return *new(types.Plural), err
 	}
 
-	err = encDec.Decode(data, &n)
+	return *pl, nil
+}
+
+/*
+	Replaces the value of a string resource in resource file. Throws an error if the string doesn't exist.
+*/
+func UpdateString(name, value string) (types.String, error) {
+	if !isDuplicateString(name) {
+		return *new(types.String), fmt.Errorf("%w: %s %q", ErrorNotFound, KindString, name)
+	}
+
+	defaultDictionary.rw.RLock()
+	s := types.String{
+		Name:  name,
+		Meta:  defaultDictionary.meta[name],
+		Value: value,
+	}
+	defaultDictionary.rw.RUnlock()
+
+	if messageFormat {
+		if _, err := icu.Parse(value); err != nil {
+			return *new(types.String), parseError("", fileType, &ParseError{Name: name, Err: err})
+		}
+	}
+
+	err := editOrigin(resourceEdit{kind: KindString, name: name, value: &s})
 	if err != nil {
-		return *new(types.Plural), err
+		return *new(types.String), err
 	}
-	n.Plurals = append(n.Plurals, pl)
 
-	data, err = encDec.Encode(n)
+	editDefault(func() { string_entries[name] = value })
+	defaultDictionary.forgetMessages(&types.Nesting{Strings: []*types.String{&s}})
+
+	return s, nil
+}
+
+/*
+	Replaces the values of a string-array resource in resource file. Throws an error if the string-array doesn't exist.
+*/
+func UpdateStringArray(name string, values []string) (types.StringArray, error) {
+	if !isDuplicateStringArray(name) {
+		return *new(types.StringArray), fmt.Errorf("%w: %s %q", ErrorNotFound, KindStringArray, name)
+	}
+
+	defaultDictionary.rw.RLock()
+	sa := &types.StringArray{Name: name, Meta: string_array_entries[name].Meta}
+	defaultDictionary.rw.RUnlock()
+	for i := 0; i < len(values); i++ {
+		sa.Items = append(sa.Items, &types.Item{Value: values[i]})
+	}
+
+	err := editOrigin(resourceEdit{kind: KindStringArray, name: name, value: sa})
 	if err != nil {
-		return *new(types.Plural), err
+		return *new(types.StringArray), err
 	}
 
-	err = writeBytes("strings/strings."+string(fileType), data)
+	editDefault(func() { string_array_entries[name] = *sa })
+
+	return *sa, nil
+}
+
+/*
+	Replaces the values of a quantity string resource in resource file. Throws an error if the quantity string
+	doesn't exist or values is empty. Values are assigned to quantities as in NewQuantityString.
+*/
+func UpdateQuantityString(name string, values []string) (types.Plural, error) {
+	if !isDuplicateQuantityString(name) {
+		return *new(types.Plural), fmt.Errorf("%w: %s %q", ErrorNotFound, KindPlurals, name)
+	}
+
+	if len(values) == 0 {
+		return *new(types.Plural), fmt.Errorf("%w: %q", ErrorQuantityStringEmptyValues, name)
+	}
+
+	defaultDictionary.rw.RLock()
+	pl := &types.Plural{Name: name, Meta: plural_string_entries[name].Meta}
+	defaultDictionary.rw.RUnlock()
+	for i := 0; i < len(values) && i < 5; i++ {
+		pl.Items = append(pl.Items, &types.PluralItem{Quantity: quantityValues[i], Value: values[i]})
+	}
+
+	err := editOrigin(resourceEdit{kind: KindPlurals, name: name, value: pl})
 	if err != nil {
 		return *new(types.Plural), err
 	}
 
+	editDefault(func() { plural_string_entries[name] = *pl })
+
 	return *pl, nil
 }
 
+// setQuantityString adds or replaces a quantity string of the default locale with the quantities of pl only,
+// where NewQuantityString and UpdateQuantityString assign values to quantities by position.
+func setQuantityString(pl *types.Plural) error {
+	if err := editOrigin(resourceEdit{kind: KindPlurals, name: pl.Name, value: pl}); err != nil {
+		return err
+	}
+	editDefault(func() { plural_string_entries[pl.Name] = *pl })
+	return nil
+}
+
+/*
+	Removes a string resource from resource file. Throws an error if the string doesn't exist.
+*/
+func RemoveString(name string) error {
+	if !isDuplicateString(name) {
+		return fmt.Errorf("%w: %s %q", ErrorNotFound, KindString, name)
+	}
+
+	if err := editOrigin(resourceEdit{kind: KindString, name: name}); err != nil {
+		return err
+	}
+
+	editDefault(func() {
+		delete(string_entries, name)
+		delete(defaultDictionary.meta, name)
+	})
+	defaultDictionary.forgetMessages(&types.Nesting{Strings: []*types.String{{Name: name}}})
+
+	return nil
+}
+
+/*
+	Removes a string-array resource from resource file. Throws an error if the string-array doesn't exist.
+*/
+func RemoveStringArray(name string) error {
+	if !isDuplicateStringArray(name) {
+		return fmt.Errorf("%w: %s %q", ErrorNotFound, KindStringArray, name)
+	}
+
+	if err := editOrigin(resourceEdit{kind: KindStringArray, name: name}); err != nil {
+		return err
+	}
+
+	editDefault(func() { delete(string_array_entries, name) })
+
+	return nil
+}
+
+/*
+	Removes a quantity string resource from resource file. Throws an error if the quantity string doesn't exist.
+*/
+func RemoveQuantityString(name string) error {
+	if !isDuplicateQuantityString(name) {
+		return fmt.Errorf("%w: %s %q", ErrorNotFound, KindPlurals, name)
+	}
+
+	if err := editOrigin(resourceEdit{kind: KindPlurals, name: name}); err != nil {
+		return err
+	}
+
+	editDefault(func() { delete(plural_string_entries, name) })
+
+	return nil
+}
+
 /*
 	Sets the threshold for "few" values in quantity strings.
 	When getting quantity strings values, the function checks if the given count is less OR EQUAL to this value.
@@ -351,110 +441,129 @@ func SetFewThreshold(value int) {
 	Returns the string resource's value with the given name. If not exists, returns empty string.
 */
 func GetString(name string) string {
-	if name == "" {
-		return ""
-	}
-	if val, ok := string_entries[name]; ok {
-		return val
-	}
-	return ""
+	return defaultLocalizer.GetString(name)
 }
 
 /*
 	Returns the string-array resource's values with the given name. If not exists, returns nil.
 */
 func GetArrayString(name string) []string {
-	if name == "" {
-		return nil
-	}
-
-	var arr []string
-
-	if _, ok := string_array_entries[name]; ok {
-		for i := 0; i < len(string_array_entries[name].Items); i++ {
-			item := string_array_entries[name].Items
-			el := item[i].Value
-			arr = append(arr, el)
-		}
-		return arr
-	}
-	return nil
+	return defaultLocalizer.GetArrayString(name)
 }
 
 /*
 	Returns the quantity string resource's corresponding string value based on the value of the given count parameter.
-	If the plural is not found, returns an empty string.
+	If the plural is not found, returns the missing handler's value (empty string by default); if it has no value
+	for the count's quantity, returns an empty string.
 */
 func GetQuantityString(name string, count int) string {
-	if name == "" {
-		return ""
-	}
+	return defaultLocalizer.GetQuantityString(name, count)
+}
 
-	idx := -1
+/*
+	Returns the string resource's value with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func LookupString(name string) (string, error) {
+	return defaultLocalizer.LookupString(name)
+}
 
-	val, exists := plural_string_entries[name]
+/*
+	Returns the string-array resource's values with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func LookupArrayString(name string) ([]string, error) {
+	return defaultLocalizer.LookupArrayString(name)
+}
 
-	if !exists {
-		return ""
-	}
+/*
+	Returns the quantity string resource's corresponding string value based on the value of the given count parameter.
+	If the plural is not found, returns an error wrapping ErrorNotFound;
+	if it has no value for the count's quantity, returns ErrorQuantityStringPluralNotFound.
+*/
+func LookupQuantityString(name string, count int) (string, error) {
+	return defaultLocalizer.LookupQuantityString(name, count)
+}
 
-	if count == 0 {
-		idx = 0
-	}
+// loadNesting copies the resources in n into the default locale's dictionary.
+func loadNesting(n *types.Nesting) {
+	defaultDictionary.load(n)
+}
 
-	if count == 1 {
-		idx = 1
+// readNesting decodes the resource file at path using the strategy for t.
+func readNesting(path string, t types.FileType) (*types.Nesting, error) {
+	d, err := store.Get(path)
+	if err != nil {
+		return nil, err
 	}
+	return decodeNesting(path, t, d)
+}
 
-	if count == 2 {
-		idx = 2
+// decodeNesting decodes the content of the resource file at path, decompressing it if needed.
+func decodeNesting(path string, t types.FileType, d []byte) (*types.Nesting, error) {
+	n := &types.Nesting{}
+
+	d, err := decompress(path, d)
+	if err != nil {
+		return nil, err
 	}
 
-	if count > 2 && count <= few_threshold {
-		idx = 3
+	ed := types.EncoderDecoder{}
+	ed.SetStrategy(strategyFor(t))
+	if err = ed.Decode(d, &n); err != nil {
+		return nil, parseError(path, t, err)
 	}
+	return n, nil
+}
 
-	if idx == -1 {
-		idx = 4
+// parseError sets the path and format of the ParseError in err, wrapping err in a new one if it has none.
+func parseError(path string, t types.FileType, err error) error {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		return &ParseError{Path: path, Format: t, Err: err}
 	}
 
-	for i := 0; i < len(val.Items); i++ {
-		if val.Items[i].Quantity == quantityValues[idx] {
-			return val.Items[i].Value
-		}
+	wrapped := *pe
+	wrapped.Path, wrapped.Format = path, t
+	return &wrapped
+}
+
+// writeNesting encodes n into the resource file at path using the strategy for t.
+func writeNesting(path string, t types.FileType, n *types.Nesting) error {
+	ed := types.EncoderDecoder{}
+	ed.SetStrategy(strategyFor(t))
+
+	d, err := ed.Encode(n)
+	if err != nil {
+		return err
 	}
-	return ""
+	return writeBytes(path, d)
 }
 
 func readBytes(path string) ([]byte, error) {
-	d, err := ioutil.ReadFile(path)
+	d, err := store.Get(path)
 	if err != nil {
 		return *new([]byte), err
 	}
-	return d, nil
+	return decompress(path, d)
 }
 
 func isDuplicateString(name string) bool {
-	if _, ok := string_entries[name]; ok {
-		return true
-	}
-	return false
+	return defaultDictionary.has(KindString, name)
 }
 
 func isDuplicateStringArray(name string) bool {
-	if _, ok := string_array_entries[name]; ok {
-		return true
-	}
-	return false
+	return defaultDictionary.has(KindStringArray, name)
 }
 
 func isDuplicateQuantityString(name string) bool {
-	if _, ok := plural_string_entries[name]; ok {
-		return true
-	}
-	return false
+	return defaultDictionary.has(KindPlurals, name)
 }
 
 func writeBytes(path string, data []byte) error {
-	return os.WriteFile(path, data, 0666)
+	data, err := compress(path, data)
+	if err != nil {
+		return err
+	}
+	return store.Put(path, data)
 }
@@ -2,12 +2,54 @@ package stres
 
 import (
 	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/Vinetwigs/stres/types"
 )
 
+func TestMain(m *testing.M) {
+	f, err := CreateResourceFile(XML)
+	if err != nil {
+		panic(err)
+	}
+	f.Close()
+
+	code := m.Run()
+
+	DeleteResourceFile()
+	os.Exit(code)
+}
+
+// inTempDir runs the rest of the test inside an empty temporary working directory.
+func inTempDir(t testing.TB) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// writeFile creates a file and its parent directories.
+func writeFile(t testing.TB, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestNewString(t *testing.T) {
 	type args struct {
 		name  string
@@ -25,7 +67,7 @@ func TestNewString(t *testing.T) {
 				name:  "new_string",
 				value: "value",
 			},
-			want: String{
+			want: types.String{
 				XMLName: xml.Name{},
 				Name:    "new_string",
 				Value:   "value",
@@ -38,7 +80,7 @@ func TestNewString(t *testing.T) {
 				name:  "",
 				value: "value",
 			},
-			want: String{
+			want: types.String{
 				XMLName: xml.Name{},
 				Name:    "",
 				Value:   "",
@@ -51,7 +93,7 @@ func TestNewString(t *testing.T) {
 				name:  "new_string",
 				value: "value_2",
 			},
-			want: String{
+			want: types.String{
 				XMLName: xml.Name{},
 				Name:    "",
 				Value:   "",
@@ -81,7 +123,7 @@ func TestNewStringArray(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
-		want    StringArray
+		want    types.StringArray
 		wantErr bool
 	}{
 		{
@@ -90,10 +132,10 @@ func TestNewStringArray(t *testing.T) {
 				name:   "new_string_array",
 				values: []string{"1", "2", "3", "4", "5"},
 			},
-			want: StringArray{
+			want: types.StringArray{
 				XMLName: xml.Name{},
 				Name:    "new_string_array",
-				Items: []*Item{
+				Items: []*types.Item{
 					{
 						XMLName: xml.Name{},
 						Value:   "1",
@@ -124,7 +166,7 @@ func TestNewStringArray(t *testing.T) {
 				name:   "",
 				values: []string{"1", "2", "3", "4", "5"},
 			},
-			want: StringArray{
+			want: types.StringArray{
 				XMLName: xml.Name{},
 				Name:    "",
 				Items:   nil,
@@ -137,7 +179,7 @@ func TestNewStringArray(t *testing.T) {
 				name:   "new_string_array",
 				values: []string{"1", "2", "3", "4", "5"},
 			},
-			want: StringArray{
+			want: types.StringArray{
 				XMLName: xml.Name{},
 				Name:    "",
 				Items:   nil,
@@ -167,7 +209,7 @@ func TestNewQuantityString(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
-		want    Plural
+		want    types.Plural
 		wantErr bool
 	}{
 		{
@@ -176,10 +218,10 @@ func TestNewQuantityString(t *testing.T) {
 				name:   "new_quantity_string",
 				values: []string{"zero", "one", "two"},
 			},
-			want: Plural{
+			want: types.Plural{
 				XMLName: xml.Name{},
 				Name:    "new_quantity_string",
-				Items: []*PluralItem{
+				Items: []*types.PluralItem{
 					{
 						XMLName:  xml.Name{},
 						Quantity: "zero",
@@ -205,10 +247,10 @@ func TestNewQuantityString(t *testing.T) {
 				name:   "new_quantity_string_two",
 				values: []string{"zero", "one", "two", "few", "many", "another", "another_one"},
 			},
-			want: Plural{
+			want: types.Plural{
 				XMLName: xml.Name{},
 				Name:    "new_quantity_string_two",
-				Items: []*PluralItem{
+				Items: []*types.PluralItem{
 					{
 						XMLName:  xml.Name{},
 						Quantity: "zero",
@@ -244,7 +286,7 @@ func TestNewQuantityString(t *testing.T) {
 				name:   "",
 				values: []string{"one"},
 			},
-			want: Plural{
+			want: types.Plural{
 				XMLName: xml.Name{},
 				Name:    "",
 				Items:   nil,
@@ -257,7 +299,7 @@ func TestNewQuantityString(t *testing.T) {
 				name:   "new_quantity_string",
 				values: []string{"one"},
 			},
-			want: Plural{
+			want: types.Plural{
 				XMLName: xml.Name{},
 				Name:    "",
 				Items:   nil,
@@ -270,10 +312,10 @@ func TestNewQuantityString(t *testing.T) {
 				name:   "new_quantity_string_three",
 				values: []string{"zero", "one", "two", "few", "many"},
 			},
-			want: Plural{
+			want: types.Plural{
 				XMLName: xml.Name{},
 				Name:    "new_quantity_string_three",
-				Items: []*PluralItem{
+				Items: []*types.PluralItem{
 					{
 						XMLName:  xml.Name{},
 						Quantity: "zero",
@@ -309,7 +351,7 @@ func TestNewQuantityString(t *testing.T) {
 				name:   "",
 				values: []string{"one"},
 			},
-			want: Plural{
+			want: types.Plural{
 				XMLName: xml.Name{},
 				Name:    "",
 				Items:   nil,
@@ -322,7 +364,7 @@ func TestNewQuantityString(t *testing.T) {
 				name:   "new_quantity_string",
 				values: []string{},
 			},
-			want: Plural{
+			want: types.Plural{
 				XMLName: xml.Name{},
 				Name:    "",
 				Items:   nil,
@@ -344,6 +386,48 @@ func TestNewQuantityString(t *testing.T) {
 	}
 }
 
+func TestNewQuantityString_errors(t *testing.T) {
+	if _, err := NewQuantityString(" ", []string{"one"}); !errors.Is(err, ErrorEmptyQuantityStringName) {
+		t.Errorf("NewQuantityString() error = %v, want %v", err, ErrorEmptyQuantityStringName)
+	}
+
+	if _, err := NewQuantityString("duplicate_quantity_string", []string{"one"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewQuantityString("duplicate_quantity_string", []string{"one"})
+	if !errors.Is(err, ErrorDuplicateQuantityStringName) {
+		t.Errorf("NewQuantityString() error = %v, want %v", err, ErrorDuplicateQuantityStringName)
+	}
+}
+
+func TestLoadValues_ParseError(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() {
+		SetResourceType(XML)
+		SetMessageFormat(false)
+	})
+
+	writeFile(t, "strings/strings.yml", "string:\n  - name: a\n    value: b\nplurals: c\n  items: d\n")
+
+	var pe *ParseError
+	if err := LoadValues(YAML); !errors.As(err, &pe) {
+		t.Fatalf("LoadValues() error = %v, want *ParseError", err)
+	}
+	if pe.Path != ResourcePath("", YAML) || pe.Format != YAML || pe.Line != 5 {
+		t.Errorf("LoadValues() error = %+v, want %s line 5", pe, ResourcePath("", YAML))
+	}
+
+	SetMessageFormat(true)
+	writeFile(t, "strings/strings.yml", "string:\n  - name: broken\n    value: \"{count, plural, one {#}\"\n")
+
+	if err := LoadValues(YAML); !errors.As(err, &pe) {
+		t.Fatalf("LoadValues() error = %v, want *ParseError", err)
+	}
+	if pe.Name != "broken" || pe.Format != YAML {
+		t.Errorf("LoadValues() error = %+v, want the name of the malformed message", pe)
+	}
+}
+
 func TestSetFewThreshold(t *testing.T) {
 	type args struct {
 		value int
@@ -0,0 +1,140 @@
+package stres
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// Android positional format specifiers ("%1$s") and their Go equivalent ("%[1]s").
+var positionalVerb = regexp.MustCompile(`%(\d+)\$`)
+
+// Styling tags allowed in template output, once escaped.
+var escapedStyleTag = regexp.MustCompile(`&lt;(/?)(b|i|u)&gt;`)
+var styleTag = regexp.MustCompile(`</?(b|i|u)>`)
+
+/*
+	Returns template functions for the default resources, usable with both text/template and html/template:
+
+	str name               string resource
+	strf name args...      string resource formatted with args ("%1$s" or "%s" specifiers)
+	plural name count ...  quantity string resource formatted with args (count when none given)
+	array name             string-array resource
+
+	Values are escaped by html/template, except values containing <b>, <i> and <u> styling tags,
+	which are sanitised and emitted as template.HTML.
+*/
+func FuncMap() map[string]interface{} {
+	return Locale("").FuncMap()
+}
+
+/*
+	Returns template functions bound to the localizer's locale. See FuncMap.
+*/
+func (l *Localizer) FuncMap() map[string]interface{} {
+	return map[string]interface{}{
+		"str": func(name string) interface{} {
+			return templateValue(l.GetString(name))
+		},
+		"strf": func(name string, args ...interface{}) interface{} {
+			return templateFormat(l.GetString(name), args)
+		},
+		"plural": func(name string, count int, args ...interface{}) interface{} {
+			value := l.GetQuantityString(name, count)
+			if len(args) == 0 && strings.Contains(value, "%") {
+				args = []interface{}{count}
+			}
+			return templateFormat(value, args)
+		},
+		"array": func(name string) []interface{} {
+			arr := l.GetArrayString(name)
+			values := make([]interface{}, len(arr))
+			for i := range arr {
+				values[i] = templateValue(arr[i])
+			}
+			return values
+		},
+	}
+}
+
+// templateValue returns styled values as sanitised HTML and any other value as a plain string.
+func templateValue(value string) interface{} {
+	if !styleTag.MatchString(value) {
+		return value
+	}
+	return template.HTML(sanitizeStyle(value))
+}
+
+// templateFormat formats value with args, escaping the arguments of styled values.
+func templateFormat(value string, args []interface{}) interface{} {
+	if len(args) == 0 {
+		return templateValue(value)
+	}
+
+	format := positionalVerb.ReplaceAllString(value, "%[$1]")
+	if !styleTag.MatchString(value) {
+		return fmt.Sprintf(format, args...)
+	}
+
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		escaped[i] = escapeArg(arg)
+	}
+	return template.HTML(fmt.Sprintf(sanitizeStyle(format), escaped...))
+}
+
+// escapeArg returns numbers and bools as they are, for numeric verbs, and the escaped text of any other value.
+func escapeArg(arg interface{}) interface{} {
+	switch arg := arg.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128, bool:
+		return arg
+	case []byte:
+		return html.EscapeString(string(arg))
+	}
+	return html.EscapeString(fmt.Sprint(arg))
+}
+
+// sanitizeStyle escapes value keeping only balanced <b>, <i> and <u> tags without attributes.
+func sanitizeStyle(value string) string {
+	escaped := html.EscapeString(value)
+
+	b := strings.Builder{}
+	var open []string
+	last := 0
+	for _, m := range escapedStyleTag.FindAllStringSubmatchIndex(escaped, -1) {
+		b.WriteString(escaped[last:m[0]])
+		last = m[1]
+
+		closing, tag := escaped[m[2]:m[3]] == "/", escaped[m[4]:m[5]]
+		if !closing {
+			open = append(open, tag)
+			b.WriteString("<" + tag + ">")
+			continue
+		}
+
+		// Close the tags opened after the one being closed, drop unmatched closing tags.
+		idx := -1
+		for i := len(open) - 1; i >= 0; i-- {
+			if open[i] == tag {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			continue
+		}
+		for i := len(open) - 1; i >= idx; i-- {
+			b.WriteString("</" + open[i] + ">")
+		}
+		open = open[:idx]
+	}
+	b.WriteString(escaped[last:])
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
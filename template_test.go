@@ -0,0 +1,103 @@
+package stres
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+)
+
+type scriptStringer struct{}
+
+func (scriptStringer) String() string { return "<script>alert(1)</script>" }
+
+func TestFuncMap(t *testing.T) {
+	NewString("template_plain", "Tom & Jerry")
+	NewString("template_styled", "<b>Hello</b> %1$s<script>")
+	NewString("template_unbalanced", "<i>open <b>bold</i> </u>")
+	NewQuantityString("template_files", []string{"no files", "%d file", "%d files", "%d files", "%d files"})
+	NewStringArray("template_days", []string{"Mon", "<u>Tue</u>"})
+	NewString("template_count", "<b>%d</b> items by %s")
+
+	tests := []struct {
+		name string
+		tmpl string
+		data interface{}
+		want string
+	}{
+		{
+			name: "str_plain",
+			tmpl: `{{str "template_plain"}}`,
+			want: "Tom &amp; Jerry",
+		},
+		{
+			name: "strf_styled",
+			tmpl: `{{strf "template_styled" "<Ann>"}}`,
+			want: "<b>Hello</b> &lt;Ann&gt;&lt;script&gt;",
+		},
+		{
+			name: "strf_styled_stringer",
+			tmpl: `{{strf "template_styled" .}}`,
+			data: scriptStringer{},
+			want: "<b>Hello</b> &lt;script&gt;alert(1)&lt;/script&gt;&lt;script&gt;",
+		},
+		{
+			name: "strf_styled_number",
+			tmpl: `{{strf "template_count" 3 .}}`,
+			data: []byte("<i>x</i>"),
+			want: "<b>3</b> items by &lt;i&gt;x&lt;/i&gt;",
+		},
+		{
+			name: "str_unbalanced",
+			tmpl: `{{str "template_unbalanced"}}`,
+			want: "<i>open <b>bold</b></i> ",
+		},
+		{
+			name: "plural",
+			tmpl: `{{plural "template_files" 1}}, {{plural "template_files" 0}}`,
+			want: "1 file, no files",
+		},
+		{
+			name: "array",
+			tmpl: `{{range array "template_days"}}[{{.}}]{{end}}`,
+			want: "[Mon][<u>Tue</u>]",
+		},
+		{
+			name: "missing",
+			tmpl: `{{str "template_missing"}}`,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := htmltemplate.Must(htmltemplate.New(tt.name).Funcs(FuncMap()).Parse(tt.tmpl))
+			b := strings.Builder{}
+			if err := tmpl.Execute(&b, tt.data); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizer_FuncMap(t *testing.T) {
+	NewString("template_greeting", "Hello %s")
+	NewString("template_fallback", "Tom & Jerry")
+
+	inTempDir(t)
+	writeFile(t, "strings-it/strings.xml", `<resources><string name="template_greeting">Ciao %s</string></resources>`)
+	if err := LoadLocale("it", XML); err != nil {
+		t.Fatalf("LoadLocale() error = %v", err)
+	}
+
+	tmpl := texttemplate.Must(texttemplate.New("greeting").Funcs(Locale("it").FuncMap()).Parse(`{{strf "template_greeting" "Ann"}} {{str "template_fallback"}}`))
+	b := strings.Builder{}
+	if err := tmpl.Execute(&b, nil); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got, want := b.String(), "Ciao Ann Tom & Jerry"; got != want {
+		t.Errorf("Execute() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package stres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+// Tag of the resources filled by AutoTranslate, to be reviewed by a translator.
+const MachineTranslatedTag = "machine-translated"
+
+var ErrorTranslationPlaceholders error = errors.New("stres: machine translation altered protected placeholders or markup")
+
+/*
+	A machine-translation provider. Translate returns the text src translated from one language to another.
+	Placeholders and markup are replaced with tags like <x id="0"/> before calling it, which must be kept.
+*/
+type Translator interface {
+	Translate(ctx context.Context, src, from, to string) (string, error)
+}
+
+/*
+	Deterministic Translator for tests, prefixing the text with the target language (e.g. "[it] Hello").
+*/
+type FakeTranslator struct{}
+
+func (FakeTranslator) Translate(ctx context.Context, src, from, to string) (string, error) {
+	return "[" + to + "] " + src, nil
+}
+
+// protectedTag matches the tags replacing protected parts of a value sent to a Translator.
+var protectedTag = regexp.MustCompile(`<x id="(\d+)"\s*/>`)
+
+/*
+	Fills the translatable values of the default resources missing in the given locale with machine translations
+	from the language from, leaving existing translations untouched. Placeholders, markup and ICU syntax are
+	protected from the translator; in values marked formatted="false", '%' is plain text. Filled resources are tagged MachineTranslatedTag in their metadata.
+	Returns the added values; if dryRun is true they are only computed and no file is written.
+	Uses setted resource file extension.
+*/
+func AutoTranslate(ctx context.Context, tr Translator, from, locale string, dryRun bool) ([]Change, error) {
+	def, _, err := readResources("", fileType)
+	if err != nil {
+		return nil, err
+	}
+	n, origins, err := readResources(locale, fileType)
+	if errors.Is(err, os.ErrNotExist) {
+		n, origins, err = &types.Nesting{}, nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := newDictionary()
+	d.load(n)
+	changes := untranslated(def, d, locale)
+
+	var edits []resourceEdit
+	edited := map[resourceKey]bool{}
+	for i := range changes {
+		c := &changes[i]
+		formatted := resourceMeta(findResource(def, c.Kind, c.Name)).IsFormatted()
+		if c.New, err = translate(ctx, tr, c.New, from, locale, formatted); err != nil {
+			return nil, fmt.Errorf("%w: %s %q", err, c.Kind, c.Name)
+		}
+
+		var meta *types.Meta
+		switch c.Kind {
+		case KindString:
+			setString(n, c.Name, c.New)
+			meta = &findString(n, c.Name).Meta
+		case KindStringArray:
+			idx, _ := strconv.Atoi(c.Key)
+			if _, _, err = setArrayItem(n, c.Name, idx, c.New); err != nil {
+				return nil, err
+			}
+			meta = &findStringArray(n, c.Name).Meta
+		case KindPlurals:
+			setPluralItem(n, c.Name, c.Key, c.New)
+			meta = &findPlural(n, c.Name).Meta
+		}
+		if !hasTag(meta.Tags, MachineTranslatedTag) {
+			meta.Tags = append(meta.Tags, MachineTranslatedTag)
+		}
+
+		if k := (resourceKey{c.Kind, c.Name}); !edited[k] {
+			edited[k] = true
+			edits = append(edits, resourceEdit{kind: c.Kind, name: c.Name})
+		}
+	}
+
+	if dryRun || len(edits) == 0 {
+		return changes, nil
+	}
+
+	for i := range edits {
+		edits[i].value = findResource(n, edits[i].kind, edits[i].name)
+	}
+	if _, err = writeEdits(locale, origins, edits); err != nil {
+		return nil, err
+	}
+	return changes, reloadLocale(locale)
+}
+
+// translate translates value with its placeholders, markup and ICU syntax protected.
+// Values not formatted have no placeholders.
+func translate(ctx context.Context, tr Translator, value, from, to string, formatted bool) (string, error) {
+	text, protected := protect(value, formatted)
+	if strings.TrimSpace(protectedTag.ReplaceAllString(text, "")) == "" {
+		return value, nil
+	}
+
+	translated, err := tr.Translate(ctx, text, from, to)
+	if err != nil {
+		return "", err
+	}
+	return unprotect(translated, protected)
+}
+
+// protect replaces the parts of value which must not be translated with numbered tags, returned in order.
+// Format specifiers are protected only if formatted is true.
+func protect(value string, formatted bool) (string, []string) {
+	segments := pseudoSegments(value)
+	if !formatted {
+		segments = segmentsOf(value, pseudoProtectedUnformatted)
+	}
+
+	b := strings.Builder{}
+	var protected []string
+	for _, seg := range segments {
+		if seg.translatable {
+			b.WriteString(seg.text)
+			continue
+		}
+		fmt.Fprintf(&b, `<x id="%d"/>`, len(protected))
+		protected = append(protected, seg.text)
+	}
+	return b.String(), protected
+}
+
+// unprotect restores the protected parts of a translated text, each of whose tags must appear exactly once.
+func unprotect(text string, protected []string) (string, error) {
+	seen := make([]bool, len(protected))
+	var err error
+	out := protectedTag.ReplaceAllStringFunc(text, func(tag string) string {
+		i, _ := strconv.Atoi(protectedTag.FindStringSubmatch(tag)[1])
+		if i >= len(protected) || seen[i] {
+			err = ErrorTranslationPlaceholders
+			return tag
+		}
+		seen[i] = true
+		return protected[i]
+	})
+	for _, ok := range seen {
+		if !ok {
+			err = ErrorTranslationPlaceholders
+		}
+	}
+	return out, err
+}
+
+func hasTag(tags types.Tags, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+package stres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+// dropTranslator loses the protected tags.
+type dropTranslator struct{}
+
+func (dropTranslator) Translate(ctx context.Context, src, from, to string) (string, error) {
+	return protectedTag.ReplaceAllString(src, ""), nil
+}
+
+func TestProtect(t *testing.T) {
+	tests := []struct {
+		value     string
+		text      string
+		protected []string
+	}{
+		{"Hello %1$s, <b>welcome</b>!", `Hello <x id="0"/>, <x id="1"/>welcome<x id="2"/>!`, []string{"%1$s", "<b>", "</b>"}},
+		{"{count, plural, one {# file} other {# files}}", `<x id="0"/> file<x id="1"/> files<x id="2"/>`, []string{"{count, plural, one {#", "} other {#", "}}"}},
+		{"No placeholders", "No placeholders", nil},
+	}
+	for _, tt := range tests {
+		text, protected := protect(tt.value, true)
+		if text != tt.text || !reflect.DeepEqual(protected, tt.protected) {
+			t.Errorf("protect(%q) = %q, %q, want %q, %q", tt.value, text, protected, tt.text, tt.protected)
+		}
+		if value, err := unprotect(text, protected); err != nil || value != tt.value {
+			t.Errorf("unprotect(%q) = %q, %v, want %q", text, value, err, tt.value)
+		}
+	}
+
+	if text, protected := protect("100% natural, <b>50%s</b>", false); text != `100% natural, <x id="0"/>50%s<x id="1"/>` || len(protected) != 2 {
+		t.Errorf("protect() of a value not formatted = %q, %q, want only the markup protected", text, protected)
+	}
+
+	if _, err := unprotect(`Hallo`, []string{"%s"}); !errors.Is(err, ErrorTranslationPlaceholders) {
+		t.Errorf("unprotect() of a lost tag error = %v, want %v", err, ErrorTranslationPlaceholders)
+	}
+	if _, err := unprotect(`<x id="0"/> <x id="0"/>`, []string{"%s"}); !errors.Is(err, ErrorTranslationPlaceholders) {
+		t.Errorf("unprotect() of a repeated tag error = %v, want %v", err, ErrorTranslationPlaceholders)
+	}
+}
+
+func TestAutoTranslate(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetResourceType(XML) })
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="auto_brand" translatable="false">stres</string>
+	<string name="auto_greeting">Hello %1$s</string>
+	<string name="auto_title">Title</string>
+	<string-array name="auto_days">
+		<item>Monday</item>
+		<item>Tuesday</item>
+	</string-array>
+	<plurals name="auto_files">
+		<item quantity="one">%d file</item>
+		<item quantity="many">%d files</item>
+	</plurals>
+</resources>`)
+	writeFile(t, "strings-de/strings.xml", `<resources>
+	<string name="auto_title">Titel</string>
+	<string-array name="auto_days">
+		<item>Montag</item>
+	</string-array>
+</resources>`)
+	SetResourceType(XML)
+	if err := LoadLocale("de", XML); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := AutoTranslate(ctx, dropTranslator{}, "en", "de", false); !errors.Is(err, ErrorTranslationPlaceholders) {
+		t.Errorf("AutoTranslate() losing placeholders error = %v, want %v", err, ErrorTranslationPlaceholders)
+	}
+
+	before, _ := os.ReadFile("strings-de/strings.xml")
+	changes, err := AutoTranslate(ctx, FakeTranslator{}, "en", "de", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile("strings-de/strings.xml"); string(after) != string(before) {
+		t.Error("AutoTranslate() dry run wrote the resource file")
+	}
+
+	want := []Change{
+		{Type: ChangeAdded, Locale: "de", Kind: KindString, Name: "auto_greeting", New: "[de] Hello %1$s"},
+		{Type: ChangeAdded, Locale: "de", Kind: KindStringArray, Name: "auto_days", Key: "1", New: "[de] Tuesday"},
+		{Type: ChangeAdded, Locale: "de", Kind: KindPlurals, Name: "auto_files", Key: "one", New: "[de] %d file"},
+		{Type: ChangeAdded, Locale: "de", Kind: KindPlurals, Name: "auto_files", Key: "many", New: "[de] %d files"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("AutoTranslate() = %+v, want %+v", changes, want)
+	}
+
+	if _, err = AutoTranslate(ctx, FakeTranslator{}, "en", "de", false); err != nil {
+		t.Fatal(err)
+	}
+	l := Locale("de")
+	if got := l.GetString("auto_title"); got != "Titel" {
+		t.Errorf("GetString() of an existing translation = %q, want Titel", got)
+	}
+	if got := l.GetString("auto_greeting"); got != "[de] Hello %1$s" {
+		t.Errorf("GetString() = %q", got)
+	}
+	if got := l.GetArrayString("auto_days"); !reflect.DeepEqual(got, []string{"Montag", "[de] Tuesday"}) {
+		t.Errorf("GetArrayString() = %q", got)
+	}
+	if got := l.GetQuantityString("auto_files", 1); got != "[de] %d file" {
+		t.Errorf("GetQuantityString() = %q", got)
+	}
+
+	data, _ := os.ReadFile("strings-de/strings.xml")
+	for _, s := range []string{
+		`<string name="auto_greeting" tags="machine-translated">`,
+		`<string-array name="auto_days" tags="machine-translated">`,
+		`<plurals name="auto_files" tags="machine-translated">`,
+		`<string name="auto_title">Titel</string>`,
+	} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("resource file doesn't contain %s:\n%s", s, data)
+		}
+	}
+	if strings.Contains(string(data), "auto_brand") {
+		t.Error("AutoTranslate() translated an untranslatable resource")
+	}
+
+	if changes, _ = AutoTranslate(ctx, FakeTranslator{}, "en", "de", false); len(changes) != 0 {
+		t.Errorf("AutoTranslate() of a translated locale = %+v, want none", changes)
+	}
+}
+
+func TestAutoTranslate_preserveFormat(t *testing.T) {
+	inTempDir(t)
+	SetPreserveFormat(true)
+	t.Cleanup(func() {
+		SetPreserveFormat(false)
+		SetResourceType(XML)
+	})
+
+	tests := []struct {
+		name     string
+		t        types.FileType
+		def      string
+		locale   string
+		contains []string
+	}{
+		{
+			name: "test_xml",
+			t:    XML,
+			def: `<resources>
+	<string name="preserve_title">Title</string>
+	<string-array name="preserve_days">
+		<item>Monday</item>
+		<item>Tuesday</item>
+	</string-array>
+	<plurals name="preserve_files">
+		<item quantity="one">%d file</item>
+		<item quantity="many">%d files</item>
+	</plurals>
+</resources>`,
+			locale: `<resources>
+	<!-- Reviewed -->
+	<string name="preserve_title">Titel</string>
+	<string-array name="preserve_days">
+		<item>Montag</item>
+	</string-array>
+	<plurals name="preserve_files">
+		<item quantity="one">%d Datei</item>
+	</plurals>
+</resources>`,
+			contains: []string{
+				"<!-- Reviewed -->",
+				`<string name="preserve_title">Titel</string>`,
+				`<string-array name="preserve_days" tags="machine-translated">`,
+				`<plurals name="preserve_files" tags="machine-translated">`,
+			},
+		},
+		{
+			name: "test_yaml",
+			t:    YAML,
+			def: `string:
+  - name: preserve_title
+    value: Title
+  - name: preserve_greeting
+    value: Hello
+`,
+			locale: `# Reviewed
+string:
+  - name: preserve_title
+    value: Titel
+`,
+			contains: []string{
+				"# Reviewed",
+				"- name: preserve_greeting\n    tags: [machine-translated]\n    value: '[de] Hello'",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := string(tt.t)
+			writeFile(t, "strings/strings."+ext, tt.def)
+			writeFile(t, "strings-de/strings."+ext, tt.locale)
+			t.Cleanup(func() {
+				os.RemoveAll("strings")
+				os.RemoveAll("strings-de")
+			})
+			SetResourceType(tt.t)
+
+			if _, err := AutoTranslate(context.Background(), FakeTranslator{}, "en", "de", false); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile("strings-de/strings." + ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(string(data), s) {
+					t.Errorf("resource file doesn't contain %s:\n%s", s, data)
+				}
+			}
+		})
+	}
+}
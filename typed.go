@@ -0,0 +1,286 @@
+package stres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Vinetwigs/stres/types"
+)
+
+/*
+	Adds a new integer resource to resource file. Throws an error if the chosen name is already inserted or it is an empty string.
+*/
+func NewInteger(name string, value int) (types.Integer, error) {
+	if strings.TrimSpace(name) == "" {
+		return *new(types.Integer), ErrorEmptyIntegerName
+	}
+
+	if defaultDictionary.has(KindInteger, name) {
+		return *new(types.Integer), fmt.Errorf("%w: %q", ErrorDuplicateIntegerName, name)
+	}
+
+	i := &types.Integer{Name: name, Value: value}
+
+	err := editOrigin(resourceEdit{kind: KindInteger, name: name, value: i})
+	if err != nil {
+		return *new(types.Integer), err
+	}
+
+	editDefault(func() { integer_entries[name] = *i })
+
+	return *i, nil
+}
+
+/*
+	Adds a new bool resource to resource file. Throws an error if the chosen name is already inserted or it is an empty string.
+*/
+func NewBool(name string, value bool) (types.Bool, error) {
+	if strings.TrimSpace(name) == "" {
+		return *new(types.Bool), ErrorEmptyBoolName
+	}
+
+	if defaultDictionary.has(KindBool, name) {
+		return *new(types.Bool), fmt.Errorf("%w: %q", ErrorDuplicateBoolName, name)
+	}
+
+	b := &types.Bool{Name: name, Value: value}
+
+	err := editOrigin(resourceEdit{kind: KindBool, name: name, value: b})
+	if err != nil {
+		return *new(types.Bool), err
+	}
+
+	editDefault(func() { bool_entries[name] = *b })
+
+	return *b, nil
+}
+
+/*
+	Adds a new integer-array resource to resource file. Throws an error if the chosen name is already inserted or it is an empty string.
+*/
+func NewIntegerArray(name string, values []int) (types.IntegerArray, error) {
+	if strings.TrimSpace(name) == "" {
+		return *new(types.IntegerArray), ErrorEmptyIntegerArrayName
+	}
+
+	if defaultDictionary.has(KindIntegerArray, name) {
+		return *new(types.IntegerArray), fmt.Errorf("%w: %q", ErrorDuplicateIntegerArrayName, name)
+	}
+
+	ia := &types.IntegerArray{Name: name}
+	for _, v := range values {
+		ia.Items = append(ia.Items, &types.IntegerItem{Value: v})
+	}
+
+	err := editOrigin(resourceEdit{kind: KindIntegerArray, name: name, value: ia})
+	if err != nil {
+		return *new(types.IntegerArray), err
+	}
+
+	editDefault(func() { integer_array_entries[name] = *ia })
+
+	return *ia, nil
+}
+
+/*
+	Adds a new typed array resource to resource file. Items are values or references to other resources
+	(e.g. "@drawable/icon"). Throws an error if the chosen name is already inserted or it is an empty string.
+*/
+func NewArray(name string, values []string) (types.Array, error) {
+	if strings.TrimSpace(name) == "" {
+		return *new(types.Array), ErrorEmptyArrayName
+	}
+
+	if defaultDictionary.has(KindArray, name) {
+		return *new(types.Array), fmt.Errorf("%w: %q", ErrorDuplicateArrayName, name)
+	}
+
+	a := &types.Array{Name: name}
+	for _, v := range values {
+		a.Items = append(a.Items, &types.Item{Value: v})
+	}
+
+	err := editOrigin(resourceEdit{kind: KindArray, name: name, value: a})
+	if err != nil {
+		return *new(types.Array), err
+	}
+
+	editDefault(func() { typed_array_entries[name] = *a })
+
+	return *a, nil
+}
+
+/*
+	Returns the integer resource's value with the given name. If not exists, returns the missing handler's value
+	when it's an integer, 0 otherwise.
+*/
+func GetInt(name string) int {
+	return defaultLocalizer.GetInt(name)
+}
+
+/*
+	Returns the bool resource's value with the given name. If not exists, returns the missing handler's value
+	when it's a bool, false otherwise.
+*/
+func GetBool(name string) bool {
+	return defaultLocalizer.GetBool(name)
+}
+
+/*
+	Returns the integer-array resource's values with the given name. If not exists, returns nil
+	or the missing handler's value as only item when it's an integer.
+*/
+func GetIntArray(name string) []int {
+	return defaultLocalizer.GetIntArray(name)
+}
+
+/*
+	Returns the typed array resource's items with the given name. If not exists, returns nil
+	or the missing handler's value as only item.
+*/
+func GetArray(name string) []string {
+	return defaultLocalizer.GetArray(name)
+}
+
+/*
+	Returns the integer resource's value with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func LookupInt(name string) (int, error) {
+	return defaultLocalizer.LookupInt(name)
+}
+
+/*
+	Returns the bool resource's value with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func LookupBool(name string) (bool, error) {
+	return defaultLocalizer.LookupBool(name)
+}
+
+/*
+	Returns the integer-array resource's values with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func LookupIntArray(name string) ([]int, error) {
+	return defaultLocalizer.LookupIntArray(name)
+}
+
+/*
+	Returns the typed array resource's items with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func LookupArray(name string) ([]string, error) {
+	return defaultLocalizer.LookupArray(name)
+}
+
+/*
+	Returns the integer resource's value with the given name. If not exists, returns the missing handler's value
+	when it's an integer, 0 otherwise.
+*/
+func (l *Localizer) GetInt(name string) int {
+	val, err := l.LookupInt(name)
+	if err != nil {
+		val, _ = strconv.Atoi(l.missing(KindInteger, name))
+	}
+	return val
+}
+
+/*
+	Returns the bool resource's value with the given name. If not exists, returns the missing handler's value
+	when it's a bool, false otherwise.
+*/
+func (l *Localizer) GetBool(name string) bool {
+	val, err := l.LookupBool(name)
+	if err != nil {
+		val, _ = strconv.ParseBool(l.missing(KindBool, name))
+	}
+	return val
+}
+
+/*
+	Returns the integer-array resource's values with the given name. If not exists, returns nil
+	or the missing handler's value as only item when it's an integer.
+*/
+func (l *Localizer) GetIntArray(name string) []int {
+	arr, err := l.LookupIntArray(name)
+	if err != nil {
+		if val, err := strconv.Atoi(l.missing(KindIntegerArray, name)); err == nil {
+			return []int{val}
+		}
+		return nil
+	}
+	return arr
+}
+
+/*
+	Returns the typed array resource's items with the given name. If not exists, returns nil
+	or the missing handler's value as only item.
+*/
+func (l *Localizer) GetArray(name string) []string {
+	arr, err := l.LookupArray(name)
+	if err != nil {
+		if val := l.missing(KindArray, name); val != "" {
+			return []string{val}
+		}
+		return nil
+	}
+	return arr
+}
+
+/*
+	Returns the integer resource's value with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (l *Localizer) LookupInt(name string) (int, error) {
+	if val, ok := l.dict.getInt(name); ok {
+		return val, nil
+	}
+	if val, ok := defaultDictionary.getInt(name); ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("%w: %s %q", ErrorNotFound, KindInteger, name)
+}
+
+/*
+	Returns the bool resource's value with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (l *Localizer) LookupBool(name string) (bool, error) {
+	if val, ok := l.dict.getBool(name); ok {
+		return val, nil
+	}
+	if val, ok := defaultDictionary.getBool(name); ok {
+		return val, nil
+	}
+	return false, fmt.Errorf("%w: %s %q", ErrorNotFound, KindBool, name)
+}
+
+/*
+	Returns the integer-array resource's values with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (l *Localizer) LookupIntArray(name string) ([]int, error) {
+	if arr, ok := l.dict.getIntArray(name); ok {
+		return arr, nil
+	}
+	if arr, ok := defaultDictionary.getIntArray(name); ok {
+		return arr, nil
+	}
+	return nil, fmt.Errorf("%w: %s %q", ErrorNotFound, KindIntegerArray, name)
+}
+
+/*
+	Returns the typed array resource's items with the given name.
+	If not exists, returns an error wrapping ErrorNotFound.
+*/
+func (l *Localizer) LookupArray(name string) ([]string, error) {
+	if arr, ok := l.dict.getArray(name); ok {
+		return arr, nil
+	}
+	if arr, ok := defaultDictionary.getArray(name); ok {
+		return arr, nil
+	}
+	return nil, fmt.Errorf("%w: %s %q", ErrorNotFound, KindArray, name)
+}
@@ -0,0 +1,181 @@
+package stres
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypedResources(t *testing.T) {
+	inTempDir(t)
+	t.Cleanup(func() { SetPreserveFormat(false) })
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<string name="typed_app_name">stres</string>
+	<integer name="typed_max_retries">3</integer>
+	<bool name="typed_beta">true</bool>
+	<integer-array name="typed_limits">
+		<item>10</item>
+		<item>20</item>
+	</integer-array>
+	<array name="typed_icons">
+		<item>@drawable/home</item>
+	</array>
+</resources>`)
+	writeFile(t, "strings-land/strings.xml", `<resources>
+	<integer name="typed_max_retries">5</integer>
+</resources>`)
+
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLocale("land", XML); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := GetInt("typed_max_retries"); got != 3 {
+		t.Errorf("GetInt() = %d, want 3", got)
+	}
+	if got := Locale("land").GetInt("typed_max_retries"); got != 5 {
+		t.Errorf("Locale(land).GetInt() = %d, want 5", got)
+	}
+	if !GetBool("typed_beta") || !Locale("land").GetBool("typed_beta") {
+		t.Errorf("GetBool() = false, want true")
+	}
+	if got := GetIntArray("typed_limits"); !reflect.DeepEqual(got, []int{10, 20}) {
+		t.Errorf("GetIntArray() = %v, want [10 20]", got)
+	}
+	if got := GetArray("typed_icons"); !reflect.DeepEqual(got, []string{"@drawable/home"}) {
+		t.Errorf("GetArray() = %v, want [@drawable/home]", got)
+	}
+	if _, err := LookupInt("typed_missing"); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("LookupInt() error = %v, want %v", err, ErrorNotFound)
+	}
+
+	SetPreserveFormat(true)
+	if _, err := NewInteger("typed_timeout", 30); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBool("typed_dark_mode", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIntegerArray("typed_steps", []int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewArray("typed_colors", []string{"@color/red"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `<resources>
+	<string name="typed_app_name">stres</string>
+	<integer name="typed_max_retries">3</integer>
+	<integer name="typed_timeout">30</integer>
+	<bool name="typed_beta">true</bool>
+	<bool name="typed_dark_mode">false</bool>
+	<integer-array name="typed_limits">
+		<item>10</item>
+		<item>20</item>
+	</integer-array>
+	<integer-array name="typed_steps">
+		<item>1</item>
+		<item>2</item>
+	</integer-array>
+	<array name="typed_icons">
+		<item>@drawable/home</item>
+	</array>
+	<array name="typed_colors">
+		<item>@color/red</item>
+	</array>
+</resources>`
+	if got, _ := os.ReadFile("strings/strings.xml"); string(got) != want {
+		t.Errorf("New*() wrote\n%s\nwant\n%s", got, want)
+	}
+	if GetInt("typed_timeout") != 30 {
+		t.Errorf("GetInt() = %d after NewInteger(), want 30", GetInt("typed_timeout"))
+	}
+
+	if _, err := NewInteger("typed_timeout", 60); !errors.Is(err, ErrorDuplicateIntegerName) {
+		t.Errorf("NewInteger() error = %v, want %v", err, ErrorDuplicateIntegerName)
+	}
+	if _, err := NewBool(" ", true); !errors.Is(err, ErrorEmptyBoolName) {
+		t.Errorf("NewBool() error = %v, want %v", err, ErrorEmptyBoolName)
+	}
+}
+
+func TestTypedResources_references(t *testing.T) {
+	inTempDir(t)
+
+	writeFile(t, "strings/strings.xml", `<resources>
+	<integer name="ref_max">8</integer>
+	<integer name="ref_retries">@integer/ref_max</integer>
+	<integer name="ref_framework">@android:integer/config_shortAnimTime</integer>
+	<integer name="ref_loop">@integer/ref_loop</integer>
+	<bool name="ref_tablet">true</bool>
+	<bool name="ref_two_panes">@bool/ref_tablet</bool>
+	<integer-array name="ref_limits">
+		<item>1</item>
+		<item>@integer/ref_max</item>
+	</integer-array>
+</resources>`)
+
+	if err := LoadValues(XML); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetInt("ref_retries"); got != 8 {
+		t.Errorf("GetInt() = %d, want 8", got)
+	}
+	if !GetBool("ref_two_panes") {
+		t.Errorf("GetBool() = false, want true")
+	}
+	if got := GetIntArray("ref_limits"); !reflect.DeepEqual(got, []int{1, 8}) {
+		t.Errorf("GetIntArray() = %v, want [1 8]", got)
+	}
+	for _, name := range []string{"ref_framework", "ref_loop"} {
+		if _, err := LookupInt(name); !errors.Is(err, ErrorNotFound) {
+			t.Errorf("LookupInt(%q) error = %v, want %v", name, err, ErrorNotFound)
+		}
+	}
+
+	if _, err := NewInteger("ref_timeout", 30); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile("strings/strings.xml")
+	for _, want := range []string{
+		`<integer name="ref_retries">@integer/ref_max</integer>`,
+		`<bool name="ref_two_panes">@bool/ref_tablet</bool>`,
+		`<item>@integer/ref_max</item>`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("NewInteger() rewrote references, want %s in\n%s", want, got)
+		}
+	}
+}
+
+func TestTypedResources_missingHandler(t *testing.T) {
+	SetMissingHandler(func(locale, kind, name string) string {
+		if kind == KindBool {
+			return "true"
+		}
+		return "7"
+	})
+	t.Cleanup(func() { SetMissingHandler(nil) })
+
+	if got := GetInt("typed_missing"); got != 7 {
+		t.Errorf("GetInt() = %d, want 7", got)
+	}
+	if !GetBool("typed_missing") {
+		t.Errorf("GetBool() = false, want true")
+	}
+	if got := GetIntArray("typed_missing"); !reflect.DeepEqual(got, []int{7}) {
+		t.Errorf("GetIntArray() = %v, want [7]", got)
+	}
+	if got := GetArray("typed_missing"); !reflect.DeepEqual(got, []string{"7"}) {
+		t.Errorf("GetArray() = %v, want [7]", got)
+	}
+
+	SetMissingHandler(MissingBrackets)
+	if got := GetInt("typed_missing"); got != 0 {
+		t.Errorf("GetInt() with a non-integer missing value = %d, want 0", got)
+	}
+}
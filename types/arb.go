@@ -0,0 +1,402 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ARBStrategy reads and writes Flutter Application Resource Bundle files.
+//
+// Strings are stored as plain messages, quantity strings as ICU plural messages
+// ("{count, plural, =0{...} =1{...} =2{...} few{...} other{...}}") and string-arrays
+// as ICU select messages keyed by item index ("{index, select, 0{...} 1{...} other{}}").
+// Item values are plain text: apostrophes and braces are ICU-quoted, except for
+// simple placeholders like "{count}", and a plural without "many" gets an empty
+// "other" case.
+// Keys starting with "@" hold message metadata and are regenerated on encode.
+// Integers, bools, integer-arrays and typed arrays can't be stored.
+type ARBStrategy struct{}
+
+var arbPluralSelectors = map[string]string{
+	"=0":    "zero",
+	"zero":  "zero",
+	"=1":    "one",
+	"one":   "one",
+	"=2":    "two",
+	"two":   "two",
+	"few":   "few",
+	"many":  "many",
+	"other": "other",
+}
+
+var arbQuantitySelectors = map[string]string{
+	"zero": "=0",
+	"one":  "=1",
+	"two":  "=2",
+	"few":  "few",
+	"many": "other",
+}
+
+func (a *ARBStrategy) encode(n *Nesting) ([]byte, error) {
+	if len(n.Integers) > 0 || len(n.Bools) > 0 || len(n.IntegerArrays) > 0 || len(n.Arrays) > 0 {
+		return nil, fmt.Errorf("%w: ARB only holds strings, string-arrays and quantity strings", ErrorUnsupportedKind)
+	}
+
+	var fields []field
+
+	for _, s := range n.Strings {
+		fields = append(fields, field{Key: s.Name, Value: marshalString(s.Value)})
+		if !s.Meta.IsZero() {
+			fields = append(fields, field{Key: "@" + s.Name, Value: arbMetadataOf(s.Meta, nil)})
+		}
+	}
+
+	for _, sa := range n.StringsArray {
+		var msg strings.Builder
+		msg.WriteString("{index, select,")
+		for i, item := range sa.Items {
+			fmt.Fprintf(&msg, " %d{%s}", i, arbQuote(item.Value))
+		}
+		msg.WriteString(" other{}}")
+
+		fields = append(fields,
+			field{Key: sa.Name, Value: marshalString(msg.String())},
+			field{Key: "@" + sa.Name, Value: arbMetadataOf(sa.Meta, json.RawMessage(`{"index": {"type": "String"}}`))},
+		)
+	}
+
+	for _, pl := range n.Plurals {
+		var msg strings.Builder
+		msg.WriteString("{count, plural,")
+		other := false
+		for _, item := range pl.Items {
+			sel, ok := arbQuantitySelectors[item.Quantity]
+			if !ok {
+				return nil, fmt.Errorf("types: %s: unknown quantity %q", pl.Name, item.Quantity)
+			}
+			other = other || sel == "other"
+			fmt.Fprintf(&msg, " %s{%s}", sel, arbQuote(item.Value))
+		}
+		if !other {
+			// ICU requires an "other" case.
+			msg.WriteString(" other{}")
+		}
+		msg.WriteString("}")
+
+		fields = append(fields,
+			field{Key: pl.Name, Value: marshalString(msg.String())},
+			field{Key: "@" + pl.Name, Value: arbMetadataOf(pl.Meta, json.RawMessage(`{"count": {"type": "int"}}`))},
+		)
+	}
+
+	buf := bytes.Buffer{}
+	err := writeObject(&buf, fields, "")
+	return buf.Bytes(), err
+}
+
+func (a *ARBStrategy) decode(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	n, err := nestingOf(v)
+	if err != nil {
+		return err
+	}
+
+	fields, err := readObject(data)
+	if err != nil {
+		return err
+	}
+
+	*n = Nesting{}
+	metas := map[string]Meta{}
+	for _, f := range fields {
+		if strings.HasPrefix(f.Key, "@@") {
+			// Global attributes, like "@@locale".
+			continue
+		}
+		if strings.HasPrefix(f.Key, "@") {
+			var md arbMetadata
+			if err = json.Unmarshal(f.Value, &md); err != nil {
+				return fieldError(data, f, f.Key, err)
+			}
+			metas[f.Key[1:]] = md.meta()
+			continue
+		}
+
+		var value string
+		if err = json.Unmarshal(f.Value, &value); err != nil {
+			return fieldError(data, f, f.Key, err)
+		}
+
+		msg, ok := splitComplexArg(value)
+		switch {
+		case ok && msg.kind == "plural":
+			pl, err := arbPlural(f.Key, msg)
+			if err != nil {
+				return fieldError(data, f, f.Key, err)
+			}
+			n.Plurals = append(n.Plurals, pl)
+		case ok && msg.kind == "select" && isIndexSelect(msg):
+			sa := &StringArray{Name: f.Key}
+			for _, c := range msg.cases {
+				if c.selector == "other" {
+					continue
+				}
+				sa.Items = append(sa.Items, &Item{Value: arbUnquote(c.message)})
+			}
+			n.StringsArray = append(n.StringsArray, sa)
+		default:
+			n.Strings = append(n.Strings, &String{Name: f.Key, Value: value})
+		}
+	}
+
+	for _, s := range n.Strings {
+		s.Meta = metas[s.Name]
+	}
+	for _, sa := range n.StringsArray {
+		sa.Meta = metas[sa.Name]
+	}
+	for _, pl := range n.Plurals {
+		pl.Meta = metas[pl.Name]
+	}
+
+	return nil
+}
+
+// arbMetadata is the "@key" object of a message. Attributes not defined by ARB are prefixed with "x-".
+type arbMetadata struct {
+	Description  string          `json:"description,omitempty"`
+	Placeholders json.RawMessage `json:"placeholders,omitempty"`
+	Translatable *bool           `json:"x-translatable,omitempty"`
+	Formatted    *bool           `json:"x-formatted,omitempty"`
+	MaxLength    int             `json:"x-maxLength,omitempty"`
+	Tags         Tags            `json:"x-tags,omitempty"`
+}
+
+func arbMetadataOf(m Meta, placeholders json.RawMessage) json.RawMessage {
+	md := arbMetadata{
+		Description:  m.Description,
+		Placeholders: placeholders,
+		Translatable: m.Translatable,
+		Formatted:    m.Formatted,
+		MaxLength:    m.MaxLength,
+		Tags:         m.Tags,
+	}
+
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// Encoding can't fail: every field has a JSON representation.
+	enc.Encode(md)
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+func (md arbMetadata) meta() Meta {
+	return Meta{
+		Description:  md.Description,
+		Translatable: md.Translatable,
+		Formatted:    md.Formatted,
+		MaxLength:    md.MaxLength,
+		Tags:         md.Tags,
+	}
+}
+
+func arbPlural(name string, msg complexArg) (*Plural, error) {
+	values := map[string]string{}
+	for _, c := range msg.cases {
+		q, ok := arbPluralSelectors[c.selector]
+		if !ok {
+			return nil, fmt.Errorf("types: unsupported plural selector %q", c.selector)
+		}
+		if _, set := values[q]; !set {
+			values[q] = arbUnquote(c.message)
+		}
+	}
+
+	if _, ok := values["many"]; !ok {
+		// An empty "other" only completes the message.
+		if other, ok := values["other"]; ok && other != "" {
+			values["many"] = other
+		}
+	}
+
+	pl := &Plural{Name: name}
+	for _, q := range []string{"zero", "one", "two", "few", "many"} {
+		if value, ok := values[q]; ok {
+			pl.Items = append(pl.Items, &PluralItem{Quantity: q, Value: value})
+		}
+	}
+	return pl, nil
+}
+
+// arbQuote quotes the apostrophes and braces of a plain text value for an ICU message,
+// keeping simple placeholders like "{count}".
+func arbQuote(s string) string {
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'':
+			b.WriteString("''")
+		case '{':
+			if end := strings.IndexByte(s[i:], '}'); end > 1 && isArbIdentifier(s[i+1:i+end]) {
+				b.WriteString(s[i : i+end+1])
+				i += end
+				continue
+			}
+			b.WriteString("'{'")
+		case '}':
+			b.WriteString("'}'")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// arbUnquote resolves the ICU apostrophe quoting of a case message.
+func arbUnquote(s string) string {
+	if !strings.Contains(s, "'") {
+		return s
+	}
+
+	b := strings.Builder{}
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\'' {
+			b.WriteByte(c)
+			continue
+		}
+		switch {
+		case i+1 < len(s) && s[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case quoted || (i+1 < len(s) && strings.IndexByte("{}#|", s[i+1]) >= 0):
+			quoted = !quoted
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isArbIdentifier(s string) bool {
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isIndexSelect reports whether a select message enumerates the items of a string-array.
+func isIndexSelect(msg complexArg) bool {
+	i := 0
+	for _, c := range msg.cases {
+		if c.selector == "other" {
+			continue
+		}
+		if c.selector != strconv.Itoa(i) {
+			return false
+		}
+		i++
+	}
+	return i > 0
+}
+
+type complexArg struct {
+	arg   string
+	kind  string
+	cases []selectCase
+}
+
+type selectCase struct {
+	selector string
+	message  string
+}
+
+// splitComplexArg splits a message consisting of a single ICU plural or select
+// argument into its cases. Case messages are returned verbatim.
+func splitComplexArg(s string) (complexArg, bool) {
+	var msg complexArg
+
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return msg, false
+	}
+	body := s[1 : len(s)-1]
+
+	parts := strings.SplitN(body, ",", 3)
+	if len(parts) != 3 {
+		return msg, false
+	}
+	msg.arg = strings.TrimSpace(parts[0])
+	msg.kind = strings.TrimSpace(parts[1])
+	if msg.arg == "" || (msg.kind != "plural" && msg.kind != "select") {
+		return msg, false
+	}
+
+	rest := strings.TrimSpace(parts[2])
+	if strings.HasPrefix(rest, "offset:") {
+		return msg, false
+	}
+
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open <= 0 {
+			return msg, false
+		}
+		selector := strings.TrimSpace(rest[:open])
+		if selector == "" || strings.ContainsAny(selector, " \t\n{}") {
+			return msg, false
+		}
+
+		end := matchingBrace(rest, open)
+		if end < 0 {
+			return msg, false
+		}
+
+		msg.cases = append(msg.cases, selectCase{selector: selector, message: rest[open+1 : end]})
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	return msg, len(msg.cases) > 0
+}
+
+// matchingBrace returns the index of the brace closing the one at open,
+// skipping ICU apostrophe-quoted text.
+func matchingBrace(s string, open int) int {
+	depth := 0
+	quoted := false
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			if quoted || (i+1 < len(s) && strings.IndexByte("{}#|", s[i+1]) >= 0) {
+				quoted = !quoted
+			}
+			continue
+		}
+		if quoted {
+			continue
+		}
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ParseError reports a malformed resource file. Line, Column and Name are set where known.
+type ParseError struct {
+	Path   string
+	Format FileType
+	Line   int
+	Column int
+	Name   string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	b := bytes.Buffer{}
+	b.WriteString("stres: ")
+
+	location := e.Path
+	if location == "" {
+		location = string(e.Format)
+	}
+	b.WriteString(location)
+
+	switch {
+	case e.Line > 0 && location != "":
+		fmt.Fprintf(&b, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ":%d", e.Column)
+		}
+	case e.Line > 0:
+		fmt.Fprintf(&b, "line %d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ", column %d", e.Column)
+		}
+	}
+	if b.Len() > len("stres: ") {
+		b.WriteString(": ")
+	}
+
+	if e.Name != "" {
+		fmt.Fprintf(&b, "%s: ", e.Name)
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// position returns the 1-based line and column of a byte offset in data.
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// jsonError converts the errors of encoding/json into a ParseError.
+func jsonError(data []byte, err error, offset int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+
+	pe := &ParseError{Err: err}
+	if offset >= 0 {
+		pe.Line, pe.Column = position(data, offset)
+	}
+	return pe
+}
+
+// ErrorUnsupportedKind is returned when encoding resources of a kind the format can't represent.
+var ErrorUnsupportedKind = errors.New("types: resource kind not supported by the format")
+
+var yamlLine = regexp.MustCompile(`line (\d+)(?:, column (\d+))?`)
+
+// yamlError extracts the position reported in the message of yaml.v3 errors.
+func yamlError(err error) error {
+	pe := &ParseError{Err: err}
+	if m := yamlLine.FindStringSubmatch(err.Error()); m != nil {
+		pe.Line, _ = strconv.Atoi(m[1])
+		pe.Column, _ = strconv.Atoi(m[2])
+	}
+	return pe
+}
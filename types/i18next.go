@@ -0,0 +1,287 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// I18nextStrategy reads and writes i18next JSON (v4) translation files.
+//
+// Nested objects are flattened into dot separated names ("common.ok"), string-arrays
+// are stored as JSON arrays and quantity strings use the "_zero", "_one", "_two",
+// "_few" and "_other" key suffixes ("many" is written as "_other"). Integers, bools and
+// integer-arrays are stored as JSON numbers, booleans and arrays of numbers; typed arrays
+// can't be stored.
+type I18nextStrategy struct{}
+
+var i18nextSuffixes = map[string]string{
+	"_zero":  "zero",
+	"_one":   "one",
+	"_two":   "two",
+	"_few":   "few",
+	"_many":  "many",
+	"_other": "other",
+}
+
+var i18nextQuantitySuffixes = map[string]string{
+	"zero": "_zero",
+	"one":  "_one",
+	"two":  "_two",
+	"few":  "_few",
+	"many": "_other",
+}
+
+// i18nextNode is an object being built by encode.
+type i18nextNode struct {
+	keys     []string
+	values   map[string]json.RawMessage
+	children map[string]*i18nextNode
+}
+
+func newI18nextNode() *i18nextNode {
+	return &i18nextNode{
+		values:   map[string]json.RawMessage{},
+		children: map[string]*i18nextNode{},
+	}
+}
+
+func (node *i18nextNode) set(name string, value json.RawMessage) error {
+	path := strings.Split(name, ".")
+	for _, key := range path[:len(path)-1] {
+		if _, ok := node.values[key]; ok {
+			return fmt.Errorf("types: %s: %q is both a value and a namespace", name, key)
+		}
+		child, ok := node.children[key]
+		if !ok {
+			child = newI18nextNode()
+			node.children[key] = child
+			node.keys = append(node.keys, key)
+		}
+		node = child
+	}
+
+	key := path[len(path)-1]
+	if _, ok := node.children[key]; ok {
+		return fmt.Errorf("types: %s: %q is both a value and a namespace", name, key)
+	}
+	if _, ok := node.values[key]; ok {
+		return fmt.Errorf("types: %s: duplicate key", name)
+	}
+	node.values[key] = value
+	node.keys = append(node.keys, key)
+	return nil
+}
+
+func (node *i18nextNode) write(buf *bytes.Buffer, indent string) error {
+	fields := make([]field, 0, len(node.keys))
+	for _, key := range node.keys {
+		if child, ok := node.children[key]; ok {
+			var sub bytes.Buffer
+			if err := child.write(&sub, ""); err != nil {
+				return err
+			}
+			fields = append(fields, field{Key: key, Value: sub.Bytes()})
+			continue
+		}
+		fields = append(fields, field{Key: key, Value: node.values[key]})
+	}
+	return writeObject(buf, fields, indent)
+}
+
+func (i *I18nextStrategy) encode(n *Nesting) ([]byte, error) {
+	root := newI18nextNode()
+
+	for _, s := range n.Strings {
+		if err := root.set(s.Name, marshalString(s.Value)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sa := range n.StringsArray {
+		items := make([]json.RawMessage, 0, len(sa.Items))
+		for _, item := range sa.Items {
+			items = append(items, marshalString(item.Value))
+		}
+		value := append(json.RawMessage("["), bytes.Join(toBytes(items), []byte(","))...)
+		value = append(value, ']')
+		if err := root.set(sa.Name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, i := range n.Integers {
+		if err := root.set(i.Name, json.RawMessage(strconv.Itoa(i.Value))); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, b := range n.Bools {
+		if err := root.set(b.Name, json.RawMessage(strconv.FormatBool(b.Value))); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ia := range n.IntegerArrays {
+		items := make([]string, 0, len(ia.Items))
+		for _, item := range ia.Items {
+			items = append(items, strconv.Itoa(item.Value))
+		}
+		if err := root.set(ia.Name, json.RawMessage("["+strings.Join(items, ",")+"]")); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(n.Arrays) > 0 {
+		return nil, fmt.Errorf("%w: %s: i18next has no typed arrays", ErrorUnsupportedKind, n.Arrays[0].Name)
+	}
+
+	for _, pl := range n.Plurals {
+		for _, item := range pl.Items {
+			suffix, ok := i18nextQuantitySuffixes[item.Quantity]
+			if !ok {
+				return nil, fmt.Errorf("types: %s: unknown quantity %q", pl.Name, item.Quantity)
+			}
+			if err := root.set(pl.Name+suffix, marshalString(item.Value)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buf := bytes.Buffer{}
+	err := root.write(&buf, "")
+	return buf.Bytes(), err
+}
+
+func (i *I18nextStrategy) decode(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	n, err := nestingOf(v)
+	if err != nil {
+		return err
+	}
+
+	*n = Nesting{}
+	plurals := map[string]*Plural{}
+	values := map[string]map[string]string{}
+
+	err = i18nextWalk(data, 0, "", func(name string, f field) error {
+		raw := f.Value
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			base, quantity := i18nextSplitSuffix(name)
+			if quantity == "" {
+				n.Strings = append(n.Strings, &String{Name: name, Value: value})
+				return nil
+			}
+			if _, ok := plurals[base]; !ok {
+				plurals[base] = &Plural{Name: base}
+				values[base] = map[string]string{}
+				n.Plurals = append(n.Plurals, plurals[base])
+			}
+			values[base][quantity] = value
+			return nil
+		}
+
+		var integer int
+		if err := json.Unmarshal(raw, &integer); err == nil {
+			n.Integers = append(n.Integers, &Integer{Name: name, Value: integer})
+			return nil
+		}
+
+		var boolean bool
+		if err := json.Unmarshal(raw, &boolean); err == nil {
+			n.Bools = append(n.Bools, &Bool{Name: name, Value: boolean})
+			return nil
+		}
+
+		var integers []int
+		if err := json.Unmarshal(raw, &integers); err == nil && len(integers) > 0 {
+			ia := &IntegerArray{Name: name}
+			for _, item := range integers {
+				ia.Items = append(ia.Items, &IntegerItem{Value: item})
+			}
+			n.IntegerArrays = append(n.IntegerArrays, ia)
+			return nil
+		}
+
+		var items []string
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return fieldError(data, f, name, errors.New("types: expected a string, an integer, a bool, an array or an object"))
+		}
+		sa := &StringArray{Name: name}
+		for _, item := range items {
+			sa.Items = append(sa.Items, &Item{Value: item})
+		}
+		n.StringsArray = append(n.StringsArray, sa)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pl := range n.Plurals {
+		v := values[pl.Name]
+		if _, ok := v["many"]; !ok {
+			if other, ok := v["other"]; ok {
+				v["many"] = other
+			}
+		}
+		for _, q := range []string{"zero", "one", "two", "few", "many"} {
+			if value, ok := v[q]; ok {
+				pl.Items = append(pl.Items, &PluralItem{Quantity: q, Value: value})
+			}
+		}
+	}
+
+	return nil
+}
+
+// i18nextWalk calls fn for every non-object value, flattening nested namespaces.
+// The fields passed to fn have offsets relative to the whole document, data starting at base.
+func i18nextWalk(data []byte, base int64, prefix string, fn func(name string, f field) error) error {
+	fields, err := readObject(data)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		name := prefix + f.Key
+		f.Offset += base
+		if trimmed := bytes.TrimLeft(f.Value, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+			lead := int64(len(f.Value) - len(trimmed))
+			if err = i18nextWalk(bytes.TrimSpace(trimmed), f.Offset+lead, name+".", fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = fn(name, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func i18nextSplitSuffix(name string) (string, string) {
+	idx := strings.LastIndexByte(name, '_')
+	if idx <= 0 {
+		return name, ""
+	}
+	if q, ok := i18nextSuffixes[name[idx:]]; ok {
+		return name[:idx], q
+	}
+	return name, ""
+}
+
+func toBytes(raws []json.RawMessage) [][]byte {
+	b := make([][]byte, len(raws))
+	for i, raw := range raws {
+		b[i] = raw
+	}
+	return b
+}
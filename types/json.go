@@ -15,5 +15,8 @@ func (j *JSONStrategy) decode(data []byte, v interface{}) error {
 		return nil
 	}
 
-	return json.Unmarshal(data, v)
+	if err := json.Unmarshal(data, v); err != nil {
+		return jsonError(data, err, -1)
+	}
+	return nil
 }
@@ -13,5 +13,8 @@ func (m *MsgPackStrategy) decode(data []byte, v interface{}) error {
 		return nil
 	}
 
-	return msgpack.Unmarshal(data, v)
+	if err := msgpack.Unmarshal(data, v); err != nil {
+		return &ParseError{Err: err}
+	}
+	return nil
 }
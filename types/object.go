@@ -0,0 +1,114 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// field is a single member of a JSON object, kept in declaration order.
+type field struct {
+	Key    string
+	Value  json.RawMessage
+	Offset int64 // offset of Value in the decoded document
+}
+
+// readObject decodes a JSON object preserving the order of its members.
+// Errors are ParseErrors positioned in data.
+func readObject(data []byte) ([]field, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonError(data, err, dec.InputOffset())
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, jsonError(data, errors.New("types: expected a JSON object"), 0)
+	}
+
+	var fields []field
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, jsonError(data, err, dec.InputOffset())
+		}
+		key, _ := tok.(string)
+
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			pe := jsonError(data, err, dec.InputOffset()).(*ParseError)
+			pe.Name = key
+			return nil, pe
+		}
+		fields = append(fields, field{Key: key, Value: raw, Offset: dec.InputOffset() - int64(len(raw))})
+	}
+
+	if _, err = dec.Token(); err != nil {
+		return nil, jsonError(data, err, dec.InputOffset())
+	}
+	return fields, nil
+}
+
+// fieldError returns a ParseError for the value of f named name.
+func fieldError(data []byte, f field, name string, err error) error {
+	pe := &ParseError{Name: name, Err: err}
+	pe.Line, pe.Column = position(data, f.Offset)
+	return pe
+}
+
+// writeObject encodes fields as an indented JSON object in the given order.
+func writeObject(buf *bytes.Buffer, fields []field, indent string) error {
+	if len(fields) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+
+	buf.WriteString("{\n")
+	for i, f := range fields {
+		key, err := json.Marshal(f.Key)
+		if err != nil {
+			return err
+		}
+
+		var value bytes.Buffer
+		if err = json.Indent(&value, f.Value, indent+"\t", "\t"); err != nil {
+			return fmt.Errorf("types: %s: %w", f.Key, err)
+		}
+
+		buf.WriteString(indent + "\t")
+		buf.Write(key)
+		buf.WriteString(": ")
+		buf.Write(value.Bytes())
+		if i < len(fields)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(indent + "}")
+	return nil
+}
+
+// nestingOf returns the Nesting a strategy has to decode into, allocating it if needed.
+func nestingOf(v interface{}) (*Nesting, error) {
+	switch n := v.(type) {
+	case *Nesting:
+		return n, nil
+	case **Nesting:
+		if *n == nil {
+			*n = &Nesting{}
+		}
+		return *n, nil
+	}
+	return nil, fmt.Errorf("types: cannot decode into %T", v)
+}
+
+// marshalString encodes s as a JSON string without escaping HTML characters.
+func marshalString(s string) json.RawMessage {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
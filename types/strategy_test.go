@@ -0,0 +1,322 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testNesting() *Nesting {
+	return &Nesting{
+		Strings: []*String{
+			{Name: "app_name", Value: "stres"},
+			{Name: "common.ok", Value: "<b>OK</b>"},
+		},
+		StringsArray: []*StringArray{
+			{Name: "planets", Items: []*Item{{Value: "Mercury"}, {Value: "Venus"}}},
+		},
+		Plurals: []*Plural{
+			{Name: "files", Items: []*PluralItem{
+				{Quantity: "zero", Value: "no files"},
+				{Quantity: "one", Value: "# file"},
+				{Quantity: "many", Value: "# files"},
+			}},
+		},
+	}
+}
+
+func TestStrategyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy StrategyAlgo
+	}{
+		{name: "arb", strategy: &ARBStrategy{}},
+		{name: "i18next", strategy: &I18nextStrategy{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := testNesting()
+
+			data, err := tt.strategy.encode(want)
+			if err != nil {
+				t.Fatalf("encode() error = %v", err)
+			}
+
+			got := &Nesting{}
+			if err = tt.strategy.decode(data, &got); err != nil {
+				t.Fatalf("decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("decode(encode()) = %+v, want %+v\n%s", got, want, data)
+			}
+		})
+	}
+}
+
+func TestARBStrategy_decode(t *testing.T) {
+	data := []byte(`{
+		"@@locale": "en",
+		"greeting": "Hello {name}",
+		"@greeting": {"placeholders": {"name": {}}},
+		"items": "{count, plural, zero{none} =1{one item} other{{count} items}}"
+	}`)
+
+	want := &Nesting{
+		Strings: []*String{{Name: "greeting", Value: "Hello {name}"}},
+		Plurals: []*Plural{{Name: "items", Items: []*PluralItem{
+			{Quantity: "zero", Value: "none"},
+			{Quantity: "one", Value: "one item"},
+			{Quantity: "many", Value: "{count} items"},
+		}}},
+	}
+
+	got := &Nesting{}
+	if err := (&ARBStrategy{}).decode(data, &got); err != nil {
+		t.Fatalf("decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decode() = %+v, want %+v", got, want)
+	}
+}
+
+func TestARBStrategy_quoting(t *testing.T) {
+	want := &Nesting{
+		StringsArray: []*StringArray{
+			{Name: "symbols", Items: []*Item{{Value: "x}y"}, {Value: "{a"}, {Value: "it's {name}"}, {Value: "'{'"}}},
+		},
+		Plurals: []*Plural{
+			{Name: "files", Items: []*PluralItem{
+				{Quantity: "one", Value: "{count} file {"},
+			}},
+		},
+	}
+
+	data, err := (&ARBStrategy{}).encode(want)
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+	if !bytes.Contains(data, []byte("other{}}")) {
+		t.Errorf("encode() = %s, want an other case in the plural", data)
+	}
+
+	got := &Nesting{}
+	if err = (&ARBStrategy{}).decode(data, &got); err != nil {
+		t.Fatalf("decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decode(encode()) = %+v, want %+v\n%s", got, want, data)
+	}
+}
+
+func TestI18nextStrategy_decode(t *testing.T) {
+	data := []byte(`{
+		"title": "Welcome",
+		"cart": {
+			"item_one": "{{count}} item",
+			"item_many": "{{count}} items",
+			"item_other": "{{count}} things"
+		},
+		"days": ["Mon", "Tue"]
+	}`)
+
+	want := &Nesting{
+		Strings:      []*String{{Name: "title", Value: "Welcome"}},
+		StringsArray: []*StringArray{{Name: "days", Items: []*Item{{Value: "Mon"}, {Value: "Tue"}}}},
+		Plurals: []*Plural{{Name: "cart.item", Items: []*PluralItem{
+			{Quantity: "one", Value: "{{count}} item"},
+			{Quantity: "many", Value: "{{count}} items"},
+		}}},
+	}
+
+	got := &Nesting{}
+	if err := (&I18nextStrategy{}).decode(data, &got); err != nil {
+		t.Fatalf("decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decode() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStrategy_decodeError(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategy   StrategyAlgo
+		data       string
+		wantLine   int
+		wantColumn int
+		wantName   string
+	}{
+		{name: "xml", strategy: &XMLStrategy{}, data: "<resources>\n\t<string name=\"a\">A</strin>\n</resources>", wantLine: 2},
+		{name: "json", strategy: &JSONStrategy{}, data: "{\n\t\"string\": [\n\t\t{\"name\": \"a\",}\n\t]\n}", wantLine: 3, wantColumn: 17},
+		{name: "yaml", strategy: &YAMLStrategy{}, data: "string: a\nplurals: b\n  items: c\n", wantLine: 3},
+		{name: "toml", strategy: &TOMLStrategy{}, data: "[[string]]\nname = \"a\"\nvalue = \n", wantLine: 3, wantColumn: 9},
+		{name: "watson", strategy: &WatsonStrategy{}, data: "~\nM", wantLine: 2, wantColumn: 1},
+		{name: "arb", strategy: &ARBStrategy{}, data: "{\n\t\"a\": \"A\",\n\t\"b\": 1\n}", wantLine: 3, wantColumn: 7, wantName: "b"},
+		{name: "arb_plural", strategy: &ARBStrategy{}, data: "{\n\t\"a\": \"{n, plural, =5{x} other{y}}\"\n}", wantLine: 2, wantColumn: 7, wantName: "a"},
+		{name: "i18next", strategy: &I18nextStrategy{}, data: "{\n\t\"common\": {\n\t\t\"ok\": 1.5\n\t}\n}", wantLine: 3, wantColumn: 9, wantName: "common.ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &Nesting{}
+			err := tt.strategy.decode([]byte(tt.data), &n)
+
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("decode() error = %#v, want *ParseError", err)
+			}
+			if pe.Line != tt.wantLine || (tt.wantColumn != 0 && pe.Column != tt.wantColumn) {
+				t.Errorf("decode() position = %d:%d, want %d:%d", pe.Line, pe.Column, tt.wantLine, tt.wantColumn)
+			}
+			if pe.Name != tt.wantName {
+				t.Errorf("decode() name = %q, want %q", pe.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestParseError_Error(t *testing.T) {
+	err := errors.New("bad value")
+	tests := []struct {
+		pe   *ParseError
+		want string
+	}{
+		{pe: &ParseError{Path: "strings/strings.json", Line: 1, Column: 83, Err: err}, want: "stres: strings/strings.json:1:83: bad value"},
+		{pe: &ParseError{Format: "json", Line: 2, Err: err}, want: "stres: json:2: bad value"},
+		{pe: &ParseError{Line: 1, Column: 83, Err: err}, want: "stres: line 1, column 83: bad value"},
+		{pe: &ParseError{Name: "a", Err: err}, want: "stres: a: bad value"},
+	}
+	for _, tt := range tests {
+		if got := tt.pe.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestStrategy_meta(t *testing.T) {
+	untranslatable := false
+	want := &Nesting{
+		Strings: []*String{
+			{Name: "app_name", Value: "stres", Meta: Meta{Description: "Name of the <b>app</b>", Translatable: &untranslatable, Tags: Tags{"brand", "home"}}},
+			{Name: "welcome", Value: "Welcome", Meta: Meta{MaxLength: 20}},
+		},
+		StringsArray: []*StringArray{
+			{Name: "planets", Meta: Meta{Description: "Planets"}, Items: []*Item{{Value: "Mercury"}}},
+		},
+		Plurals: []*Plural{
+			{Name: "files", Meta: Meta{Formatted: &untranslatable}, Items: []*PluralItem{{Quantity: "one", Value: "# file"}}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		strategy StrategyAlgo
+	}{
+		{name: "xml", strategy: &XMLStrategy{}},
+		{name: "json", strategy: &JSONStrategy{}},
+		{name: "yaml", strategy: &YAMLStrategy{}},
+		{name: "toml", strategy: &TOMLStrategy{}},
+		{name: "watson", strategy: &WatsonStrategy{}},
+		{name: "msgpack", strategy: &MsgPackStrategy{}},
+		{name: "arb", strategy: &ARBStrategy{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.strategy.encode(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := &Nesting{}
+			if err = tt.strategy.decode(data, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, s := range want.Strings {
+				if !reflect.DeepEqual(got.Strings[i].Meta, s.Meta) {
+					t.Errorf("string %s meta = %+v, want %+v", s.Name, got.Strings[i].Meta, s.Meta)
+				}
+			}
+			if !reflect.DeepEqual(got.StringsArray[0].Meta, want.StringsArray[0].Meta) {
+				t.Errorf("string-array meta = %+v, want %+v", got.StringsArray[0].Meta, want.StringsArray[0].Meta)
+			}
+			if !reflect.DeepEqual(got.Plurals[0].Meta, want.Plurals[0].Meta) {
+				t.Errorf("plurals meta = %+v, want %+v", got.Plurals[0].Meta, want.Plurals[0].Meta)
+			}
+		})
+	}
+}
+
+func TestStrategy_typed(t *testing.T) {
+	want := &Nesting{
+		Integers:      []*Integer{{Name: "max_retries", Value: 3}, {Name: "offset", Value: -1}},
+		Bools:         []*Bool{{Name: "beta", Value: true}, {Name: "dark_mode", Value: false}},
+		IntegerArrays: []*IntegerArray{{Name: "limits", Items: []*IntegerItem{{Value: 10}, {Value: 20}}}},
+		Arrays:        []*Array{{Name: "icons", Items: []*Item{{Value: "@drawable/home"}, {Value: "@drawable/settings"}}}},
+	}
+
+	tests := []struct {
+		name     string
+		strategy StrategyAlgo
+		noArrays bool
+	}{
+		{name: "xml", strategy: &XMLStrategy{}},
+		{name: "json", strategy: &JSONStrategy{}},
+		{name: "yaml", strategy: &YAMLStrategy{}},
+		{name: "toml", strategy: &TOMLStrategy{}},
+		{name: "watson", strategy: &WatsonStrategy{}},
+		{name: "msgpack", strategy: &MsgPackStrategy{}},
+		{name: "i18next", strategy: &I18nextStrategy{}, noArrays: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := *want
+			if tt.noArrays {
+				n.Arrays = nil
+			}
+			data, err := tt.strategy.encode(&n)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := &Nesting{}
+			if err = tt.strategy.decode(data, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			var values []interface{}
+			for _, i := range got.Integers {
+				values = append(values, i.Name, i.Value)
+			}
+			for _, b := range got.Bools {
+				values = append(values, b.Name, b.Value)
+			}
+			for _, ia := range got.IntegerArrays {
+				values = append(values, ia.Name)
+				for _, item := range ia.Items {
+					values = append(values, item.Value)
+				}
+			}
+			for _, a := range got.Arrays {
+				values = append(values, a.Name)
+				for _, item := range a.Items {
+					values = append(values, item.Value)
+				}
+			}
+			wantValues := []interface{}{"max_retries", 3, "offset", -1, "beta", true, "dark_mode", false, "limits", 10, 20}
+			if !tt.noArrays {
+				wantValues = append(wantValues, "icons", "@drawable/home", "@drawable/settings")
+			}
+			if !reflect.DeepEqual(values, wantValues) {
+				t.Errorf("decoded %v, want %v", values, wantValues)
+			}
+		})
+	}
+
+	if _, err := (&ARBStrategy{}).encode(want); !errors.Is(err, ErrorUnsupportedKind) {
+		t.Errorf("ARB encode() error = %v, want %v", err, ErrorUnsupportedKind)
+	}
+	if _, err := (&I18nextStrategy{}).encode(want); !errors.Is(err, ErrorUnsupportedKind) {
+		t.Errorf("i18next encode() error = %v, want %v", err, ErrorUnsupportedKind)
+	}
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"bytes"
+	"errors"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -21,5 +22,13 @@ func (t *TOMLStrategy) decode(data []byte, v interface{}) error {
 		return nil
 	}
 
-	return toml.Unmarshal(data, v)
+	if err := toml.Unmarshal(data, v); err != nil {
+		pe := &ParseError{Err: err}
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			pe.Line, pe.Column = decodeErr.Position()
+		}
+		return pe
+	}
+	return nil
 }
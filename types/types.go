@@ -1,12 +1,56 @@
 package types
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
 
 type FileType string
 
+// Meta holds the translator metadata of a resource. Every field is optional.
+type Meta struct {
+	Description  string `xml:"description,attr,omitempty" json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty" watson:"description,omitempty" msgpack:"description,omitempty"`
+	Translatable *bool  `xml:"translatable,attr,omitempty" json:"translatable,omitempty" yaml:"translatable,omitempty" toml:"translatable,omitempty" watson:"translatable,omitempty" msgpack:"translatable,omitempty"`
+	Formatted    *bool  `xml:"formatted,attr,omitempty" json:"formatted,omitempty" yaml:"formatted,omitempty" toml:"formatted,omitempty" watson:"formatted,omitempty" msgpack:"formatted,omitempty"`
+	MaxLength    int    `xml:"maxLength,attr,omitempty" json:"maxLength,omitempty" yaml:"maxLength,omitempty" toml:"maxLength,omitempty" watson:"maxLength,omitempty" msgpack:"maxLength,omitempty"`
+	Tags         Tags   `xml:"tags,attr,omitempty" json:"tags,omitempty" yaml:"tags,flow,omitempty" toml:"tags,omitempty" watson:"tags,omitempty" msgpack:"tags,omitempty"`
+}
+
+// IsTranslatable reports whether the resource has to be translated (default true).
+func (m Meta) IsTranslatable() bool {
+	return m.Translatable == nil || *m.Translatable
+}
+
+// IsFormatted reports whether the resource's value contains format specifiers (default true).
+func (m Meta) IsFormatted() bool {
+	return m.Formatted == nil || *m.Formatted
+}
+
+// IsZero reports whether no metadata is set.
+func (m Meta) IsZero() bool {
+	return m.Description == "" && m.Translatable == nil && m.Formatted == nil && m.MaxLength == 0 && len(m.Tags) == 0
+}
+
+// Tags are free-form labels of a resource, written as a space separated XML attribute.
+type Tags []string
+
+func (t Tags) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if len(t) == 0 {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: strings.Join(t, " ")}, nil
+}
+
+func (t *Tags) UnmarshalXMLAttr(attr xml.Attr) error {
+	*t = strings.Fields(attr.Value)
+	return nil
+}
+
 type Plural struct {
-	XMLName xml.Name      `xml:"plurals" json:"plurals" yaml:"plurals" toml:"plurals" watson:"plurals" msgpack:"plurals"`
-	Name    string        `xml:"name,attr" json:"name" yaml:"name" toml:"name" watson:"name" msgpack:"name"`
+	XMLName xml.Name `xml:"plurals" json:"plurals" yaml:"plurals" toml:"plurals" watson:"plurals" msgpack:"plurals"`
+	Name    string   `xml:"name,attr" json:"name" yaml:"name" toml:"name" watson:"name" msgpack:"name"`
+	Meta    `yaml:",inline" watson:",inline" msgpack:",inline"`
 	Items   []*PluralItem `xml:"item" json:"items" yaml:"items,flow" toml:"items,multiline" watson:"items" msgpack:"items,as_array"`
 }
 
@@ -24,18 +68,169 @@ type Item struct {
 type StringArray struct {
 	XMLName xml.Name `xml:"string-array" json:"string-array" yaml:"string-array" toml:"string-array" watson:"string-array" msgpack:"string-array"`
 	Name    string   `xml:"name,attr" json:"name" yaml:"name" toml:"name" watson:"name" msgpack:"name"`
-	Items   []*Item  `xml:"item" json:"items" yaml:"items,flow" toml:"items,multiline" watson:"items" msgpack:"items,as_array" `
+	Meta    `yaml:",inline" watson:",inline" msgpack:",inline"`
+	Items   []*Item `xml:"item" json:"items" yaml:"items,flow" toml:"items,multiline" watson:"items" msgpack:"items,as_array" `
 }
 
 type String struct {
 	XMLName xml.Name `xml:"string" json:"string" yaml:"string" toml:"string" watson:"string" msgpack:"string"`
 	Name    string   `xml:"name,attr" json:"name" yaml:"name" toml:"name" watson:"name" msgpack:"name"`
-	Value   string   `xml:",innerxml" json:"value" yaml:"value" toml:"value" watson:"value" msgpack:"value"`
+	Meta    `yaml:",inline" watson:",inline" msgpack:",inline"`
+	Value   string `xml:",innerxml" json:"value" yaml:"value" toml:"value" watson:"value" msgpack:"value"`
+}
+
+// Integer is an integer resource. Its value may be a reference to another resource (e.g. "@integer/max"),
+// kept in Ref instead of Value.
+type Integer struct {
+	XMLName xml.Name `xml:"integer" json:"integer" yaml:"integer" toml:"integer" watson:"integer" msgpack:"integer"`
+	Name    string   `xml:"name,attr" json:"name" yaml:"name" toml:"name" watson:"name" msgpack:"name"`
+	Meta    `yaml:",inline" watson:",inline" msgpack:",inline"`
+	Value   int    `xml:"-" json:"value" yaml:"value" toml:"value" watson:"value" msgpack:"value"`
+	Ref     string `xml:"-" json:"ref,omitempty" yaml:"ref,omitempty" toml:"ref,omitempty" watson:"ref,omitempty" msgpack:"ref,omitempty"`
+}
+
+// Text returns the value as written in resource files: the reference if any, the number otherwise.
+func (i Integer) Text() string {
+	if i.Ref != "" {
+		return i.Ref
+	}
+	return strconv.Itoa(i.Value)
+}
+
+func (i Integer) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "integer"}
+	return e.EncodeElement(xmlValue{Name: i.Name, Meta: i.Meta, Text: i.Text()}, start)
+}
+
+func (i *Integer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v xmlValue
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*i = Integer{XMLName: start.Name, Name: v.Name, Meta: v.Meta}
+	var err error
+	i.Value, i.Ref, err = parseValue(v.Text, strconv.Atoi)
+	return err
+}
+
+// Bool is a bool resource. Its value may be a reference to another resource (e.g. "@bool/enabled"),
+// kept in Ref instead of Value.
+type Bool struct {
+	XMLName xml.Name `xml:"bool" json:"bool" yaml:"bool" toml:"bool" watson:"bool" msgpack:"bool"`
+	Name    string   `xml:"name,attr" json:"name" yaml:"name" toml:"name" watson:"name" msgpack:"name"`
+	Meta    `yaml:",inline" watson:",inline" msgpack:",inline"`
+	Value   bool   `xml:"-" json:"value" yaml:"value" toml:"value" watson:"value" msgpack:"value"`
+	Ref     string `xml:"-" json:"ref,omitempty" yaml:"ref,omitempty" toml:"ref,omitempty" watson:"ref,omitempty" msgpack:"ref,omitempty"`
+}
+
+// Text returns the value as written in resource files: the reference if any, "true" or "false" otherwise.
+func (b Bool) Text() string {
+	if b.Ref != "" {
+		return b.Ref
+	}
+	return strconv.FormatBool(b.Value)
+}
+
+func (b Bool) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "bool"}
+	return e.EncodeElement(xmlValue{Name: b.Name, Meta: b.Meta, Text: b.Text()}, start)
+}
+
+func (b *Bool) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v xmlValue
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*b = Bool{XMLName: start.Name, Name: v.Name, Meta: v.Meta}
+	var err error
+	b.Value, b.Ref, err = parseValue(v.Text, strconv.ParseBool)
+	return err
+}
+
+// IntegerItem is an item of an integer-array. Its value may be a reference, kept in Ref instead of Value.
+type IntegerItem struct {
+	XMLName xml.Name `xml:"item" json:"item" yaml:"item" toml:"item" watson:"item" msgpack:"item"`
+	Value   int      `xml:"-" json:"value" yaml:"value" toml:"value" watson:"value" msgpack:"value"`
+	Ref     string   `xml:"-" json:"ref,omitempty" yaml:"ref,omitempty" toml:"ref,omitempty" watson:"ref,omitempty" msgpack:"ref,omitempty"`
+}
+
+// Text returns the value as written in resource files: the reference if any, the number otherwise.
+func (i IntegerItem) Text() string {
+	if i.Ref != "" {
+		return i.Ref
+	}
+	return strconv.Itoa(i.Value)
+}
+
+func (i IntegerItem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "item"}
+	return e.EncodeElement(xmlValue{Text: i.Text()}, start)
+}
+
+func (i *IntegerItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v xmlValue
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*i = IntegerItem{XMLName: start.Name}
+	var err error
+	i.Value, i.Ref, err = parseValue(v.Text, strconv.Atoi)
+	return err
+}
+
+// xmlValue is the XML element of a resource with a non-string value, read as text so that references
+// don't fail to parse.
+type xmlValue struct {
+	Name string `xml:"name,attr,omitempty"`
+	Meta
+	Text string `xml:",chardata"`
+}
+
+// IsReference reports whether a resource value refers to another resource ("@type/name") or to a theme
+// attribute ("?attr").
+func IsReference(text string) bool {
+	return strings.HasPrefix(text, "@") || strings.HasPrefix(text, "?")
+}
+
+// parseValue parses the text of a resource value, returning it as the reference if it's one.
+func parseValue[T any](text string, parse func(string) (T, error)) (T, string, error) {
+	var zero T
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return zero, "", nil
+	}
+	if IsReference(text) {
+		return zero, text, nil
+	}
+	v, err := parse(text)
+	if err != nil {
+		return zero, "", err
+	}
+	return v, "", nil
+}
+
+type IntegerArray struct {
+	XMLName xml.Name `xml:"integer-array" json:"integer-array" yaml:"integer-array" toml:"integer-array" watson:"integer-array" msgpack:"integer-array"`
+	Name    string   `xml:"name,attr" json:"name" yaml:"name" toml:"name" watson:"name" msgpack:"name"`
+	Meta    `yaml:",inline" watson:",inline" msgpack:",inline"`
+	Items   []*IntegerItem `xml:"item" json:"items" yaml:"items,flow" toml:"items,multiline" watson:"items" msgpack:"items,as_array"`
+}
+
+// Array is a typed array, whose items are values or references to other resources (e.g. "@drawable/icon").
+type Array struct {
+	XMLName xml.Name `xml:"array" json:"array" yaml:"array" toml:"array" watson:"array" msgpack:"array"`
+	Name    string   `xml:"name,attr" json:"name" yaml:"name" toml:"name" watson:"name" msgpack:"name"`
+	Meta    `yaml:",inline" watson:",inline" msgpack:",inline"`
+	Items   []*Item `xml:"item" json:"items" yaml:"items,flow" toml:"items,multiline" watson:"items" msgpack:"items,as_array"`
 }
 
 type Nesting struct {
-	XMLName      xml.Name       `xml:"resources" json:"resources" yaml:"resources" toml:"resources" watson:"resources" msgpack:"resources"`
-	Strings      []*String      `xml:"string" json:"string" yaml:"string,flow" toml:"string,multiline" watson:"string" msgpack:"string,as_array"`
-	StringsArray []*StringArray `xml:"string-array" json:"string-array" yaml:"string-array,flow" toml:"string-array,multiline" watson:"string-array" msgpack:"string-array,as_array"`
-	Plurals      []*Plural      `xml:"plurals" json:"plurals" yaml:"plurals,flow" toml:"plurals,multiline" watson:"plurals" msgpack:"plurals,as_array"`
+	XMLName       xml.Name        `xml:"resources" json:"resources" yaml:"resources" toml:"resources" watson:"resources" msgpack:"resources"`
+	Strings       []*String       `xml:"string" json:"string" yaml:"string,flow" toml:"string,multiline" watson:"string" msgpack:"string,as_array"`
+	StringsArray  []*StringArray  `xml:"string-array" json:"string-array" yaml:"string-array,flow" toml:"string-array,multiline" watson:"string-array" msgpack:"string-array,as_array"`
+	Plurals       []*Plural       `xml:"plurals" json:"plurals" yaml:"plurals,flow" toml:"plurals,multiline" watson:"plurals" msgpack:"plurals,as_array"`
+	Integers      []*Integer      `xml:"integer" json:"integer,omitempty" yaml:"integer,flow,omitempty" toml:"integer,multiline,omitempty" watson:"integer,omitempty" msgpack:"integer,as_array,omitempty"`
+	Bools         []*Bool         `xml:"bool" json:"bool,omitempty" yaml:"bool,flow,omitempty" toml:"bool,multiline,omitempty" watson:"bool,omitempty" msgpack:"bool,as_array,omitempty"`
+	IntegerArrays []*IntegerArray `xml:"integer-array" json:"integer-array,omitempty" yaml:"integer-array,flow,omitempty" toml:"integer-array,multiline,omitempty" watson:"integer-array,omitempty" msgpack:"integer-array,as_array,omitempty"`
+	Arrays        []*Array        `xml:"array" json:"array,omitempty" yaml:"array,flow,omitempty" toml:"array,multiline,omitempty" watson:"array,omitempty" msgpack:"array,as_array,omitempty"`
 }
@@ -1,6 +1,11 @@
 package types
 
-import "github.com/genkami/watson"
+import (
+	"bytes"
+	"io"
+
+	"github.com/genkami/watson"
+)
 
 type WatsonStrategy struct{}
 
@@ -13,5 +18,29 @@ func (w *WatsonStrategy) decode(data []byte, v interface{}) error {
 		return nil
 	}
 
-	return watson.Unmarshal(data, v)
+	r := &watsonReader{Reader: bytes.NewReader(data)}
+	if err := watson.NewDecoder(r).Decode(v); err != nil {
+		pe := &ParseError{Err: err}
+		if !r.eof {
+			// The Watson lexer reads a byte at a time: the failing op is the last byte read.
+			pe.Line, pe.Column = position(data, r.Size()-int64(r.Len())-1)
+		}
+		return pe
+	}
+	return nil
+}
+
+// watsonReader records whether the decoder read all of the data, i.e. whether an error is
+// about an op or about the decoded value.
+type watsonReader struct {
+	*bytes.Reader
+	eof bool
+}
+
+func (r *watsonReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
 }
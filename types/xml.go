@@ -1,6 +1,9 @@
 package types
 
-import "encoding/xml"
+import (
+	"bytes"
+	"encoding/xml"
+)
 
 type XMLStrategy struct{}
 
@@ -13,5 +16,11 @@ func (x *XMLStrategy) decode(data []byte, v interface{}) error {
 		return nil
 	}
 
-	return xml.Unmarshal(data, v)
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(v); err != nil {
+		pe := &ParseError{Err: err}
+		pe.Line, pe.Column = dec.InputPos()
+		return pe
+	}
+	return nil
 }
@@ -15,5 +15,8 @@ func (y *YAMLStrategy) decode(data []byte, v interface{}) error {
 		return nil
 	}
 
-	return yaml.Unmarshal(data, v)
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return yamlError(err)
+	}
+	return nil
 }